@@ -1,7 +1,8 @@
 package aspsp
 
 import (
-	"github.com/pkg/errors"
+	"context"
+	"strings"
 	"time"
 )
 
@@ -13,29 +14,51 @@ type Account interface {
 	Nickname() string
 	AccountIdentity() AccountIdentity
 	Transactions(from, to time.Time) ([]Transaction, error)
+	TransactionsContext(ctx context.Context, from, to time.Time) ([]Transaction, error)
+	TransactionIterator(from, to time.Time) TransactionIterator
+	TransactionIteratorContext(ctx context.Context, from, to time.Time) TransactionIterator
+	Balances() ([]Balance, error)
+	BalancesContext(ctx context.Context) ([]Balance, error)
+	Products() ([]Product, error)
+	ProductsContext(ctx context.Context) ([]Product, error)
+	Offers() ([]Offer, error)
+	OffersContext(ctx context.Context) ([]Offer, error)
+	Party() (Party, error)
+	PartyContext(ctx context.Context) (Party, error)
+	Parties() ([]Party, error)
+	PartiesContext(ctx context.Context) ([]Party, error)
+	ScheduledPayments() ([]ScheduledPayment, error)
+	ScheduledPaymentsContext(ctx context.Context) ([]ScheduledPayment, error)
+	Statements(from, to time.Time) ([]Statement, error)
+	StatementsContext(ctx context.Context, from, to time.Time) ([]Statement, error)
+	StatementFile(statementId string) ([]byte, error)
+	StatementFileContext(ctx context.Context, statementId string) ([]byte, error)
+	StatementTransactions(statementId string) ([]Transaction, error)
+	StatementTransactionsContext(ctx context.Context, statementId string) ([]Transaction, error)
 }
 
 type AccountId string
 
 type account struct {
-	id                AccountId
-	currency          string
-	accType           string
-	subtype           string
-	nickname          string
-	identity          AccountIdentity
-	transactionLoader TransactionLoaderFunc
+	id        AccountId
+	currency  string
+	accType   string
+	subtype   string
+	nickname  string
+	identity  AccountIdentity
+	resources AccountResources
 }
 
-func NewAccount(id AccountId, currency, accType, subtype, nickname string, identity AccountIdentity, transactionLoader TransactionLoaderFunc) Account {
+// NewResourceAccount reads transactions and the other account resources from resources
+func NewResourceAccount(id AccountId, currency, accType, subtype, nickname string, identity AccountIdentity, resources AccountResources) Account {
 	return account{
-		id:                id,
-		currency:          currency,
-		accType:           accType,
-		subtype:           subtype,
-		nickname:          nickname,
-		identity:          identity,
-		transactionLoader: transactionLoader,
+		id:        id,
+		currency:  currency,
+		accType:   accType,
+		subtype:   subtype,
+		nickname:  nickname,
+		identity:  identity,
+		resources: resources,
 	}
 }
 
@@ -60,11 +83,95 @@ func (a account) Nickname() string {
 }
 
 func (a account) AccountIdentity() AccountIdentity {
-	return nil
+	return a.identity
 }
 
 func (a account) Transactions(from, to time.Time) ([]Transaction, error) {
-	return a.transactionLoader(from, to)
+	return a.resources.Transactions(context.Background(), a.id, from, to)
+}
+
+func (a account) TransactionsContext(ctx context.Context, from, to time.Time) ([]Transaction, error) {
+	return a.resources.Transactions(ctx, a.id, from, to)
+}
+
+func (a account) TransactionIterator(from, to time.Time) TransactionIterator {
+	return a.resources.TransactionIterator(context.Background(), a.id, from, to)
+}
+
+func (a account) TransactionIteratorContext(ctx context.Context, from, to time.Time) TransactionIterator {
+	return a.resources.TransactionIterator(ctx, a.id, from, to)
+}
+
+func (a account) Balances() ([]Balance, error) {
+	return a.resources.Balances(context.Background(), a.id)
+}
+
+func (a account) BalancesContext(ctx context.Context) ([]Balance, error) {
+	return a.resources.Balances(ctx, a.id)
+}
+
+func (a account) Products() ([]Product, error) {
+	return a.resources.Products(context.Background(), a.id)
+}
+
+func (a account) ProductsContext(ctx context.Context) ([]Product, error) {
+	return a.resources.Products(ctx, a.id)
+}
+
+func (a account) Offers() ([]Offer, error) {
+	return a.resources.Offers(context.Background(), a.id)
+}
+
+func (a account) OffersContext(ctx context.Context) ([]Offer, error) {
+	return a.resources.Offers(ctx, a.id)
+}
+
+func (a account) Party() (Party, error) {
+	return a.resources.Party(context.Background(), a.id)
+}
+
+func (a account) PartyContext(ctx context.Context) (Party, error) {
+	return a.resources.Party(ctx, a.id)
+}
+
+func (a account) Parties() ([]Party, error) {
+	return a.resources.Parties(context.Background(), a.id)
+}
+
+func (a account) PartiesContext(ctx context.Context) ([]Party, error) {
+	return a.resources.Parties(ctx, a.id)
+}
+
+func (a account) ScheduledPayments() ([]ScheduledPayment, error) {
+	return a.resources.ScheduledPayments(context.Background(), a.id)
+}
+
+func (a account) ScheduledPaymentsContext(ctx context.Context) ([]ScheduledPayment, error) {
+	return a.resources.ScheduledPayments(ctx, a.id)
+}
+
+func (a account) Statements(from, to time.Time) ([]Statement, error) {
+	return a.resources.Statements(context.Background(), a.id, from, to)
+}
+
+func (a account) StatementsContext(ctx context.Context, from, to time.Time) ([]Statement, error) {
+	return a.resources.Statements(ctx, a.id, from, to)
+}
+
+func (a account) StatementFile(statementId string) ([]byte, error) {
+	return a.resources.StatementFile(context.Background(), a.id, statementId)
+}
+
+func (a account) StatementFileContext(ctx context.Context, statementId string) ([]byte, error) {
+	return a.resources.StatementFile(ctx, a.id, statementId)
+}
+
+func (a account) StatementTransactions(statementId string) ([]Transaction, error) {
+	return a.resources.StatementTransactions(context.Background(), a.id, statementId)
+}
+
+func (a account) StatementTransactionsContext(ctx context.Context, statementId string) ([]Transaction, error) {
+	return a.resources.StatementTransactions(ctx, a.id, statementId)
 }
 
 type AccountIdentity interface {
@@ -113,19 +220,43 @@ func (ai accountIdentity) Servicer() string {
 	return ai.servicer
 }
 
-type TransactionLoaderFunc func(from, to time.Time) ([]Transaction, error)
+type Transaction struct {
+	Id        string
+	Reference string
+	Amount    Money
+	// CreditDebitIndicator is Credit or Debit as sent by the ASPSP, empty in transactions stored before it was kept
+	CreditDebitIndicator string
+	Status               string
+	BookingDateTime      time.Time
+	ValueDateTime        time.Time
+	Information          string
+	// BankTransactionCode is the ISO 20022 code and sub code, e.g. ReceivedCreditTransfers/DomesticCreditTransfer
+	BankTransactionCode            string
+	ProprietaryBankTransactionCode string
+	MerchantName                   string
+	MerchantCategoryCode           string
+	Counterparty                   Counterparty
+	// Categories are set by a categoriser, see package categorisation
+	Categories []string
+}
 
-func ErrNotImplementedTransactionLoaderFunc(_, _ time.Time) ([]Transaction, error) {
-	return nil, errors.New("transactions loader not implemented")
+// Counterparty is the creditor account of a debit or the debtor account of a credit
+type Counterparty struct {
+	SchemeName     string
+	Identification string
+	Name           string
 }
 
-type Transaction struct {
-	Id            string
-	Reference     string
-	Value         string
-	Currency      string
-	Credit        bool
-	Status        string
-	ValueDateTime time.Time
-	Information   string
+// Credit reports whether the transaction is a credit, without a CreditDebitIndicator its amount tells,
+// it is negative for debits
+func (t Transaction) Credit() bool {
+	if t.CreditDebitIndicator != "" {
+		return strings.EqualFold(t.CreditDebitIndicator, creditDebitIndicatorCredit)
+	}
+	return !t.Amount.IsNegative()
+}
+
+// Booked reports whether the transaction is booked, otherwise it is pending
+func (t Transaction) Booked() bool {
+	return strings.EqualFold(t.Status, transactionStatusBooked)
 }
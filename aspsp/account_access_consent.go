@@ -0,0 +1,85 @@
+package aspsp
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// AccountAccessConsenter checks and deletes the AIS consents authorised by authorization.Authenticator,
+// it needs a client credentials token, see authorization.IntentAuthenticator
+type AccountAccessConsenter interface {
+	Get(string) (AccountAccessConsent, error)
+	GetContext(context.Context, string) (AccountAccessConsent, error)
+	Delete(string) error
+	DeleteContext(context.Context, string) error
+}
+
+type AccountAccessConsent struct {
+	ConsentId            string
+	Status               string
+	CreationDateTime     time.Time
+	StatusUpdateDateTime time.Time
+	// ExpirationDateTime is zero for consents that do not expire
+	ExpirationDateTime time.Time
+	Permissions        []string
+}
+
+var NoAccountAccessConsent = AccountAccessConsent{}
+
+type AccountAccessConsentResponse struct {
+	Data AccountAccessConsentDataResponse `json:"Data"`
+}
+
+type AccountAccessConsentDataResponse struct {
+	ConsentId            string   `json:"ConsentId"`
+	Status               string   `json:"Status"`
+	CreationDateTime     string   `json:"CreationDateTime"`
+	StatusUpdateDateTime string   `json:"StatusUpdateDateTime"`
+	ExpirationDateTime   string   `json:"ExpirationDateTime"`
+	Permissions          []string `json:"Permissions"`
+}
+
+type accountAccessConsenter struct {
+	client ResourceClient
+}
+
+func NewAccountAccessConsenter(client ResourceClient) AccountAccessConsenter {
+	return &accountAccessConsenter{
+		client: client,
+	}
+}
+
+func (a *accountAccessConsenter) Get(consentId string) (AccountAccessConsent, error) {
+	return a.GetContext(context.Background(), consentId)
+}
+
+func (a *accountAccessConsenter) GetContext(ctx context.Context, consentId string) (AccountAccessConsent, error) {
+	var response AccountAccessConsentResponse
+	if err := a.client.GetContext(ctx, "/account-access-consents/"+url.PathEscape(consentId), &response); err != nil {
+		return NoAccountAccessConsent, errors.Wrap(err, "error getting account access consent")
+	}
+
+	return AccountAccessConsent{
+		ConsentId:            response.Data.ConsentId,
+		Status:               response.Data.Status,
+		CreationDateTime:     parseDateTime(response.Data.CreationDateTime),
+		StatusUpdateDateTime: parseDateTime(response.Data.StatusUpdateDateTime),
+		ExpirationDateTime:   parseDateTime(response.Data.ExpirationDateTime),
+		Permissions:          response.Data.Permissions,
+	}, nil
+}
+
+func (a *accountAccessConsenter) Delete(consentId string) error {
+	return a.DeleteContext(context.Background(), consentId)
+}
+
+func (a *accountAccessConsenter) DeleteContext(ctx context.Context, consentId string) error {
+	if err := a.client.DoContext(ctx, http.MethodDelete, "/account-access-consents/"+url.PathEscape(consentId), nil, nil); err != nil {
+		return errors.Wrap(err, "error deleting account access consent")
+	}
+
+	return nil
+}
@@ -1,98 +1,93 @@
 package aspsp
 
 import (
-	"encoding/json"
-	"fmt"
-	"github.com/google/uuid"
-	"github.com/jmatosp/obclient/authorization"
+	"context"
 	"github.com/pkg/errors"
-	"io/ioutil"
-	"net/http"
 )
 
 type AccountLister interface {
 	List() ([]Account, error)
+	ListContext(context.Context) ([]Account, error)
+	Get(AccountId) (Account, error)
+	GetContext(context.Context, AccountId) (Account, error)
 }
 
 type accountLister struct {
-	transport       authorization.Transport
-	endpoint        string
-	fapiFinancialId string
-	accessToken     authorization.Token
+	client ResourceClient
 }
 
-func NewAccountLister(transport authorization.Transport, endpoint, fapiFinancialId string, accessToken authorization.Token) AccountLister {
+func NewAccountLister(client ResourceClient) AccountLister {
 	return &accountLister{
-		transport:       transport,
-		endpoint:        endpoint,
-		fapiFinancialId: fapiFinancialId,
-		accessToken:     accessToken,
+		client: client,
 	}
 }
 
 func (a *accountLister) List() ([]Account, error) {
-	client, err := a.transport.Client()
-	if err != nil {
-		return []Account{}, errors.Wrap(err, "error listing accounts")
-	}
+	return a.ListContext(context.Background())
+}
 
-	request, err := http.NewRequest(http.MethodGet, a.endpoint+"/accounts", nil)
-	if err != nil {
+func (a *accountLister) ListContext(ctx context.Context) ([]Account, error) {
+	var accountsResponse AccountsResponse
+	if err := a.client.GetContext(ctx, "/accounts", &accountsResponse); err != nil {
 		return []Account{}, errors.Wrap(err, "error listing accounts")
 	}
-	request.Header.Set("Authorization", "Bearer "+a.accessToken.AccessToken)
-	request.Header.Set("Content-Type", "application/json")
-	request.Header.Set("x-fapi-financial-id", a.fapiFinancialId)
-	request.Header.Set("x-fapi-interaction-id", uuid.New().String())
 
-	response, err := client.Do(request)
-	if err != nil {
-		return []Account{}, errors.Wrap(err, "error listing accounts")
+	var accounts []Account
+	for _, account := range accountsResponse.Data.Account {
+		accounts = append(accounts, a.mapAccounts(account))
 	}
 
-	if response.StatusCode != http.StatusOK {
-		body, _ := ioutil.ReadAll(response.Body)
-		fmt.Println(string(body))
-		return []Account{}, errors.Wrap(err, "error listing accounts")
-	}
+	return accounts, nil
+}
+
+func (a *accountLister) Get(accountId AccountId) (Account, error) {
+	return a.GetContext(context.Background(), accountId)
+}
 
+func (a *accountLister) GetContext(ctx context.Context, accountId AccountId) (Account, error) {
 	var accountsResponse AccountsResponse
-	if err = json.NewDecoder(response.Body).Decode(&accountsResponse); err != nil {
-		return []Account{}, errors.Wrap(err, "error listing accounts")
+	if err := a.client.GetContext(ctx, accountPath(accountId, ""), &accountsResponse); err != nil {
+		return nil, errors.Wrap(err, "error getting account")
 	}
 
-	var accounts []Account
-	for _, account := range accountsResponse.Data.Account {
-		accounts = append(accounts, mapAccounts(account))
+	if len(accountsResponse.Data.Account) == 0 {
+		return nil, errors.Errorf("error getting account: account %s not found", accountId)
 	}
 
-	return accounts, nil
+	return a.mapAccounts(accountsResponse.Data.Account[0]), nil
 }
 
 type AccountsResponse struct {
-	Data AccountsDataResponse `json:"data"`
+	Data AccountsDataResponse `json:"Data"`
 }
 
 type AccountsDataResponse struct {
-	Account []AccountsDataAccountResponse `json:"account"`
+	Account []AccountsDataAccountResponse `json:"Account"`
 }
 
 type AccountsDataAccountResponse struct {
-	AccountId      string `json:"AccountId"`
-	Currency       string `json:"Currency"`
-	Nickname       string `json:"Nickname"`
-	AccountType    string `json:"AccountType"`
-	AccountSubType string `json:"AccountSubType"`
+	AccountId      string                `json:"AccountId"`
+	Currency       string                `json:"Currency"`
+	Nickname       string                `json:"Nickname"`
+	AccountType    string                `json:"AccountType"`
+	AccountSubType string                `json:"AccountSubType"`
+	Account        []CashAccountResponse `json:"Account"`
+	Servicer       *AgentResponse        `json:"Servicer"`
 }
 
-func mapAccounts(account AccountsDataAccountResponse) Account {
-	return NewAccount(
+func (a *accountLister) mapAccounts(account AccountsDataAccountResponse) Account {
+	identity := NewAccountIdentity("", "", "", "", "")
+	if len(account.Account) > 0 {
+		identity = mapAccountIdentity(&account.Account[0], account.Servicer)
+	}
+
+	return NewResourceAccount(
 		AccountId(account.AccountId),
 		account.Currency,
 		account.AccountType,
 		account.AccountSubType,
 		account.Nickname,
-		NewAccountIdentity("", "", "", "", ""),
-		ErrNotImplementedTransactionLoaderFunc,
+		identity,
+		NewAccountResources(a.client),
 	)
 }
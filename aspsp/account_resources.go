@@ -0,0 +1,360 @@
+package aspsp
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"net/url"
+	"time"
+)
+
+// AccountResources loads the ASPSP resources of an account
+type AccountResources interface {
+	Transactions(ctx context.Context, accountId AccountId, from, to time.Time) ([]Transaction, error)
+	TransactionIterator(ctx context.Context, accountId AccountId, from, to time.Time) TransactionIterator
+	Balances(ctx context.Context, accountId AccountId) ([]Balance, error)
+	Products(ctx context.Context, accountId AccountId) ([]Product, error)
+	Offers(ctx context.Context, accountId AccountId) ([]Offer, error)
+	Party(ctx context.Context, accountId AccountId) (Party, error)
+	Parties(ctx context.Context, accountId AccountId) ([]Party, error)
+	ScheduledPayments(ctx context.Context, accountId AccountId) ([]ScheduledPayment, error)
+	Statements(ctx context.Context, accountId AccountId, from, to time.Time) ([]Statement, error)
+	StatementFile(ctx context.Context, accountId AccountId, statementId string) ([]byte, error)
+	StatementTransactions(ctx context.Context, accountId AccountId, statementId string) ([]Transaction, error)
+}
+
+type accountResources struct {
+	client ResourceClient
+}
+
+func NewAccountResources(client ResourceClient) AccountResources {
+	return &accountResources{
+		client: client,
+	}
+}
+
+func (r *accountResources) Transactions(ctx context.Context, accountId AccountId, from, to time.Time) ([]Transaction, error) {
+	return collectTransactions(r.TransactionIterator(ctx, accountId, from, to))
+}
+
+func (r *accountResources) TransactionIterator(ctx context.Context, accountId AccountId, from, to time.Time) TransactionIterator {
+	var fromTime, toTime *time.Time
+	if !from.IsZero() {
+		fromTime = &from
+	}
+	if !to.IsZero() {
+		toTime = &to
+	}
+	return NewTransactionLister(r.client, accountId).IterateContext(ctx, toTime, fromTime)
+}
+
+func (r *accountResources) Balances(ctx context.Context, accountId AccountId) ([]Balance, error) {
+	var balancesResponse BalancesResponse
+	if err := r.client.GetContext(ctx, accountPath(accountId, "/balances"), &balancesResponse); err != nil {
+		return []Balance{}, errors.Wrap(err, "error listing balances")
+	}
+
+	var balances []Balance
+	for _, balance := range balancesResponse.Data.Balance {
+		amount, err := signedMoney(balance.Amount, balance.CreditDebitIndicator)
+		if err != nil {
+			return []Balance{}, errors.Wrap(err, "error listing balances")
+		}
+		balances = append(balances, Balance{
+			AccountId: AccountId(balance.AccountId),
+			Type:      balance.Type,
+			Amount:    amount,
+			DateTime:  parseDateTime(balance.DateTime),
+		})
+	}
+
+	return balances, nil
+}
+
+func (r *accountResources) Products(ctx context.Context, accountId AccountId) ([]Product, error) {
+	var productsResponse ProductsResponse
+	if err := r.client.GetContext(ctx, accountPath(accountId, "/product"), &productsResponse); err != nil {
+		return []Product{}, errors.Wrap(err, "error getting products")
+	}
+
+	var products []Product
+	for _, product := range productsResponse.Data.Product {
+		products = append(products, Product{
+			Id:          product.ProductId,
+			AccountId:   AccountId(product.AccountId),
+			Name:        product.ProductName,
+			Type:        product.ProductType,
+			SecondaryId: product.SecondaryProductId,
+		})
+	}
+
+	return products, nil
+}
+
+func (r *accountResources) Offers(ctx context.Context, accountId AccountId) ([]Offer, error) {
+	var offersResponse OffersResponse
+	if err := r.client.GetContext(ctx, accountPath(accountId, "/offers"), &offersResponse); err != nil {
+		return []Offer{}, errors.Wrap(err, "error listing offers")
+	}
+
+	var offers []Offer
+	for _, offer := range offersResponse.Data.Offer {
+		amount, err := optionalMoney(offer.Amount)
+		if err != nil {
+			return []Offer{}, errors.Wrap(err, "error listing offers")
+		}
+		offers = append(offers, Offer{
+			Id:            offer.OfferId,
+			AccountId:     AccountId(offer.AccountId),
+			Type:          offer.OfferType,
+			Description:   offer.Description,
+			StartDateTime: parseDateTime(offer.StartDateTime),
+			EndDateTime:   parseDateTime(offer.EndDateTime),
+			Rate:          offer.Rate,
+			Term:          offer.Term,
+			URL:           offer.URL,
+			Amount:        amount,
+		})
+	}
+
+	return offers, nil
+}
+
+func (r *accountResources) Party(ctx context.Context, accountId AccountId) (Party, error) {
+	var partyResponse PartyResponse
+	if err := r.client.GetContext(ctx, accountPath(accountId, "/party"), &partyResponse); err != nil {
+		return NoParty, errors.Wrap(err, "error getting party")
+	}
+
+	return mapParty(partyResponse.Data.Party), nil
+}
+
+func (r *accountResources) Parties(ctx context.Context, accountId AccountId) ([]Party, error) {
+	var partiesResponse PartiesResponse
+	if err := r.client.GetContext(ctx, accountPath(accountId, "/parties"), &partiesResponse); err != nil {
+		return []Party{}, errors.Wrap(err, "error listing parties")
+	}
+
+	var parties []Party
+	for _, party := range partiesResponse.Data.Party {
+		parties = append(parties, mapParty(party))
+	}
+
+	return parties, nil
+}
+
+func (r *accountResources) ScheduledPayments(ctx context.Context, accountId AccountId) ([]ScheduledPayment, error) {
+	var scheduledPaymentsResponse ScheduledPaymentsResponse
+	if err := r.client.GetContext(ctx, accountPath(accountId, "/scheduled-payments"), &scheduledPaymentsResponse); err != nil {
+		return []ScheduledPayment{}, errors.Wrap(err, "error listing scheduled payments")
+	}
+
+	var scheduledPayments []ScheduledPayment
+	for _, scheduledPayment := range scheduledPaymentsResponse.Data.ScheduledPayment {
+		amount, err := ParseMoney(scheduledPayment.InstructedAmount.Amount, scheduledPayment.InstructedAmount.Currency)
+		if err != nil {
+			return []ScheduledPayment{}, errors.Wrap(err, "error listing scheduled payments")
+		}
+		scheduledPayments = append(scheduledPayments, ScheduledPayment{
+			Id:        scheduledPayment.ScheduledPaymentId,
+			AccountId: AccountId(scheduledPayment.AccountId),
+			DateTime:  parseDateTime(scheduledPayment.ScheduledPaymentDateTime),
+			Type:      scheduledPayment.ScheduledType,
+			Reference: scheduledPayment.Reference,
+			Amount:    amount,
+			Creditor:  mapAccountIdentity(scheduledPayment.CreditorAccount, scheduledPayment.CreditorAgent),
+		})
+	}
+
+	return scheduledPayments, nil
+}
+
+func (r *accountResources) Statements(ctx context.Context, accountId AccountId, from, to time.Time) ([]Statement, error) {
+	query := url.Values{}
+	if !from.IsZero() {
+		query.Set("fromStatementDateTime", from.Format(queryDateTimeLayout))
+	}
+	if !to.IsZero() {
+		query.Set("toStatementDateTime", to.Format(queryDateTimeLayout))
+	}
+
+	path := accountPath(accountId, "/statements")
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var statementsResponse StatementsResponse
+	if err := r.client.GetContext(ctx, path, &statementsResponse); err != nil {
+		return []Statement{}, errors.Wrap(err, "error listing statements")
+	}
+
+	var statements []Statement
+	for _, statement := range statementsResponse.Data.Statement {
+		statements = append(statements, Statement{
+			Id:               statement.StatementId,
+			AccountId:        AccountId(statement.AccountId),
+			Reference:        statement.StatementReference,
+			Type:             statement.Type,
+			StartDateTime:    parseDateTime(statement.StartDateTime),
+			EndDateTime:      parseDateTime(statement.EndDateTime),
+			CreationDateTime: parseDateTime(statement.CreationDateTime),
+			Description:      statement.StatementDescription,
+		})
+	}
+
+	return statements, nil
+}
+
+func (r *accountResources) StatementFile(ctx context.Context, accountId AccountId, statementId string) ([]byte, error) {
+	file, err := r.client.DownloadContext(ctx, accountPath(accountId, "/statements/"+url.PathEscape(statementId)+"/file"), "application/pdf")
+	if err != nil {
+		return nil, errors.Wrap(err, "error downloading statement file")
+	}
+
+	return file, nil
+}
+
+func (r *accountResources) StatementTransactions(ctx context.Context, accountId AccountId, statementId string) ([]Transaction, error) {
+	transactions, err := collectTransactions(NewTransactionIterator(ctx, r.client, accountPath(accountId, "/statements/"+url.PathEscape(statementId)+"/transactions")))
+	if err != nil {
+		return []Transaction{}, errors.Wrap(err, "error listing statement transactions")
+	}
+
+	return transactions, nil
+}
+
+func accountPath(accountId AccountId, resource string) string {
+	return "/accounts/" + url.PathEscape(string(accountId)) + resource
+}
+
+type BalancesResponse struct {
+	Data BalancesDataResponse `json:"Data"`
+}
+
+type BalancesDataResponse struct {
+	Balance []BalanceResponse `json:"Balance"`
+}
+
+type BalanceResponse struct {
+	AccountId            string         `json:"AccountId"`
+	Type                 string         `json:"Type"`
+	CreditDebitIndicator string         `json:"CreditDebitIndicator"`
+	DateTime             string         `json:"DateTime"`
+	Amount               AmountResponse `json:"Amount"`
+}
+
+type ProductsResponse struct {
+	Data ProductsDataResponse `json:"Data"`
+}
+
+type ProductsDataResponse struct {
+	Product []ProductResponse `json:"Product"`
+}
+
+type ProductResponse struct {
+	AccountId          string `json:"AccountId"`
+	ProductId          string `json:"ProductId"`
+	ProductName        string `json:"ProductName"`
+	ProductType        string `json:"ProductType"`
+	SecondaryProductId string `json:"SecondaryProductId"`
+}
+
+type OffersResponse struct {
+	Data OffersDataResponse `json:"Data"`
+}
+
+type OffersDataResponse struct {
+	Offer []OfferResponse `json:"Offer"`
+}
+
+type OfferResponse struct {
+	AccountId     string         `json:"AccountId"`
+	OfferId       string         `json:"OfferId"`
+	OfferType     string         `json:"OfferType"`
+	Description   string         `json:"Description"`
+	StartDateTime string         `json:"StartDateTime"`
+	EndDateTime   string         `json:"EndDateTime"`
+	Rate          string         `json:"Rate"`
+	Term          string         `json:"Term"`
+	URL           string         `json:"URL"`
+	Amount        AmountResponse `json:"Amount"`
+}
+
+type PartyResponse struct {
+	Data PartyDataResponse `json:"Data"`
+}
+
+type PartyDataResponse struct {
+	Party PartyDetailResponse `json:"Party"`
+}
+
+type PartiesResponse struct {
+	Data PartiesDataResponse `json:"Data"`
+}
+
+type PartiesDataResponse struct {
+	Party []PartyDetailResponse `json:"Party"`
+}
+
+type PartyDetailResponse struct {
+	PartyId       string `json:"PartyId"`
+	PartyNumber   string `json:"PartyNumber"`
+	PartyType     string `json:"PartyType"`
+	Name          string `json:"Name"`
+	FullLegalName string `json:"FullLegalName"`
+	EmailAddress  string `json:"EmailAddress"`
+	Phone         string `json:"Phone"`
+	Mobile        string `json:"Mobile"`
+	AccountRole   string `json:"AccountRole"`
+}
+
+func mapParty(party PartyDetailResponse) Party {
+	return Party{
+		Id:            party.PartyId,
+		Number:        party.PartyNumber,
+		Type:          party.PartyType,
+		Name:          party.Name,
+		FullLegalName: party.FullLegalName,
+		EmailAddress:  party.EmailAddress,
+		Phone:         party.Phone,
+		Mobile:        party.Mobile,
+		AccountRole:   party.AccountRole,
+	}
+}
+
+type ScheduledPaymentsResponse struct {
+	Data ScheduledPaymentsDataResponse `json:"Data"`
+}
+
+type ScheduledPaymentsDataResponse struct {
+	ScheduledPayment []ScheduledPaymentResponse `json:"ScheduledPayment"`
+}
+
+type ScheduledPaymentResponse struct {
+	AccountId                string               `json:"AccountId"`
+	ScheduledPaymentId       string               `json:"ScheduledPaymentId"`
+	ScheduledPaymentDateTime string               `json:"ScheduledPaymentDateTime"`
+	ScheduledType            string               `json:"ScheduledType"`
+	Reference                string               `json:"Reference"`
+	InstructedAmount         AmountResponse       `json:"InstructedAmount"`
+	CreditorAgent            *AgentResponse       `json:"CreditorAgent"`
+	CreditorAccount          *CashAccountResponse `json:"CreditorAccount"`
+}
+
+type StatementsResponse struct {
+	Data StatementsDataResponse `json:"Data"`
+}
+
+type StatementsDataResponse struct {
+	Statement []StatementResponse `json:"Statement"`
+}
+
+type StatementResponse struct {
+	AccountId            string   `json:"AccountId"`
+	StatementId          string   `json:"StatementId"`
+	StatementReference   string   `json:"StatementReference"`
+	Type                 string   `json:"Type"`
+	StartDateTime        string   `json:"StartDateTime"`
+	EndDateTime          string   `json:"EndDateTime"`
+	CreationDateTime     string   `json:"CreationDateTime"`
+	StatementDescription []string `json:"StatementDescription"`
+}
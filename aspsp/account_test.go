@@ -0,0 +1,42 @@
+package aspsp
+
+import "testing"
+
+func TestTransactionCredit(t *testing.T) {
+	tests := []struct {
+		name        string
+		transaction Transaction
+		want        bool
+	}{
+		{name: "credit", transaction: Transaction{Amount: NewMoney(100, "GBP"), CreditDebitIndicator: "Credit"}, want: true},
+		{name: "debit", transaction: Transaction{Amount: NewMoney(-100, "GBP"), CreditDebitIndicator: "Debit"}, want: false},
+		{name: "zero amount debit", transaction: Transaction{Amount: NewMoney(0, "GBP"), CreditDebitIndicator: "Debit"}, want: false},
+		{name: "indicator case", transaction: Transaction{Amount: NewMoney(0, "GBP"), CreditDebitIndicator: "credit"}, want: true},
+		{name: "stored without indicator, positive", transaction: Transaction{Amount: NewMoney(100, "GBP")}, want: true},
+		{name: "stored without indicator, negative", transaction: Transaction{Amount: NewMoney(-100, "GBP")}, want: false},
+	}
+	for _, test := range tests {
+		if got := test.transaction.Credit(); got != test.want {
+			t.Errorf("%s: Credit() = %t, want %t", test.name, got, test.want)
+		}
+	}
+}
+
+func TestMapTransactionZeroDebit(t *testing.T) {
+	transaction, err := mapTransaction(TransactionResponse{
+		TransactionId:        "127",
+		CreditDebitIndicator: "Debit",
+		Amount:               AmountResponse{Amount: "0.00", Currency: "GBP"},
+		CreditorAccount:      &CashAccountResponse{Name: "Payee"},
+		DebtorAccount:        &CashAccountResponse{Name: "Payer"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transaction.Credit() {
+		t.Error("zero amount debit reported as a credit")
+	}
+	if transaction.Counterparty.Name != "Payee" {
+		t.Errorf("counterparty %s, want the creditor of a debit", transaction.Counterparty.Name)
+	}
+}
@@ -1,39 +1,23 @@
 package aspsp
 
 import (
-	"fmt"
+	"io"
 	"os"
-	"text/tabwriter"
 )
 
+// AccountsPrinter prints accounts as a table to stdout
+//
+// Deprecated: use NewPrinter with AccountsTable
 type AccountsPrinter struct {
-	w *tabwriter.Writer
+	w io.Writer
 }
 
+// Deprecated: use NewPrinter(FormatTable)
 func NewAccountsPrinter() AccountsPrinter {
-	return AccountsPrinter{
-		tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', tabwriter.AlignRight|tabwriter.Debug),
-	}
+	return AccountsPrinter{os.Stdout}
 }
 
 func (a AccountsPrinter) Print(accounts []Account) {
-	a.header()
-	for _, account := range accounts {
-		a.accountPrint(account)
-	}
-	a.w.Flush()
-}
-
-func (a AccountsPrinter) header() {
-	fmt.Fprintf(a.w, "Id\tCurrency\tNickname\tType\tSubType\n")
-}
-
-func (a AccountsPrinter) accountPrint(account Account) {
-	fmt.Fprintf(a.w, "%s\t%s\t%s\t%s\t%s\n",
-		account.Id(),
-		account.Currency(),
-		account.Nickname(),
-		account.Type(),
-		account.Subtype(),
-	)
+	printer, _ := NewPrinter(FormatTable)
+	printer.Print(a.w, AccountsTable(accounts))
 }
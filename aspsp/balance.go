@@ -0,0 +1,15 @@
+package aspsp
+
+import "time"
+
+type Balance struct {
+	AccountId AccountId
+	Type      string
+	Amount    Money
+	DateTime  time.Time
+}
+
+// Credit reports whether the balance is in credit, its amount is negative otherwise
+func (b Balance) Credit() bool {
+	return !b.Amount.IsNegative()
+}
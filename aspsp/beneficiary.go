@@ -0,0 +1,8 @@
+package aspsp
+
+type Beneficiary struct {
+	Id        string
+	AccountId AccountId
+	Reference string
+	Creditor  AccountIdentity
+}
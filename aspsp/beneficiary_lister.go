@@ -0,0 +1,78 @@
+package aspsp
+
+import (
+	"context"
+	"github.com/pkg/errors"
+)
+
+type BeneficiaryLister interface {
+	List(AccountId) ([]Beneficiary, error)
+	ListContext(context.Context, AccountId) ([]Beneficiary, error)
+	ListAll() ([]Beneficiary, error)
+	ListAllContext(context.Context) ([]Beneficiary, error)
+}
+
+type beneficiaryLister struct {
+	client ResourceClient
+}
+
+func NewBeneficiaryLister(client ResourceClient) BeneficiaryLister {
+	return &beneficiaryLister{
+		client: client,
+	}
+}
+
+func (b *beneficiaryLister) List(accountId AccountId) ([]Beneficiary, error) {
+	return b.ListContext(context.Background(), accountId)
+}
+
+func (b *beneficiaryLister) ListContext(ctx context.Context, accountId AccountId) ([]Beneficiary, error) {
+	return b.list(ctx, accountPath(accountId, "/beneficiaries"))
+}
+
+func (b *beneficiaryLister) ListAll() ([]Beneficiary, error) {
+	return b.ListAllContext(context.Background())
+}
+
+func (b *beneficiaryLister) ListAllContext(ctx context.Context) ([]Beneficiary, error) {
+	return b.list(ctx, "/beneficiaries")
+}
+
+func (b *beneficiaryLister) list(ctx context.Context, path string) ([]Beneficiary, error) {
+	var beneficiariesResponse BeneficiariesResponse
+	if err := b.client.GetContext(ctx, path, &beneficiariesResponse); err != nil {
+		return []Beneficiary{}, errors.Wrap(err, "error listing beneficiaries")
+	}
+
+	var beneficiaries []Beneficiary
+	for _, beneficiary := range beneficiariesResponse.Data.Beneficiary {
+		beneficiaries = append(beneficiaries, mapBeneficiary(beneficiary))
+	}
+
+	return beneficiaries, nil
+}
+
+type BeneficiariesResponse struct {
+	Data BeneficiariesDataResponse `json:"Data"`
+}
+
+type BeneficiariesDataResponse struct {
+	Beneficiary []BeneficiaryResponse `json:"Beneficiary"`
+}
+
+type BeneficiaryResponse struct {
+	AccountId       string               `json:"AccountId"`
+	BeneficiaryId   string               `json:"BeneficiaryId"`
+	Reference       string               `json:"Reference"`
+	CreditorAgent   *AgentResponse       `json:"CreditorAgent"`
+	CreditorAccount *CashAccountResponse `json:"CreditorAccount"`
+}
+
+func mapBeneficiary(beneficiary BeneficiaryResponse) Beneficiary {
+	return Beneficiary{
+		Id:        beneficiary.BeneficiaryId,
+		AccountId: AccountId(beneficiary.AccountId),
+		Reference: beneficiary.Reference,
+		Creditor:  mapAccountIdentity(beneficiary.CreditorAccount, beneficiary.CreditorAgent),
+	}
+}
@@ -0,0 +1,112 @@
+package aspsp
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"net/http"
+	"net/url"
+)
+
+// CallbackURLRegister manages the urls the ASPSP posts event notifications to, below
+// EventReceiverPath, it needs a client credentials token, see authorization.IntentAuthenticator
+type CallbackURLRegister interface {
+	Register(CallbackURL) (CallbackURL, error)
+	RegisterContext(context.Context, CallbackURL) (CallbackURL, error)
+	List() ([]CallbackURL, error)
+	ListContext(context.Context) ([]CallbackURL, error)
+	Delete(string) error
+	DeleteContext(context.Context, string) error
+}
+
+// CallbackURL is a registered url, Version is the event notification API version, e.g. 3.1
+type CallbackURL struct {
+	Id      string
+	URL     string
+	Version string
+}
+
+var NoCallbackURL = CallbackURL{}
+
+type CallbackURLData struct {
+	CallbackUrlId string `json:"CallbackUrlId,omitempty"`
+	Url           string `json:"Url"`
+	Version       string `json:"Version"`
+}
+
+type CallbackURLRequest struct {
+	Data CallbackURLData `json:"Data"`
+}
+
+type CallbackURLResponse struct {
+	Data CallbackURLData `json:"Data"`
+}
+
+type CallbackURLsResponse struct {
+	Data struct {
+		CallbackUrl []CallbackURLData `json:"CallbackUrl"`
+	} `json:"Data"`
+}
+
+type callbackURLRegister struct {
+	client ResourceClient
+}
+
+func NewCallbackURLRegister(client ResourceClient) CallbackURLRegister {
+	return &callbackURLRegister{
+		client: client,
+	}
+}
+
+func (c *callbackURLRegister) Register(callback CallbackURL) (CallbackURL, error) {
+	return c.RegisterContext(context.Background(), callback)
+}
+
+func (c *callbackURLRegister) RegisterContext(ctx context.Context, callback CallbackURL) (CallbackURL, error) {
+	if callback.URL == "" || callback.Version == "" {
+		return NoCallbackURL, errors.New("error registering callback url: url and version required")
+	}
+
+	var response CallbackURLResponse
+	request := CallbackURLRequest{Data: CallbackURLData{Url: callback.URL, Version: callback.Version}}
+	if err := c.client.PostContext(ctx, "/callback-urls", request, &response); err != nil {
+		return NoCallbackURL, errors.Wrap(err, "error registering callback url")
+	}
+
+	return mapCallbackURL(response.Data), nil
+}
+
+func (c *callbackURLRegister) List() ([]CallbackURL, error) {
+	return c.ListContext(context.Background())
+}
+
+func (c *callbackURLRegister) ListContext(ctx context.Context) ([]CallbackURL, error) {
+	var response CallbackURLsResponse
+	if err := c.client.GetContext(ctx, "/callback-urls", &response); err != nil {
+		return nil, errors.Wrap(err, "error listing callback urls")
+	}
+
+	var callbacks []CallbackURL
+	for _, data := range response.Data.CallbackUrl {
+		callbacks = append(callbacks, mapCallbackURL(data))
+	}
+	return callbacks, nil
+}
+
+func (c *callbackURLRegister) Delete(id string) error {
+	return c.DeleteContext(context.Background(), id)
+}
+
+func (c *callbackURLRegister) DeleteContext(ctx context.Context, id string) error {
+	if err := c.client.DoContext(ctx, http.MethodDelete, "/callback-urls/"+url.PathEscape(id), nil, nil); err != nil {
+		return errors.Wrap(err, "error deleting callback url")
+	}
+	return nil
+}
+
+func mapCallbackURL(data CallbackURLData) CallbackURL {
+	return CallbackURL{
+		Id:      data.CallbackUrlId,
+		URL:     data.Url,
+		Version: data.Version,
+	}
+}
@@ -0,0 +1,257 @@
+package aspsp
+
+import (
+	"encoding/xml"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	camt053Namespace      = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"
+	camt053DateTimeLayout = "2006-01-02T15:04:05"
+)
+
+var bicPattern = regexp.MustCompile(`^[A-Z]{6}[A-Z2-9][A-NP-Z0-9]([A-Z0-9]{3})?$`)
+
+// camt053BalanceCodes maps OB balance types to ISO 20022 balance type codes
+var camt053BalanceCodes = map[string]string{
+	"ClosingAvailable":       "CLAV",
+	"ClosingBooked":          "CLBD",
+	"ClosingCleared":         "CLBD",
+	"Expected":               "XPCD",
+	"ForwardAvailable":       "FWAV",
+	"Information":            "INFO",
+	"InterimAvailable":       "ITAV",
+	"InterimBooked":          "ITBD",
+	"InterimCleared":         "ITBD",
+	"OpeningAvailable":       "OPAV",
+	"OpeningBooked":          "OPBD",
+	"OpeningCleared":         "OPBD",
+	"PreviouslyClosedBooked": "PRCD",
+}
+
+// camt053Exporter writes ISO 20022 camt.053.001.02 bank to customer statements
+type camt053Exporter struct{}
+
+func (camt053Exporter) Export(w io.Writer, statement ExportStatement) error {
+	now := time.Now()
+	identity := statement.identity()
+	currency := statement.Account.Currency()
+
+	camtStatement := camtStatement{
+		Id:       uuid.New().String(),
+		Created:  now.Format(camt053DateTimeLayout),
+		FromDate: statement.From.Format(camt053DateTimeLayout),
+		ToDate:   statement.To.Format(camt053DateTimeLayout),
+		Account: camtAccount{
+			Id:       camtAccountId(identity),
+			Currency: currency,
+			Name:     identity.Name(),
+			Servicer: camtServicer(identity.Servicer()),
+		},
+	}
+
+	for _, balance := range statement.Balances {
+		code, ok := camt053BalanceCodes[balance.Type]
+		if !ok {
+			continue
+		}
+		camtStatement.Balances = append(camtStatement.Balances, camtBalance{
+			Code:   code,
+			Amount: camtAmount{Currency: balance.Amount.Currency(), Value: balance.Amount.Abs().Amount()},
+			Credit: camtCreditDebit(balance.Credit()),
+			Date:   balance.DateTime.Format(camt053DateTimeLayout),
+		})
+	}
+
+	for _, transaction := range statement.bookedTransactions() {
+		camtStatement.Entries = append(camtStatement.Entries, camtEntry{
+			Reference:           transaction.Id,
+			Amount:              camtAmount{Currency: transaction.Amount.Currency(), Value: transaction.Amount.Abs().Amount()},
+			Credit:              camtCreditDebit(transaction.Credit()),
+			Status:              "BOOK",
+			BookingDate:         transaction.BookingDateTime.Format(camt053DateTimeLayout),
+			ValueDate:           camtDate(transaction.ValueDateTime),
+			ServicerRef:         transaction.Id,
+			BankTransactionCode: camtBankTransactionCode{Code: "NOTPROVIDED"},
+			Details:             camtDetails(transaction),
+			AdditionalInfo:      truncate(strings.Join(transaction.Categories, ", "), 500),
+		})
+	}
+
+	document := camtDocument{
+		Namespace: camt053Namespace,
+		Header: camtGroupHeader{
+			MessageId: uuid.New().String(),
+			Created:   now.Format(camt053DateTimeLayout),
+		},
+		Statement: camtStatement,
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return errors.Wrap(err, "error exporting camt.053")
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(document); err != nil {
+		return errors.Wrap(err, "error exporting camt.053")
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return errors.Wrap(err, "error exporting camt.053")
+}
+
+func camtAccountId(identity AccountIdentity) camtAccountIdentification {
+	if identity.SchemaName() == schemeIBAN {
+		return camtAccountIdentification{IBAN: identity.Identification()}
+	}
+	return camtAccountIdentification{
+		Other: &camtOtherIdentification{
+			Id:     identity.Identification(),
+			Scheme: camtSchemeName(identity.SchemaName()),
+		},
+	}
+}
+
+func camtSchemeName(scheme string) *camtScheme {
+	if scheme == "" {
+		return nil
+	}
+	return &camtScheme{Proprietary: scheme}
+}
+
+func camtServicer(servicer string) *camtFinancialInstitution {
+	if servicer == "" {
+		return nil
+	}
+	if bicPattern.MatchString(servicer) {
+		return &camtFinancialInstitution{BIC: servicer}
+	}
+	return &camtFinancialInstitution{Other: &camtOtherIdentification{Id: servicer}}
+}
+
+func camtCreditDebit(credit bool) string {
+	if credit {
+		return "CRDT"
+	}
+	return "DBIT"
+}
+
+func camtDate(date time.Time) *camtDateTime {
+	if date.IsZero() {
+		return nil
+	}
+	return &camtDateTime{DateTime: date.Format(camt053DateTimeLayout)}
+}
+
+func camtDetails(transaction Transaction) camtTransactionDetails {
+	var details camtTransactionDetails
+	if transaction.Reference != "" {
+		details.References = &camtReferences{EndToEndId: truncate(transaction.Reference, 35)}
+	}
+	if transaction.Information != "" {
+		details.Remittance = &camtRemittance{Unstructured: truncate(transaction.Information, 140)}
+	}
+	return details
+}
+
+type camtDocument struct {
+	XMLName   xml.Name        `xml:"Document"`
+	Namespace string          `xml:"xmlns,attr"`
+	Header    camtGroupHeader `xml:"BkToCstmrStmt>GrpHdr"`
+	Statement camtStatement   `xml:"BkToCstmrStmt>Stmt"`
+}
+
+type camtGroupHeader struct {
+	MessageId string `xml:"MsgId"`
+	Created   string `xml:"CreDtTm"`
+}
+
+type camtStatement struct {
+	Id       string        `xml:"Id"`
+	Created  string        `xml:"CreDtTm"`
+	FromDate string        `xml:"FrToDt>FrDtTm"`
+	ToDate   string        `xml:"FrToDt>ToDtTm"`
+	Account  camtAccount   `xml:"Acct"`
+	Balances []camtBalance `xml:"Bal"`
+	Entries  []camtEntry   `xml:"Ntry"`
+}
+
+type camtAccount struct {
+	Id       camtAccountIdentification `xml:"Id"`
+	Currency string                    `xml:"Ccy,omitempty"`
+	Name     string                    `xml:"Nm,omitempty"`
+	Servicer *camtFinancialInstitution `xml:"Svcr>FinInstnId,omitempty"`
+}
+
+type camtAccountIdentification struct {
+	IBAN  string                   `xml:"IBAN,omitempty"`
+	Other *camtOtherIdentification `xml:"Othr,omitempty"`
+}
+
+type camtOtherIdentification struct {
+	Id     string      `xml:"Id"`
+	Scheme *camtScheme `xml:"SchmeNm,omitempty"`
+}
+
+// camtScheme is left out when empty, an empty SchmeNm is invalid
+type camtScheme struct {
+	Proprietary string `xml:"Prtry"`
+}
+
+type camtFinancialInstitution struct {
+	BIC   string                   `xml:"BIC,omitempty"`
+	Other *camtOtherIdentification `xml:"Othr,omitempty"`
+}
+
+type camtAmount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type camtBalance struct {
+	Code   string     `xml:"Tp>CdOrPrtry>Cd"`
+	Amount camtAmount `xml:"Amt"`
+	Credit string     `xml:"CdtDbtInd"`
+	Date   string     `xml:"Dt>DtTm"`
+}
+
+type camtEntry struct {
+	Reference           string                  `xml:"NtryRef"`
+	Amount              camtAmount              `xml:"Amt"`
+	Credit              string                  `xml:"CdtDbtInd"`
+	Status              string                  `xml:"Sts"`
+	BookingDate         string                  `xml:"BookgDt>DtTm"`
+	ValueDate           *camtDateTime           `xml:"ValDt,omitempty"`
+	ServicerRef         string                  `xml:"AcctSvcrRef"`
+	BankTransactionCode camtBankTransactionCode `xml:"BkTxCd"`
+	Details             camtTransactionDetails  `xml:"NtryDtls>TxDtls"`
+	AdditionalInfo      string                  `xml:"AddtlNtryInf,omitempty"`
+}
+
+type camtBankTransactionCode struct {
+	Code   string `xml:"Prtry>Cd"`
+	Issuer string `xml:"Prtry>Issr,omitempty"`
+}
+
+type camtDateTime struct {
+	DateTime string `xml:"DtTm"`
+}
+
+type camtTransactionDetails struct {
+	References *camtReferences `xml:"Refs,omitempty"`
+	Remittance *camtRemittance `xml:"RmtInf,omitempty"`
+}
+
+type camtReferences struct {
+	EndToEndId string `xml:"EndToEndId"`
+}
+
+type camtRemittance struct {
+	Unstructured string `xml:"Ustrd"`
+}
@@ -0,0 +1,351 @@
+package aspsp
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const (
+	Version30 = "v3.0"
+	Version31 = "v3.1"
+	Version40 = "v4.0"
+)
+
+// Codec translates the payloads of an OB API version to and from the v3.1 members and codes
+// the response structs use, so the domain model is the same whatever version the ASPSP implements
+type Codec interface {
+	Version() string
+	// Request translates a v3.1 request body to the version
+	Request([]byte) ([]byte, error)
+	// Response translates a response body of the version, from the resource at path, to v3.1
+	Response(path string, body []byte) ([]byte, error)
+}
+
+var versionPath = regexp.MustCompile(`/open-banking/(v\d+(\.\d+)*)(/|$)`)
+
+// VersionOf returns the OB version of an endpoint such as https://bank/open-banking/v3.0/aisp, empty if it has none
+func VersionOf(endpoint string) string {
+	match := versionPath.FindStringSubmatch(endpoint)
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
+// NewCodec returns the codec of version, v3.1.x versions share the v3.1 codec and an empty version is v3.1
+func NewCodec(version string) (Codec, error) {
+	switch {
+	case version == "" || version == Version31 || strings.HasPrefix(version, Version31+"."):
+		return identityCodec{}, nil
+	case version == Version30:
+		return v30Codec, nil
+	case version == Version40 || strings.HasPrefix(version, Version40+"."):
+		return v40Codec, nil
+	}
+	return nil, errors.Errorf("error unsupported OB version %s", version)
+}
+
+type identityCodec struct{}
+
+func (identityCodec) Version() string {
+	return Version31
+}
+
+func (identityCodec) Request(body []byte) ([]byte, error) {
+	return body, nil
+}
+
+func (identityCodec) Response(path string, body []byte) ([]byte, error) {
+	return body, nil
+}
+
+// treeCodec rewrites member names and codes anywhere in the JSON tree
+type treeCodec struct {
+	version string
+	// members are renamed from the version name to the v3.1 name
+	members map[string]string
+	// codes in responses are mapped, by resource collection and v3.1 member name, from the version
+	// code to the v3.1 code, codes not in the map are kept, see resourceCodes
+	codes map[string]map[string]map[string]string
+	// remittanceArrays is set for versions where RemittanceInformation has Unstructured
+	// and Structured arrays, the latter holding the reference
+	remittanceArrays bool
+}
+
+// v30Codec accepts the unprefixed account scheme names some v3.0 ASPSPs still return
+var v30Codec = &treeCodec{
+	version: Version30,
+	codes: map[string]map[string]map[string]string{
+		anyResource: {
+			"SchemeName": {
+				"SortCodeAccountNumber": "UK.OBIE.SortCodeAccountNumber",
+				"IBAN":                  "UK.OBIE.IBAN",
+				"PAN":                   "UK.OBIE.PAN",
+				"BBAN":                  "UK.OBIE.BBAN",
+				"Paym":                  "UK.OBIE.Paym",
+			},
+		},
+	},
+}
+
+// anyResource keys the codes of members of every resource
+const anyResource = ""
+
+var v40BalanceTypes = map[string]string{
+	"CLAV": "ClosingAvailable",
+	"CLBD": "ClosingBooked",
+	"FWAV": "ForwardAvailable",
+	"INFO": "Information",
+	"ITAV": "InterimAvailable",
+	"ITBD": "InterimBooked",
+	"OPAV": "OpeningAvailable",
+	"OPBD": "OpeningBooked",
+	"PRCD": "PreviouslyClosedBooked",
+	"XPCD": "Expected",
+}
+
+// v40ConsentStatus maps the consent status codes, v4.0 codes with no v3.1 equivalent are kept
+var v40ConsentStatus = map[string]map[string]string{
+	"Status": {
+		"AWAU": "AwaitingAuthorisation",
+		"AUTH": "Authorised",
+		"RJCT": "Rejected",
+		"CANC": "Revoked",
+		"EXPD": "Expired",
+		"COND": "Consumed",
+	},
+}
+
+// v40PaymentStatus maps the payment status codes, v4.0 codes with no v3.1 equivalent, e.g. RCVD
+// and ACTC, are kept
+var v40PaymentStatus = map[string]map[string]string{
+	"Status": {
+		"PDNG": "Pending",
+		"RJCT": "Rejected",
+		"ACSP": "AcceptedSettlementInProcess",
+		"ACSC": "AcceptedSettlementCompleted",
+		"ACWP": "AcceptedWithoutPosting",
+		"ACCC": "AcceptedCreditSettlementCompleted",
+	},
+}
+
+// v40Codec maps the ISO 20022 members and codes v4.0 adopted
+var v40Codec = &treeCodec{
+	version: Version40,
+	members: map[string]string{
+		"AccountCategory": "AccountType",
+		"AccountTypeCode": "AccountSubType",
+	},
+	codes: map[string]map[string]map[string]string{
+		"accounts": {
+			"AccountSubType": {
+				"CACC": "CurrentAccount",
+				"SVGS": "Savings",
+				"CARD": "CreditCard",
+				"CHAR": "ChargeCard",
+				"LOAN": "Loan",
+				"MGLN": "Mortgage",
+			},
+		},
+		"balances": {
+			"Type": v40BalanceTypes,
+		},
+		"transactions": {
+			"Type": v40BalanceTypes,
+			"Status": {
+				"BOOK": "Booked",
+				"PDNG": "Pending",
+				"RJCT": "Rejected",
+			},
+		},
+		"account-access-consents":             v40ConsentStatus,
+		"funds-confirmation-consents":         v40ConsentStatus,
+		"domestic-payment-consents":           v40ConsentStatus,
+		"domestic-scheduled-payment-consents": v40ConsentStatus,
+		"domestic-standing-order-consents":    v40ConsentStatus,
+		"international-payment-consents":      v40ConsentStatus,
+		"file-payment-consents":               v40ConsentStatus,
+		"domestic-vrp-consents":               v40ConsentStatus,
+		"domestic-payments":                   v40PaymentStatus,
+		"domestic-scheduled-payments":         v40PaymentStatus,
+		"domestic-standing-orders":            v40PaymentStatus,
+		"international-payments":              v40PaymentStatus,
+		"file-payments":                       v40PaymentStatus,
+		"domestic-vrps":                       v40PaymentStatus,
+	},
+	remittanceArrays: true,
+}
+
+func (c *treeCodec) Version() string {
+	return c.version
+}
+
+func (c *treeCodec) Request(body []byte) ([]byte, error) {
+	return c.rewrite(body, c.request)
+}
+
+func (c *treeCodec) Response(path string, body []byte) ([]byte, error) {
+	codes := c.resourceCodes(path)
+	return c.rewrite(body, func(node interface{}) interface{} {
+		return c.response(node, codes)
+	})
+}
+
+// resourceCodes returns the codes of the resource collection at path, the last segment with codes,
+// e.g. transactions of /accounts/{id}/transactions, merged with those of any resource
+func (c *treeCodec) resourceCodes(path string) map[string]map[string]string {
+	if target, err := url.Parse(path); err == nil {
+		path = target.Path
+	}
+
+	codes := map[string]map[string]string{}
+	for member, mapped := range c.codes[anyResource] {
+		codes[member] = mapped
+	}
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i := len(segments) - 1; i >= 0; i-- {
+		if resource, ok := c.codes[segments[i]]; ok {
+			for member, mapped := range resource {
+				codes[member] = mapped
+			}
+			break
+		}
+	}
+	return codes
+}
+
+func (c *treeCodec) rewrite(body []byte, node func(interface{}) interface{}) ([]byte, error) {
+	if len(body) == 0 {
+		return body, nil
+	}
+
+	var tree interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	// amounts are strings but numbers, e.g. file control sums, must keep their digits
+	decoder.UseNumber()
+	if err := decoder.Decode(&tree); err != nil {
+		return nil, errors.Wrapf(err, "error translating %s payload", c.version)
+	}
+
+	return json.Marshal(node(tree))
+}
+
+func (c *treeCodec) response(node interface{}, codes map[string]map[string]string) interface{} {
+	switch value := node.(type) {
+	case map[string]interface{}:
+		translated := map[string]interface{}{}
+		for name, member := range value {
+			if renamed, ok := c.members[name]; ok {
+				name = renamed
+			}
+			if code, ok := member.(string); ok {
+				if mapped, ok := codes[name][code]; ok {
+					member = mapped
+				}
+			}
+			if name == "RemittanceInformation" && c.remittanceArrays {
+				member = remittanceResponse(member)
+			}
+			translated[name] = c.response(member, codes)
+		}
+		return translated
+	case []interface{}:
+		for i, member := range value {
+			value[i] = c.response(member, codes)
+		}
+	}
+	return node
+}
+
+// request only renames members and translates remittance information, requests
+// carry codes every version accepts
+func (c *treeCodec) request(node interface{}) interface{} {
+	switch value := node.(type) {
+	case map[string]interface{}:
+		translated := map[string]interface{}{}
+		for name, member := range value {
+			if name == "RemittanceInformation" && c.remittanceArrays {
+				member = remittanceRequest(member)
+			}
+			translated[c.member(name)] = c.request(member)
+		}
+		return translated
+	case []interface{}:
+		for i, member := range value {
+			value[i] = c.request(member)
+		}
+	}
+	return node
+}
+
+// member returns the version name of a v3.1 member
+func (c *treeCodec) member(name string) string {
+	for versioned, canonical := range c.members {
+		if canonical == name {
+			return versioned
+		}
+	}
+	return name
+}
+
+// remittanceResponse joins Unstructured lines and takes Reference from the first Structured entry
+func remittanceResponse(member interface{}) interface{} {
+	remittance, ok := member.(map[string]interface{})
+	if !ok {
+		return member
+	}
+
+	translated := map[string]interface{}{}
+	for name, value := range remittance {
+		translated[name] = value
+	}
+	if lines, ok := remittance["Unstructured"].([]interface{}); ok {
+		var unstructured []string
+		for _, line := range lines {
+			if text, ok := line.(string); ok {
+				unstructured = append(unstructured, text)
+			}
+		}
+		translated["Unstructured"] = strings.Join(unstructured, " ")
+	}
+	if structured, ok := remittance["Structured"].([]interface{}); ok {
+		delete(translated, "Structured")
+		if len(structured) > 0 {
+			first, _ := structured[0].(map[string]interface{})
+			creditor, _ := first["CreditorReferenceInformation"].(map[string]interface{})
+			if reference, ok := creditor["Reference"]; ok {
+				translated["Reference"] = reference
+			}
+		}
+	}
+	return translated
+}
+
+// remittanceRequest is the inverse of remittanceResponse
+func remittanceRequest(member interface{}) interface{} {
+	remittance, ok := member.(map[string]interface{})
+	if !ok {
+		return member
+	}
+
+	translated := map[string]interface{}{}
+	for name, value := range remittance {
+		translated[name] = value
+	}
+	if unstructured, ok := remittance["Unstructured"].(string); ok {
+		translated["Unstructured"] = []interface{}{unstructured}
+	}
+	if reference, ok := remittance["Reference"]; ok {
+		delete(translated, "Reference")
+		translated["Structured"] = []interface{}{
+			map[string]interface{}{
+				"CreditorReferenceInformation": map[string]interface{}{"Reference": reference},
+			},
+		}
+	}
+	return translated
+}
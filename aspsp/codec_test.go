@@ -0,0 +1,224 @@
+package aspsp
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestVersionOf(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		want     string
+	}{
+		{endpoint: "https://bank/open-banking/v3.1/aisp", want: "v3.1"},
+		{endpoint: "https://bank/open-banking/v3.1.10/pisp", want: "v3.1.10"},
+		{endpoint: "https://bank/open-banking/v4.0", want: "v4.0"},
+		{endpoint: "https://bank/open-banking/v3.0x/aisp", want: ""},
+		{endpoint: "https://bank/aisp", want: ""},
+	}
+	for _, test := range tests {
+		if got := VersionOf(test.endpoint); got != test.want {
+			t.Errorf("VersionOf(%s) = %q, want %q", test.endpoint, got, test.want)
+		}
+	}
+}
+
+func TestNewCodec(t *testing.T) {
+	tests := []struct {
+		version string
+		want    string
+		wantErr bool
+	}{
+		{version: "", want: Version31},
+		{version: "v3.1", want: Version31},
+		{version: "v3.1.11", want: Version31},
+		{version: "v3.0", want: Version30},
+		{version: "v4.0", want: Version40},
+		{version: "v4.0.1", want: Version40},
+		{version: "v2.0", wantErr: true},
+		{version: "v3.10", wantErr: true},
+	}
+	for _, test := range tests {
+		codec, err := NewCodec(test.version)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("NewCodec(%q) = %s, want error", test.version, codec.Version())
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("NewCodec(%q): %v", test.version, err)
+			continue
+		}
+		if codec.Version() != test.want {
+			t.Errorf("NewCodec(%q) = %s, want %s", test.version, codec.Version(), test.want)
+		}
+	}
+}
+
+func TestCodecResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		path    string
+		body    string
+		want    string
+	}{
+		{
+			name:    "v3.1 is kept",
+			version: Version31,
+			path:    "/accounts",
+			body:    `{"Data":{"Account":[{"AccountType":"Personal","AccountSubType":"CACC"}]}}`,
+			want:    `{"Data":{"Account":[{"AccountType":"Personal","AccountSubType":"CACC"}]}}`,
+		},
+		{
+			name:    "v3.0 scheme names",
+			version: Version30,
+			path:    "/accounts/22289",
+			body:    `{"Data":{"Account":[{"Account":[{"SchemeName":"IBAN","Identification":"GB29"}]}]}}`,
+			want:    `{"Data":{"Account":[{"Account":[{"SchemeName":"UK.OBIE.IBAN","Identification":"GB29"}]}]}}`,
+		},
+		{
+			name:    "v4.0 account members and codes",
+			version: Version40,
+			path:    "/accounts",
+			body:    `{"Data":{"Account":[{"AccountCategory":"Personal","AccountTypeCode":"CACC","Status":"RJCT"}]}}`,
+			want:    `{"Data":{"Account":[{"AccountType":"Personal","AccountSubType":"CurrentAccount","Status":"RJCT"}]}}`,
+		},
+		{
+			name:    "v4.0 balance types",
+			version: Version40,
+			path:    "/accounts/22289/balances",
+			body:    `{"Data":{"Balance":[{"Type":"ITAV"}]}}`,
+			want:    `{"Data":{"Balance":[{"Type":"InterimAvailable"}]}}`,
+		},
+		{
+			name:    "v4.0 transaction status, query ignored",
+			version: Version40,
+			path:    "/accounts/22289/transactions?fromBookingDateTime=2019-05-01T00:00:00",
+			body:    `{"Data":{"Transaction":[{"Status":"BOOK","TransactionReference":"PDNG"}]}}`,
+			want:    `{"Data":{"Transaction":[{"Status":"Booked","TransactionReference":"PDNG"}]}}`,
+		},
+		{
+			name:    "v4.0 consent status",
+			version: Version40,
+			path:    "/domestic-payment-consents/58923",
+			body:    `{"Data":{"ConsentId":"58923","Status":"AUTH"}}`,
+			want:    `{"Data":{"ConsentId":"58923","Status":"Authorised"}}`,
+		},
+		{
+			name:    "v4.0 payment status of the same code",
+			version: Version40,
+			path:    "/domestic-payments/58923",
+			body:    `{"Data":{"Status":"RJCT"}}`,
+			want:    `{"Data":{"Status":"Rejected"}}`,
+		},
+		{
+			name:    "v4.0 payment status without v3.1 code",
+			version: Version40,
+			path:    "/domestic-payments",
+			body:    `{"Data":{"Status":"ACTC"}}`,
+			want:    `{"Data":{"Status":"ACTC"}}`,
+		},
+		{
+			name:    "v4.0 remittance arrays",
+			version: Version40,
+			path:    "/accounts/22289/transactions",
+			body:    `{"RemittanceInformation":{"Unstructured":["rent","may"],"Structured":[{"CreditorReferenceInformation":{"Reference":"FLAT 2"}}]}}`,
+			want:    `{"RemittanceInformation":{"Unstructured":"rent may","Reference":"FLAT 2"}}`,
+		},
+		{
+			name:    "numbers keep their digits",
+			version: Version40,
+			path:    "/file-payment-consents",
+			body:    `{"Data":{"Initiation":{"ControlSum":1000000.10}}}`,
+			want:    `{"Data":{"Initiation":{"ControlSum":1000000.10}}}`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			codec, err := NewCodec(test.version)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := codec.Response(test.path, []byte(test.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertJSON(t, got, test.want)
+		})
+	}
+}
+
+func TestCodecRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		body    string
+		want    string
+	}{
+		{
+			name:    "v3.1 is kept",
+			version: Version31,
+			body:    `{"RemittanceInformation":{"Reference":"FLAT 2"}}`,
+			want:    `{"RemittanceInformation":{"Reference":"FLAT 2"}}`,
+		},
+		{
+			name:    "v4.0 remittance arrays",
+			version: Version40,
+			body:    `{"Data":{"Initiation":{"RemittanceInformation":{"Unstructured":"rent","Reference":"FLAT 2"}}}}`,
+			want:    `{"Data":{"Initiation":{"RemittanceInformation":{"Unstructured":["rent"],"Structured":[{"CreditorReferenceInformation":{"Reference":"FLAT 2"}}]}}}}`,
+		},
+		{
+			name:    "v4.0 members",
+			version: Version40,
+			body:    `{"AccountType":"Personal","AccountSubType":"CurrentAccount"}`,
+			want:    `{"AccountCategory":"Personal","AccountTypeCode":"CurrentAccount"}`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			codec, err := NewCodec(test.version)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := codec.Request([]byte(test.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertJSON(t, got, test.want)
+		})
+	}
+}
+
+func TestCodecInvalidBody(t *testing.T) {
+	if _, err := v40Codec.Response("/accounts", []byte(`{"Data":`)); err == nil {
+		t.Error("translated an invalid body")
+	}
+	if body, err := v40Codec.Response("/accounts", nil); err != nil || len(body) != 0 {
+		t.Errorf("empty body translated to %q, %v", body, err)
+	}
+}
+
+// assertJSON compares JSON documents whatever their member order
+func assertJSON(t *testing.T, got []byte, want string) {
+	t.Helper()
+
+	canonical := func(document []byte) string {
+		var tree interface{}
+		decoder := json.NewDecoder(bytes.NewReader(document))
+		decoder.UseNumber()
+		if err := decoder.Decode(&tree); err != nil {
+			t.Fatalf("invalid json %s: %v", document, err)
+		}
+		canonical, _ := json.Marshal(tree)
+		return string(canonical)
+	}
+
+	if canonical(got) != canonical([]byte(want)) {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,13 @@
+package aspsp
+
+import "time"
+
+type DirectDebit struct {
+	Id                      string
+	AccountId               AccountId
+	MandateIdentification   string
+	Name                    string
+	Status                  string
+	PreviousPaymentDateTime time.Time
+	PreviousPaymentAmount   Money
+}
@@ -0,0 +1,92 @@
+package aspsp
+
+import (
+	"context"
+	"github.com/pkg/errors"
+)
+
+type DirectDebitLister interface {
+	List(AccountId) ([]DirectDebit, error)
+	ListContext(context.Context, AccountId) ([]DirectDebit, error)
+	ListAll() ([]DirectDebit, error)
+	ListAllContext(context.Context) ([]DirectDebit, error)
+}
+
+type directDebitLister struct {
+	client ResourceClient
+}
+
+func NewDirectDebitLister(client ResourceClient) DirectDebitLister {
+	return &directDebitLister{
+		client: client,
+	}
+}
+
+func (d *directDebitLister) List(accountId AccountId) ([]DirectDebit, error) {
+	return d.ListContext(context.Background(), accountId)
+}
+
+func (d *directDebitLister) ListContext(ctx context.Context, accountId AccountId) ([]DirectDebit, error) {
+	return d.list(ctx, accountPath(accountId, "/direct-debits"))
+}
+
+func (d *directDebitLister) ListAll() ([]DirectDebit, error) {
+	return d.ListAllContext(context.Background())
+}
+
+func (d *directDebitLister) ListAllContext(ctx context.Context) ([]DirectDebit, error) {
+	return d.list(ctx, "/direct-debits")
+}
+
+func (d *directDebitLister) list(ctx context.Context, path string) ([]DirectDebit, error) {
+	var directDebitsResponse DirectDebitsResponse
+	if err := d.client.GetContext(ctx, path, &directDebitsResponse); err != nil {
+		return []DirectDebit{}, errors.Wrap(err, "error listing direct debits")
+	}
+
+	var directDebits []DirectDebit
+	for _, directDebit := range directDebitsResponse.Data.DirectDebit {
+		mapped, err := mapDirectDebit(directDebit)
+		if err != nil {
+			return []DirectDebit{}, errors.Wrap(err, "error listing direct debits")
+		}
+		directDebits = append(directDebits, mapped)
+	}
+
+	return directDebits, nil
+}
+
+type DirectDebitsResponse struct {
+	Data DirectDebitsDataResponse `json:"Data"`
+}
+
+type DirectDebitsDataResponse struct {
+	DirectDebit []DirectDebitResponse `json:"DirectDebit"`
+}
+
+type DirectDebitResponse struct {
+	AccountId               string         `json:"AccountId"`
+	DirectDebitId           string         `json:"DirectDebitId"`
+	MandateIdentification   string         `json:"MandateIdentification"`
+	DirectDebitStatusCode   string         `json:"DirectDebitStatusCode"`
+	Name                    string         `json:"Name"`
+	PreviousPaymentDateTime string         `json:"PreviousPaymentDateTime"`
+	PreviousPaymentAmount   AmountResponse `json:"PreviousPaymentAmount"`
+}
+
+func mapDirectDebit(directDebit DirectDebitResponse) (DirectDebit, error) {
+	amount, err := optionalMoney(directDebit.PreviousPaymentAmount)
+	if err != nil {
+		return DirectDebit{}, err
+	}
+
+	return DirectDebit{
+		Id:                      directDebit.DirectDebitId,
+		AccountId:               AccountId(directDebit.AccountId),
+		MandateIdentification:   directDebit.MandateIdentification,
+		Name:                    directDebit.Name,
+		Status:                  directDebit.DirectDebitStatusCode,
+		PreviousPaymentDateTime: parseDateTime(directDebit.PreviousPaymentDateTime),
+		PreviousPaymentAmount:   amount,
+	}, nil
+}
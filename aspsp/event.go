@@ -0,0 +1,197 @@
+package aspsp
+
+import (
+	"context"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/jmatosp/obclient/authorization"
+	"github.com/pkg/errors"
+	"time"
+)
+
+const (
+	EventResourceUpdate              = "urn:uk:org:openbanking:events:resource-update"
+	EventConsentAuthorizationRevoked = "urn:uk:org:openbanking:events:consent-authorization-revoked"
+)
+
+// Event is a verified event notification, Subject is the url of the resource it is about
+type Event struct {
+	Id            string
+	Type          string
+	Issuer        string
+	Subject       string
+	TransactionId string
+	IssuedAt      time.Time
+	OccurredAt    time.Time
+	ResourceId    string
+	ResourceType  string
+	Links         []EventLink
+	// Reason is only given by some ASPSPs for consent revocations
+	Reason string
+}
+
+type EventLink struct {
+	Version string
+	Link    string
+}
+
+// EventError rejects an event notification, Code is one of the OB setErrs err codes
+// such as jwtParse, jwtCrypto, jwtIss, jwtAud or setData
+type EventError struct {
+	Code        string
+	Description string
+}
+
+func (e EventError) Error() string {
+	return "error verifying event notification: " + e.Code + ": " + e.Description
+}
+
+// EventVerifier verifies signed event notifications (SETs) of the ASPSP issuer to clientId
+type EventVerifier interface {
+	Verify(string) (Event, error)
+	VerifyContext(context.Context, string) (Event, error)
+}
+
+// EventSource surfaces events by type, both channels must be read as
+// events are delivered one at a time, events of other types are dropped
+type EventSource interface {
+	ResourceUpdates() <-chan Event
+	ConsentRevocations() <-chan Event
+}
+
+// EventNotificationClaims are the members of an OB event notification
+type EventNotificationClaims struct {
+	Issuer        string                          `json:"iss"`
+	Subject       string                          `json:"sub"`
+	Audience      interface{}                     `json:"aud"`
+	Id            string                          `json:"jti"`
+	TransactionId string                          `json:"txn"`
+	IssuedAt      int64                           `json:"iat"`
+	OccurredAt    int64                           `json:"toe"`
+	Events        map[string]EventPayloadResponse `json:"events"`
+}
+
+// Valid is left to the verifier, which reports OB setErrs codes
+func (c *EventNotificationClaims) Valid() error {
+	return nil
+}
+
+type EventPayloadResponse struct {
+	Subject struct {
+		SubjectType  string `json:"subject_type"`
+		ResourceId   string `json:"http://openbanking.org.uk/rid"`
+		ResourceType string `json:"http://openbanking.org.uk/rty"`
+		Links        []struct {
+			Version string `json:"version"`
+			Link    string `json:"link"`
+		} `json:"http://openbanking.org.uk/rlk"`
+	} `json:"subject"`
+	Reason string `json:"reason"`
+}
+
+type eventVerifier struct {
+	keys     authorization.KeySet
+	issuer   string
+	clientId string
+}
+
+// NewEventVerifier verifies notifications are signed with keys, see authorization.NewKeySet,
+// issued by issuer, the openid configuration issuer, and addressed to clientId
+func NewEventVerifier(keys authorization.KeySet, issuer, clientId string) EventVerifier {
+	return &eventVerifier{
+		keys:     keys,
+		issuer:   issuer,
+		clientId: clientId,
+	}
+}
+
+func (v *eventVerifier) Verify(set string) (Event, error) {
+	return v.VerifyContext(context.Background(), set)
+}
+
+// VerifyContext returns an EventError when the notification is rejected, other errors such as
+// the ASPSP keys not being available leave it to be verified again
+func (v *eventVerifier) VerifyContext(ctx context.Context, set string) (Event, error) {
+	claims := &EventNotificationClaims{}
+	if _, err := new(jwt.Parser).ParseWithClaims(set, claims, authorization.KeyFunc(ctx, v.keys)); err != nil {
+		if validation, ok := err.(*jwt.ValidationError); ok && validation.Errors&jwt.ValidationErrorMalformed != 0 {
+			return Event{}, EventError{"jwtParse", err.Error()}
+		}
+		// the notification may be fine, it is verified again once the ASPSP keys can be fetched
+		if validation, ok := err.(*jwt.ValidationError); ok {
+			if _, unavailable := validation.Inner.(authorization.KeyFetchError); unavailable {
+				return Event{}, errors.Wrap(validation.Inner, "error verifying event notification")
+			}
+		}
+		return Event{}, EventError{"jwtCrypto", err.Error()}
+	}
+
+	if claims.Issuer != v.issuer {
+		return Event{}, EventError{"jwtIss", "unexpected issuer " + claims.Issuer}
+	}
+	if !authorization.AudienceIncludes(claims.Audience, v.clientId) {
+		return Event{}, EventError{"jwtAud", "not addressed to client " + v.clientId}
+	}
+	if claims.Id == "" || len(claims.Events) != 1 {
+		return Event{}, EventError{"setData", "jti and a single event are required"}
+	}
+
+	event := Event{
+		Id:            claims.Id,
+		Issuer:        claims.Issuer,
+		Subject:       claims.Subject,
+		TransactionId: claims.TransactionId,
+		IssuedAt:      time.Unix(claims.IssuedAt, 0).UTC(),
+		OccurredAt:    time.Unix(claims.OccurredAt, 0).UTC(),
+	}
+	for eventType, payload := range claims.Events {
+		event.Type = eventType
+		event.ResourceId = payload.Subject.ResourceId
+		event.ResourceType = payload.Subject.ResourceType
+		event.Reason = payload.Reason
+		for _, link := range payload.Subject.Links {
+			event.Links = append(event.Links, EventLink{Version: link.Version, Link: link.Link})
+		}
+	}
+
+	return event, nil
+}
+
+type eventChannels struct {
+	resourceUpdates    chan Event
+	consentRevocations chan Event
+}
+
+func newEventChannels() eventChannels {
+	return eventChannels{
+		resourceUpdates:    make(chan Event),
+		consentRevocations: make(chan Event),
+	}
+}
+
+func (c eventChannels) ResourceUpdates() <-chan Event {
+	return c.resourceUpdates
+}
+
+func (c eventChannels) ConsentRevocations() <-chan Event {
+	return c.consentRevocations
+}
+
+// deliver waits until the event is read, failing when ctx is done first
+func (c eventChannels) deliver(ctx context.Context, event Event) error {
+	var events chan Event
+	switch event.Type {
+	case EventResourceUpdate:
+		events = c.resourceUpdates
+	case EventConsentAuthorizationRevoked:
+		events = c.consentRevocations
+	default:
+		return nil
+	}
+
+	select {
+	case events <- event:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,99 @@
+package aspsp
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"sort"
+)
+
+// EventPoller gets event notifications from the ASPSP aggregated polling endpoint, it
+// needs a client credentials token, see authorization.IntentAuthenticator
+type EventPoller interface {
+	Poll() error
+	PollContext(context.Context) error
+	EventSource
+}
+
+// EventPollingRequest acknowledges the events received by the previous request, or rejects them in SetErrs
+type EventPollingRequest struct {
+	MaxEvents         int                          `json:"maxEvents,omitempty"`
+	ReturnImmediately bool                         `json:"returnImmediately"`
+	Ack               []string                     `json:"ack,omitempty"`
+	SetErrs           map[string]EventPollingError `json:"setErrs,omitempty"`
+}
+
+type EventPollingError struct {
+	Err         string `json:"err"`
+	Description string `json:"description"`
+}
+
+// EventPollingResponse holds the signed event notifications by jti
+type EventPollingResponse struct {
+	Sets          map[string]string `json:"sets"`
+	MoreAvailable bool              `json:"moreAvailable"`
+}
+
+type eventPoller struct {
+	eventChannels
+	client    ResourceClient
+	verifier  EventVerifier
+	maxEvents int
+}
+
+// NewEventPoller gets up to maxEvents per request, zero lets the ASPSP decide
+func NewEventPoller(client ResourceClient, verifier EventVerifier, maxEvents int) EventPoller {
+	return &eventPoller{
+		eventChannels: newEventChannels(),
+		client:        client,
+		verifier:      verifier,
+		maxEvents:     maxEvents,
+	}
+}
+
+func (p *eventPoller) Poll() error {
+	return p.PollContext(context.Background())
+}
+
+// PollContext polls until the ASPSP has no more events, each event is acknowledged
+// by the next request once it has been read from the channels
+func (p *eventPoller) PollContext(ctx context.Context) error {
+	var ack []string
+	var setErrs map[string]EventPollingError
+	for {
+		request := EventPollingRequest{
+			MaxEvents:         p.maxEvents,
+			ReturnImmediately: true,
+			Ack:               ack,
+			SetErrs:           setErrs,
+		}
+		var response EventPollingResponse
+		if err := p.client.PostContext(ctx, "/events", request, &response); err != nil {
+			return errors.Wrap(err, "error polling events")
+		}
+		if len(response.Sets) == 0 {
+			return nil
+		}
+
+		ack, setErrs = nil, map[string]EventPollingError{}
+		ids := make([]string, 0, len(response.Sets))
+		for id := range response.Sets {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+
+		for _, id := range ids {
+			event, err := p.verifier.VerifyContext(ctx, response.Sets[id])
+			if rejected, ok := err.(EventError); ok {
+				setErrs[id] = EventPollingError{Err: rejected.Code, Description: rejected.Description}
+				continue
+			} else if err != nil {
+				return errors.Wrap(err, "error polling events")
+			}
+
+			if err = p.deliver(ctx, event); err != nil {
+				return errors.Wrap(err, "error polling events")
+			}
+			ack = append(ack, id)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package aspsp
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+)
+
+// EventReceiverPath is where ASPSPs post event notifications below the registered callback url
+const EventReceiverPath = "/event-notifications"
+
+// maxEventSize limits the notification body read
+const maxEventSize = 1 << 20
+
+// EventReceiver is the http handler ASPSPs post event notifications to, verified
+// events are surfaced on its channels before the notification is accepted
+type EventReceiver interface {
+	http.Handler
+	EventSource
+}
+
+type eventReceiver struct {
+	eventChannels
+	verifier EventVerifier
+}
+
+func NewEventReceiver(verifier EventVerifier) EventReceiver {
+	return &eventReceiver{
+		eventChannels: newEventChannels(),
+		verifier:      verifier,
+	}
+}
+
+func (e *eventReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	set, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxEventSize))
+	if err != nil {
+		writeEventError(w, EventError{"jwtParse", err.Error()})
+		return
+	}
+
+	event, err := e.verifier.VerifyContext(r.Context(), string(set))
+	if rejected, ok := err.(EventError); ok {
+		writeEventError(w, rejected)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	// not accepted when nobody reads it, so the ASPSP sends it again
+	if err = e.deliver(r.Context(), event); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func writeEventError(w http.ResponseWriter, rejected EventError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(EventPollingError{Err: rejected.Code, Description: rejected.Description})
+}
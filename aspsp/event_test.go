@@ -0,0 +1,157 @@
+package aspsp
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/jmatosp/obclient/authorization"
+	"github.com/pkg/errors"
+	"testing"
+	"time"
+)
+
+const (
+	testIssuer   = "https://bank.example.com"
+	testClientId = "client-1"
+	testKeyId    = "bank-key"
+)
+
+// staticKeySet serves keys by id, or fails every fetch when err is set
+type staticKeySet struct {
+	keys map[string]*rsa.PublicKey
+	err  error
+}
+
+func (k staticKeySet) Key(kid string) (*rsa.PublicKey, error) {
+	return k.KeyContext(context.Background(), kid)
+}
+
+func (k staticKeySet) KeyContext(_ context.Context, kid string) (*rsa.PublicKey, error) {
+	if k.err != nil {
+		return nil, authorization.KeyFetchError{Err: k.err}
+	}
+	if key, ok := k.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, errors.Errorf("unknown key id %s", kid)
+}
+
+func TestEventVerifier(t *testing.T) {
+	bankKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := staticKeySet{keys: map[string]*rsa.PublicKey{testKeyId: &bankKey.PublicKey}}
+
+	occurred := time.Date(2019, 5, 1, 10, 0, 0, 0, time.UTC)
+	claims := func(change func(jwt.MapClaims)) jwt.MapClaims {
+		claims := jwt.MapClaims{
+			"iss": testIssuer,
+			"sub": "https://bank.example.com/open-banking/v3.1/aisp/accounts/22289",
+			"aud": testClientId,
+			"jti": "b460a07c-4962-43d1-85ee-9dc10fbb8f6c",
+			"txn": "dfc51628-3479-4b81-ad60-210b43d02306",
+			"iat": occurred.Add(time.Minute).Unix(),
+			"toe": occurred.Unix(),
+			"events": map[string]interface{}{
+				EventResourceUpdate: map[string]interface{}{
+					"subject": map[string]interface{}{
+						"subject_type":                  "http://openbanking.org.uk/rid_http://openbanking.org.uk/rty",
+						"http://openbanking.org.uk/rid": "22289",
+						"http://openbanking.org.uk/rty": "account",
+						"http://openbanking.org.uk/rlk": []map[string]string{
+							{"version": "v3.1", "link": "https://bank.example.com/open-banking/v3.1/aisp/accounts/22289"},
+						},
+					},
+				},
+			},
+		}
+		if change != nil {
+			change(claims)
+		}
+		return claims
+	}
+	sign := func(method jwt.SigningMethod, key interface{}, kid string, claims jwt.MapClaims) string {
+		token := jwt.NewWithClaims(method, claims)
+		token.Header["kid"] = kid
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	tests := []struct {
+		name string
+		keys authorization.KeySet
+		set  string
+		// code is the EventError code, empty for verified events
+		code string
+		// fetchErr expects an error that is not an EventError
+		fetchErr bool
+	}{
+		{name: "verified", set: sign(jwt.SigningMethodPS256, bankKey, testKeyId, claims(nil))},
+		{name: "RS256", set: sign(jwt.SigningMethodRS256, bankKey, testKeyId, claims(nil))},
+		{name: "audience array", set: sign(jwt.SigningMethodPS256, bankKey, testKeyId, claims(func(c jwt.MapClaims) {
+			c["aud"] = []string{"other", testClientId}
+		}))},
+		{name: "not a jwt", set: "not.a.jwt", code: "jwtParse"},
+		{name: "other key", set: sign(jwt.SigningMethodPS256, otherKey, testKeyId, claims(nil)), code: "jwtCrypto"},
+		{name: "unknown key id", set: sign(jwt.SigningMethodPS256, bankKey, "rotated", claims(nil)), code: "jwtCrypto"},
+		{name: "hmac", set: sign(jwt.SigningMethodHS256, []byte("secret"), testKeyId, claims(nil)), code: "jwtCrypto"},
+		{name: "other issuer", set: sign(jwt.SigningMethodPS256, bankKey, testKeyId, claims(func(c jwt.MapClaims) {
+			c["iss"] = "https://other.example.com"
+		})), code: "jwtIss"},
+		{name: "other audience", set: sign(jwt.SigningMethodPS256, bankKey, testKeyId, claims(func(c jwt.MapClaims) {
+			c["aud"] = "other"
+		})), code: "jwtAud"},
+		{name: "no jti", set: sign(jwt.SigningMethodPS256, bankKey, testKeyId, claims(func(c jwt.MapClaims) {
+			delete(c, "jti")
+		})), code: "setData"},
+		{name: "two events", set: sign(jwt.SigningMethodPS256, bankKey, testKeyId, claims(func(c jwt.MapClaims) {
+			c["events"].(map[string]interface{})[EventConsentAuthorizationRevoked] = map[string]interface{}{}
+		})), code: "setData"},
+		{name: "keys unavailable", keys: staticKeySet{err: errors.New("connection refused")},
+			set: sign(jwt.SigningMethodPS256, bankKey, testKeyId, claims(nil)), fetchErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			verifierKeys := test.keys
+			if verifierKeys == nil {
+				verifierKeys = keys
+			}
+
+			event, err := NewEventVerifier(verifierKeys, testIssuer, testClientId).Verify(test.set)
+			eventErr, isEventErr := err.(EventError)
+			switch {
+			case test.fetchErr:
+				if err == nil || isEventErr {
+					t.Fatalf("got %v, want a key fetch error", err)
+				}
+				return
+			case test.code != "":
+				if !isEventErr || eventErr.Code != test.code {
+					t.Fatalf("got %v, want EventError %s", err, test.code)
+				}
+				return
+			case err != nil:
+				t.Fatal(err)
+			}
+
+			if event.Type != EventResourceUpdate || event.ResourceId != "22289" || event.ResourceType != "account" {
+				t.Errorf("unexpected event %+v", event)
+			}
+			if !event.OccurredAt.Equal(occurred) {
+				t.Errorf("OccurredAt = %s, want %s", event.OccurredAt, occurred)
+			}
+			if len(event.Links) != 1 || event.Links[0].Version != "v3.1" {
+				t.Errorf("Links = %+v", event.Links)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package aspsp
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	ExportOFX     = "ofx"
+	ExportQIF     = "qif"
+	ExportCAMT053 = "camt053"
+)
+
+const (
+	transactionStatusBooked    = "Booked"
+	creditDebitIndicatorCredit = "Credit"
+	creditDebitIndicatorDebit  = "Debit"
+	schemeSortCodeAccount      = "UK.OBIE.SortCodeAccountNumber"
+	schemeIBAN                 = "UK.OBIE.IBAN"
+)
+
+// ExportStatement is an account statement to be exported to accounting tools
+type ExportStatement struct {
+	Account      Account
+	From         time.Time
+	To           time.Time
+	Balances     []Balance
+	Transactions []Transaction
+}
+
+// LoadExportStatement loads account balances and transactions booked between from and to
+func LoadExportStatement(ctx context.Context, account Account, from, to time.Time) (ExportStatement, error) {
+	transactions, err := account.TransactionsContext(ctx, from, to)
+	if err != nil {
+		return ExportStatement{}, errors.Wrap(err, "error loading export statement")
+	}
+
+	balances, err := account.BalancesContext(ctx)
+	if err != nil {
+		return ExportStatement{}, errors.Wrap(err, "error loading export statement")
+	}
+
+	return ExportStatement{
+		Account:      account,
+		From:         from,
+		To:           to,
+		Balances:     balances,
+		Transactions: transactions,
+	}, nil
+}
+
+// bookedTransactions filters out pending transactions, accounting tools only import booked ones
+func (s ExportStatement) bookedTransactions() []Transaction {
+	var booked []Transaction
+	for _, transaction := range s.Transactions {
+		if transaction.Booked() {
+			booked = append(booked, transaction)
+		}
+	}
+	return booked
+}
+
+// identity returns the account identity, never nil
+func (s ExportStatement) identity() AccountIdentity {
+	if identity := s.Account.AccountIdentity(); identity != nil {
+		return identity
+	}
+	return NewAccountIdentity("", string(s.Account.Id()), s.Account.Nickname(), "", "")
+}
+
+type Exporter interface {
+	Export(w io.Writer, statement ExportStatement) error
+}
+
+func NewExporter(format string) (Exporter, error) {
+	switch strings.ToLower(format) {
+	case ExportOFX:
+		return ofxExporter{}, nil
+	case ExportQIF:
+		return qifExporter{}, nil
+	case ExportCAMT053, "camt.053":
+		return camt053Exporter{}, nil
+	}
+	return nil, errors.Errorf("error unknown export format %s", format)
+}
+
+// splitAccountIdentification returns bank and account ids from an account identity,
+// sort code and account number are split, other schemes use servicer as bank id
+func splitAccountIdentification(identity AccountIdentity) (string, string) {
+	identification := strings.Replace(identity.Identification(), " ", "", -1)
+	if identity.SchemaName() == schemeSortCodeAccount && len(identification) == 14 {
+		return identification[:6], identification[6:]
+	}
+	if identity.SchemaName() == schemeIBAN && len(identification) > 8 {
+		return identification[4:8], identification
+	}
+	return identity.Servicer(), identification
+}
+
+func truncate(value string, length int) string {
+	runes := []rune(value)
+	if len(runes) <= length {
+		return value
+	}
+	return string(runes[:length])
+}
@@ -0,0 +1,106 @@
+package aspsp
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "update the golden files of testdata")
+
+// exportVolatile matches the creation times and message ids exports take from the clock and uuids
+var exportVolatile = regexp.MustCompile(`<(DTSERVER|CreDtTm|MsgId|Stmt>\s*<Id)>[^<]*<`)
+
+func TestExporters(t *testing.T) {
+	may := func(day, hour int) time.Time {
+		return time.Date(2019, 5, day, hour, 30, 0, 0, time.UTC)
+	}
+
+	statement := func(identity AccountIdentity) ExportStatement {
+		return ExportStatement{
+			Account: NewResourceAccount("22289", "GBP", "Personal", "CurrentAccount", "Bills", identity, nil),
+			From:    may(1, 0),
+			To:      may(4, 23),
+			Balances: []Balance{
+				{AccountId: "22289", Type: "InterimBooked", Amount: NewMoney(189283, "GBP"), DateTime: may(4, 0)},
+				{AccountId: "22289", Type: "InterimAvailable", Amount: NewMoney(-1250, "GBP"), DateTime: may(4, 0)},
+			},
+			Transactions: []Transaction{
+				{
+					Id:                   "123",
+					Reference:            "Salary",
+					Amount:               NewMoney(150000, "GBP"),
+					CreditDebitIndicator: "Credit",
+					Status:               "Booked",
+					BookingDateTime:      may(1, 9),
+					ValueDateTime:        may(1, 9),
+					Information:          "Acme <Payroll>",
+				},
+				{
+					Id:                   "124",
+					Reference:            "Table 4 & 5",
+					Amount:               NewMoney(-2550, "GBP"),
+					CreditDebitIndicator: "Debit",
+					Status:               "Booked",
+					BookingDateTime:      may(2, 20),
+					Information:          "Fish & Chips",
+					Categories:           []string{"Eating out", "Leisure"},
+				},
+				{
+					Id:                   "125",
+					Amount:               NewMoney(-999, "GBP"),
+					CreditDebitIndicator: "Debit",
+					Status:               "Pending",
+					BookingDateTime:      may(3, 12),
+					Information:          "Not booked yet",
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		format   string
+		identity AccountIdentity
+		golden   string
+	}{
+		{name: "ofx", format: ExportOFX, identity: NewAccountIdentity(schemeSortCodeAccount, "80200110203345", "Mr Kevin & Mrs <Ann>", "", ""), golden: "export.ofx"},
+		{name: "ofx iban", format: ExportOFX, identity: NewAccountIdentity(schemeIBAN, "GB29NWBK60161331926819", "Mr Kevin", "", "NWBKGB2L"), golden: "export_iban.ofx"},
+		{name: "qif", format: ExportQIF, identity: NewAccountIdentity(schemeSortCodeAccount, "80200110203345", "Mr Kevin & Mrs <Ann>", "", ""), golden: "export.qif"},
+		{name: "qif without account name", format: ExportQIF, identity: nil, golden: "export_nickname.qif"},
+		{name: "camt053", format: ExportCAMT053, identity: NewAccountIdentity(schemeSortCodeAccount, "80200110203345", "Mr Kevin & Mrs <Ann>", "", "Bank & Co"), golden: "export.camt053.xml"},
+		{name: "camt053 iban", format: ExportCAMT053, identity: NewAccountIdentity(schemeIBAN, "GB29NWBK60161331926819", "Mr Kevin", "", "NWBKGB2L"), golden: "export_iban.camt053.xml"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			exporter, err := NewExporter(test.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var out bytes.Buffer
+			if err = exporter.Export(&out, statement(test.identity)); err != nil {
+				t.Fatal(err)
+			}
+			got := exportVolatile.ReplaceAll(out.Bytes(), []byte("<$1><"))
+
+			golden := filepath.Join("testdata", test.golden)
+			if *update {
+				if err = ioutil.WriteFile(golden, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := ioutil.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("got\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,281 @@
+package aspsp
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// FilePaymentConsenter creates file payment consents and uploads their file before
+// the PSU authorises them, it needs a client credentials token, see authorization.IntentAuthenticator
+type FilePaymentConsenter interface {
+	Create(FilePaymentOrder) (FilePaymentConsent, error)
+	CreateContext(context.Context, FilePaymentOrder) (FilePaymentConsent, error)
+	Upload(FilePaymentConsent) error
+	UploadContext(context.Context, FilePaymentConsent) error
+	Get(string) (FilePaymentConsent, error)
+	GetContext(context.Context, string) (FilePaymentConsent, error)
+}
+
+// FilePaymentSubmitter submits file payments of authorised consents, it needs the token the
+// PSU authorised the consent with, getting payments and reports also works with client credentials
+type FilePaymentSubmitter interface {
+	Submit(FilePaymentConsent) (FilePayment, error)
+	SubmitContext(context.Context, FilePaymentConsent) (FilePayment, error)
+	Get(string) (FilePayment, error)
+	GetContext(context.Context, string) (FilePayment, error)
+	Report(string) ([]byte, error)
+	ReportContext(context.Context, string) ([]byte, error)
+}
+
+// FilePaymentOrder pays the payments of File, Reference identifies the file to the PSU
+type FilePaymentOrder struct {
+	File                       PaymentFile
+	Reference                  string
+	DebtorAccount              AccountIdentity
+	RequestedExecutionDateTime time.Time
+}
+
+type FilePaymentConsent struct {
+	ConsentId            string
+	Status               string
+	CreationDateTime     time.Time
+	StatusUpdateDateTime time.Time
+	CutOffDateTime       time.Time
+	// Order is the order the consent was created with, its file is uploaded and submitted
+	Order FilePaymentOrder
+}
+
+var NoFilePaymentConsent = FilePaymentConsent{}
+
+type FilePayment struct {
+	Id                   string
+	ConsentId            string
+	Status               string
+	CreationDateTime     time.Time
+	StatusUpdateDateTime time.Time
+}
+
+var NoFilePayment = FilePayment{}
+
+type filePaymentConsenter struct {
+	client ResourceClient
+}
+
+func NewFilePaymentConsenter(client ResourceClient) FilePaymentConsenter {
+	return &filePaymentConsenter{
+		client: client,
+	}
+}
+
+func (f *filePaymentConsenter) Create(order FilePaymentOrder) (FilePaymentConsent, error) {
+	return f.CreateContext(context.Background(), order)
+}
+
+func (f *filePaymentConsenter) CreateContext(ctx context.Context, order FilePaymentOrder) (FilePaymentConsent, error) {
+	initiation, err := order.initiation()
+	if err != nil {
+		return NoFilePaymentConsent, errors.Wrap(err, "error creating file payment consent")
+	}
+
+	var response FilePaymentConsentResponse
+	body := FilePaymentConsentRequest{Data: FilePaymentConsentDataRequest{Initiation: initiation}}
+	ctx = idempotent(ctx, IdempotencyKey("/file-payment-consents", initiation.FileHash, initiation.FileReference))
+	ctx = signed(ctx)
+	if err = f.client.PostContext(ctx, "/file-payment-consents", body, &response); err != nil {
+		return NoFilePaymentConsent, errors.Wrap(err, "error creating file payment consent")
+	}
+
+	consent := mapFilePaymentConsent(response.Data)
+	consent.Order = order
+	return consent, nil
+}
+
+func (f *filePaymentConsenter) Upload(consent FilePaymentConsent) error {
+	return f.UploadContext(context.Background(), consent)
+}
+
+// UploadContext uploads the consent order file, the consent then awaits authorisation
+func (f *filePaymentConsenter) UploadContext(ctx context.Context, consent FilePaymentConsent) error {
+	path := "/file-payment-consents/" + url.PathEscape(consent.ConsentId) + "/file"
+	ctx = idempotent(ctx, IdempotencyKey(path))
+	ctx = signed(ctx)
+	if err := f.client.UploadContext(ctx, path, "application/json", consent.Order.File.Content, nil); err != nil {
+		return errors.Wrap(err, "error uploading payment file")
+	}
+
+	return nil
+}
+
+func (f *filePaymentConsenter) Get(consentId string) (FilePaymentConsent, error) {
+	return f.GetContext(context.Background(), consentId)
+}
+
+// GetContext gets the consent status, its Order is not returned
+func (f *filePaymentConsenter) GetContext(ctx context.Context, consentId string) (FilePaymentConsent, error) {
+	var response FilePaymentConsentResponse
+	if err := f.client.GetContext(ctx, "/file-payment-consents/"+url.PathEscape(consentId), &response); err != nil {
+		return NoFilePaymentConsent, errors.Wrap(err, "error getting file payment consent")
+	}
+
+	return mapFilePaymentConsent(response.Data), nil
+}
+
+type filePaymentSubmitter struct {
+	client ResourceClient
+}
+
+func NewFilePaymentSubmitter(client ResourceClient) FilePaymentSubmitter {
+	return &filePaymentSubmitter{
+		client: client,
+	}
+}
+
+func (f *filePaymentSubmitter) Submit(consent FilePaymentConsent) (FilePayment, error) {
+	return f.SubmitContext(context.Background(), consent)
+}
+
+// SubmitContext submits the payment with the consent Order, which must be the one the consent was created with
+func (f *filePaymentSubmitter) SubmitContext(ctx context.Context, consent FilePaymentConsent) (FilePayment, error) {
+	initiation, err := consent.Order.initiation()
+	if err != nil {
+		return NoFilePayment, errors.Wrap(err, "error submitting file payment")
+	}
+
+	body := FilePaymentRequest{
+		Data: FilePaymentDataRequest{
+			ConsentId:  consent.ConsentId,
+			Initiation: initiation,
+		},
+	}
+
+	var response FilePaymentResponse
+	ctx = idempotent(ctx, IdempotencyKey("/file-payments", consent.ConsentId))
+	ctx = signed(ctx)
+	if err = f.client.PostContext(ctx, "/file-payments", body, &response); err != nil {
+		return NoFilePayment, errors.Wrap(err, "error submitting file payment")
+	}
+
+	return mapFilePayment(response.Data), nil
+}
+
+func (f *filePaymentSubmitter) Get(paymentId string) (FilePayment, error) {
+	return f.GetContext(context.Background(), paymentId)
+}
+
+func (f *filePaymentSubmitter) GetContext(ctx context.Context, paymentId string) (FilePayment, error) {
+	var response FilePaymentResponse
+	if err := f.client.GetContext(ctx, "/file-payments/"+url.PathEscape(paymentId), &response); err != nil {
+		return NoFilePayment, errors.Wrap(err, "error getting file payment")
+	}
+
+	return mapFilePayment(response.Data), nil
+}
+
+func (f *filePaymentSubmitter) Report(paymentId string) ([]byte, error) {
+	return f.ReportContext(context.Background(), paymentId)
+}
+
+// ReportContext downloads the ASPSP report of the file payment, its format is ASPSP specific
+func (f *filePaymentSubmitter) ReportContext(ctx context.Context, paymentId string) ([]byte, error) {
+	report, err := f.client.DownloadContext(ctx, "/file-payments/"+url.PathEscape(paymentId)+"/report-file", "*/*")
+	if err != nil {
+		return nil, errors.Wrap(err, "error downloading file payment report")
+	}
+
+	return report, nil
+}
+
+// initiation builds the OB initiation declaring the order file
+func (o FilePaymentOrder) initiation() (FilePaymentInitiationRequest, error) {
+	if len(o.File.Content) == 0 || o.File.NumberOfTransactions == 0 {
+		return FilePaymentInitiationRequest{}, errors.New("error empty payment file")
+	}
+
+	initiation := FilePaymentInitiationRequest{
+		FileType:             o.File.Type,
+		FileHash:             o.File.Hash(),
+		FileReference:        o.Reference,
+		NumberOfTransactions: strconv.Itoa(o.File.NumberOfTransactions),
+		ControlSum:           json.Number(o.File.ControlSum.Amount()),
+		DebtorAccount:        cashAccountRequest(o.DebtorAccount),
+	}
+	if !o.RequestedExecutionDateTime.IsZero() {
+		initiation.RequestedExecutionDateTime = o.RequestedExecutionDateTime.Format(time.RFC3339)
+	}
+	return initiation, nil
+}
+
+type FilePaymentInitiationRequest struct {
+	FileType                   string               `json:"FileType"`
+	FileHash                   string               `json:"FileHash"`
+	FileReference              string               `json:"FileReference,omitempty"`
+	NumberOfTransactions       string               `json:"NumberOfTransactions"`
+	ControlSum                 json.Number          `json:"ControlSum"`
+	RequestedExecutionDateTime string               `json:"RequestedExecutionDateTime,omitempty"`
+	DebtorAccount              *CashAccountResponse `json:"DebtorAccount,omitempty"`
+}
+
+type FilePaymentConsentRequest struct {
+	Data FilePaymentConsentDataRequest `json:"Data"`
+}
+
+type FilePaymentConsentDataRequest struct {
+	Initiation FilePaymentInitiationRequest `json:"Initiation"`
+}
+
+type FilePaymentConsentResponse struct {
+	Data FilePaymentConsentDataResponse `json:"Data"`
+}
+
+type FilePaymentConsentDataResponse struct {
+	ConsentId            string `json:"ConsentId"`
+	Status               string `json:"Status"`
+	CreationDateTime     string `json:"CreationDateTime"`
+	StatusUpdateDateTime string `json:"StatusUpdateDateTime"`
+	CutOffDateTime       string `json:"CutOffDateTime"`
+}
+
+type FilePaymentRequest struct {
+	Data FilePaymentDataRequest `json:"Data"`
+}
+
+type FilePaymentDataRequest struct {
+	ConsentId  string                       `json:"ConsentId"`
+	Initiation FilePaymentInitiationRequest `json:"Initiation"`
+}
+
+type FilePaymentResponse struct {
+	Data FilePaymentDataResponse `json:"Data"`
+}
+
+type FilePaymentDataResponse struct {
+	FilePaymentId        string `json:"FilePaymentId"`
+	ConsentId            string `json:"ConsentId"`
+	Status               string `json:"Status"`
+	CreationDateTime     string `json:"CreationDateTime"`
+	StatusUpdateDateTime string `json:"StatusUpdateDateTime"`
+}
+
+func mapFilePaymentConsent(consent FilePaymentConsentDataResponse) FilePaymentConsent {
+	return FilePaymentConsent{
+		ConsentId:            consent.ConsentId,
+		Status:               consent.Status,
+		CreationDateTime:     parseDateTime(consent.CreationDateTime),
+		StatusUpdateDateTime: parseDateTime(consent.StatusUpdateDateTime),
+		CutOffDateTime:       parseDateTime(consent.CutOffDateTime),
+	}
+}
+
+func mapFilePayment(payment FilePaymentDataResponse) FilePayment {
+	return FilePayment{
+		Id:                   payment.FilePaymentId,
+		ConsentId:            payment.ConsentId,
+		Status:               payment.Status,
+		CreationDateTime:     parseDateTime(payment.CreationDateTime),
+		StatusUpdateDateTime: parseDateTime(payment.StatusUpdateDateTime),
+	}
+}
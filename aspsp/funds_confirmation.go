@@ -0,0 +1,234 @@
+package aspsp
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// FundsConfirmationConsenter manages CBPII funds confirmation consents, it needs a
+// client credentials token, see authorization.IntentAuthenticator
+type FundsConfirmationConsenter interface {
+	Create(FundsConfirmationConsentRequest) (FundsConfirmationConsent, error)
+	CreateContext(context.Context, FundsConfirmationConsentRequest) (FundsConfirmationConsent, error)
+	Get(string) (FundsConfirmationConsent, error)
+	GetContext(context.Context, string) (FundsConfirmationConsent, error)
+	Delete(string) error
+	DeleteContext(context.Context, string) error
+}
+
+// FundsConfirmer asks the ASPSP whether funds are available, it needs the token
+// the PSU authorised the consent with
+type FundsConfirmer interface {
+	Confirm(FundsConfirmationRequest) (FundsConfirmation, error)
+	ConfirmContext(context.Context, FundsConfirmationRequest) (FundsConfirmation, error)
+}
+
+// FundsConfirmationConsentRequest asks confirmation of funds on DebtorAccount,
+// a zero ExpirationDateTime requests a consent that does not expire
+type FundsConfirmationConsentRequest struct {
+	DebtorAccount      AccountIdentity
+	ExpirationDateTime time.Time
+}
+
+type FundsConfirmationConsent struct {
+	ConsentId            string
+	Status               string
+	CreationDateTime     time.Time
+	StatusUpdateDateTime time.Time
+	ExpirationDateTime   time.Time
+	DebtorAccount        AccountIdentity
+}
+
+var NoFundsConfirmationConsent = FundsConfirmationConsent{}
+
+type FundsConfirmationRequest struct {
+	ConsentId string
+	Reference string
+	Amount    Money
+}
+
+type FundsConfirmation struct {
+	Id               string
+	ConsentId        string
+	Reference        string
+	Amount           Money
+	FundsAvailable   bool
+	CreationDateTime time.Time
+}
+
+var NoFundsConfirmation = FundsConfirmation{}
+
+type fundsConfirmationConsenter struct {
+	client ResourceClient
+}
+
+func NewFundsConfirmationConsenter(client ResourceClient) FundsConfirmationConsenter {
+	return &fundsConfirmationConsenter{
+		client: client,
+	}
+}
+
+func (f *fundsConfirmationConsenter) Create(request FundsConfirmationConsentRequest) (FundsConfirmationConsent, error) {
+	return f.CreateContext(context.Background(), request)
+}
+
+func (f *fundsConfirmationConsenter) CreateContext(ctx context.Context, request FundsConfirmationConsentRequest) (FundsConfirmationConsent, error) {
+	if request.DebtorAccount == nil {
+		return NoFundsConfirmationConsent, errors.New("error creating funds confirmation consent: debtor account not provided")
+	}
+
+	body := FundsConfirmationConsentRequestBody{
+		Data: FundsConfirmationConsentDataRequest{
+			DebtorAccount: CashAccountResponse{
+				SchemeName:              request.DebtorAccount.SchemaName(),
+				Identification:          request.DebtorAccount.Identification(),
+				Name:                    request.DebtorAccount.Name(),
+				SecondaryIdentification: request.DebtorAccount.SecondaryIdentification(),
+			},
+		},
+	}
+	if !request.ExpirationDateTime.IsZero() {
+		body.Data.ExpirationDateTime = request.ExpirationDateTime.Format(time.RFC3339)
+	}
+
+	var response FundsConfirmationConsentResponse
+	if err := f.client.PostContext(signed(ctx), "/funds-confirmation-consents", body, &response); err != nil {
+		return NoFundsConfirmationConsent, errors.Wrap(err, "error creating funds confirmation consent")
+	}
+
+	return mapFundsConfirmationConsent(response.Data), nil
+}
+
+func (f *fundsConfirmationConsenter) Get(consentId string) (FundsConfirmationConsent, error) {
+	return f.GetContext(context.Background(), consentId)
+}
+
+func (f *fundsConfirmationConsenter) GetContext(ctx context.Context, consentId string) (FundsConfirmationConsent, error) {
+	var response FundsConfirmationConsentResponse
+	if err := f.client.GetContext(ctx, "/funds-confirmation-consents/"+url.PathEscape(consentId), &response); err != nil {
+		return NoFundsConfirmationConsent, errors.Wrap(err, "error getting funds confirmation consent")
+	}
+
+	return mapFundsConfirmationConsent(response.Data), nil
+}
+
+func (f *fundsConfirmationConsenter) Delete(consentId string) error {
+	return f.DeleteContext(context.Background(), consentId)
+}
+
+func (f *fundsConfirmationConsenter) DeleteContext(ctx context.Context, consentId string) error {
+	if err := f.client.DoContext(ctx, http.MethodDelete, "/funds-confirmation-consents/"+url.PathEscape(consentId), nil, nil); err != nil {
+		return errors.Wrap(err, "error deleting funds confirmation consent")
+	}
+
+	return nil
+}
+
+type fundsConfirmer struct {
+	client ResourceClient
+}
+
+func NewFundsConfirmer(client ResourceClient) FundsConfirmer {
+	return &fundsConfirmer{
+		client: client,
+	}
+}
+
+func (f *fundsConfirmer) Confirm(request FundsConfirmationRequest) (FundsConfirmation, error) {
+	return f.ConfirmContext(context.Background(), request)
+}
+
+func (f *fundsConfirmer) ConfirmContext(ctx context.Context, request FundsConfirmationRequest) (FundsConfirmation, error) {
+	if request.Amount.IsNegative() || request.Amount.IsZero() {
+		return NoFundsConfirmation, errors.Errorf("error confirming funds: invalid amount %s", request.Amount)
+	}
+
+	body := FundsConfirmationRequestBody{
+		Data: FundsConfirmationDataRequest{
+			ConsentId: request.ConsentId,
+			Reference: request.Reference,
+			InstructedAmount: AmountResponse{
+				Amount:   request.Amount.Amount(),
+				Currency: request.Amount.Currency(),
+			},
+		},
+	}
+
+	var response FundsConfirmationResponse
+	if err := f.client.PostContext(signed(ctx), "/funds-confirmations", body, &response); err != nil {
+		return NoFundsConfirmation, errors.Wrap(err, "error confirming funds")
+	}
+
+	amount, err := optionalMoney(response.Data.InstructedAmount)
+	if err != nil {
+		return NoFundsConfirmation, errors.Wrap(err, "error confirming funds")
+	}
+
+	return FundsConfirmation{
+		Id:               response.Data.FundsConfirmationId,
+		ConsentId:        response.Data.ConsentId,
+		Reference:        response.Data.Reference,
+		Amount:           amount,
+		FundsAvailable:   response.Data.FundsAvailable,
+		CreationDateTime: parseDateTime(response.Data.CreationDateTime),
+	}, nil
+}
+
+type FundsConfirmationConsentRequestBody struct {
+	Data FundsConfirmationConsentDataRequest `json:"Data"`
+}
+
+type FundsConfirmationConsentDataRequest struct {
+	ExpirationDateTime string              `json:"ExpirationDateTime,omitempty"`
+	DebtorAccount      CashAccountResponse `json:"DebtorAccount"`
+}
+
+type FundsConfirmationConsentResponse struct {
+	Data FundsConfirmationConsentDataResponse `json:"Data"`
+}
+
+type FundsConfirmationConsentDataResponse struct {
+	ConsentId            string               `json:"ConsentId"`
+	Status               string               `json:"Status"`
+	CreationDateTime     string               `json:"CreationDateTime"`
+	StatusUpdateDateTime string               `json:"StatusUpdateDateTime"`
+	ExpirationDateTime   string               `json:"ExpirationDateTime"`
+	DebtorAccount        *CashAccountResponse `json:"DebtorAccount"`
+}
+
+type FundsConfirmationRequestBody struct {
+	Data FundsConfirmationDataRequest `json:"Data"`
+}
+
+type FundsConfirmationDataRequest struct {
+	ConsentId        string         `json:"ConsentId"`
+	Reference        string         `json:"Reference"`
+	InstructedAmount AmountResponse `json:"InstructedAmount"`
+}
+
+type FundsConfirmationResponse struct {
+	Data FundsConfirmationDataResponse `json:"Data"`
+}
+
+type FundsConfirmationDataResponse struct {
+	FundsConfirmationId string         `json:"FundsConfirmationId"`
+	ConsentId           string         `json:"ConsentId"`
+	CreationDateTime    string         `json:"CreationDateTime"`
+	FundsAvailable      bool           `json:"FundsAvailable"`
+	Reference           string         `json:"Reference"`
+	InstructedAmount    AmountResponse `json:"InstructedAmount"`
+}
+
+func mapFundsConfirmationConsent(consent FundsConfirmationConsentDataResponse) FundsConfirmationConsent {
+	return FundsConfirmationConsent{
+		ConsentId:            consent.ConsentId,
+		Status:               consent.Status,
+		CreationDateTime:     parseDateTime(consent.CreationDateTime),
+		StatusUpdateDateTime: parseDateTime(consent.StatusUpdateDateTime),
+		ExpirationDateTime:   parseDateTime(consent.ExpirationDateTime),
+		DebtorAccount:        mapAccountIdentity(consent.DebtorAccount, nil),
+	}
+}
@@ -0,0 +1,83 @@
+package aspsp
+
+import (
+	"encoding/json"
+	"github.com/jmatosp/obclient/authorization"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+)
+
+// AuthorisedFundsConfirmationConsent is a funds confirmation consent on a debtor
+// account together with the token the PSU authorised it with
+type AuthorisedFundsConfirmationConsent struct {
+	ConsentId      string
+	Identification string
+	Token          authorization.Token
+}
+
+var NoAuthorisedFundsConfirmationConsent = AuthorisedFundsConfirmationConsent{}
+
+// FundsConfirmationConsentStore keeps authorised funds confirmation consents by debtor account identification
+type FundsConfirmationConsentStore interface {
+	Store(AuthorisedFundsConfirmationConsent) error
+	Get(string) (AuthorisedFundsConfirmationConsent, error)
+	Delete(string) error
+}
+
+type fileFundsConfirmationConsentStore struct {
+	folder string
+}
+
+func NewFileFundsConfirmationConsentStore(folder string) FundsConfirmationConsentStore {
+	return fileFundsConfirmationConsentStore{
+		folder: folder,
+	}
+}
+
+func (s fileFundsConfirmationConsentStore) Store(consent AuthorisedFundsConfirmationConsent) error {
+	consentJson, err := json.Marshal(consent)
+	if err != nil {
+		return errors.Wrap(err, "error storing funds confirmation consent")
+	}
+
+	err = ioutil.WriteFile(s.filename(consent.Identification), consentJson, 0600)
+	if err != nil {
+		return errors.Wrap(err, "error storing funds confirmation consent")
+	}
+
+	return nil
+}
+
+func (s fileFundsConfirmationConsentStore) Get(identification string) (AuthorisedFundsConfirmationConsent, error) {
+	consentJson, err := ioutil.ReadFile(s.filename(identification))
+	if os.IsNotExist(err) {
+		return NoAuthorisedFundsConfirmationConsent, ErrNotFound
+	} else if err != nil {
+		return NoAuthorisedFundsConfirmationConsent, errors.Wrap(err, "error getting funds confirmation consent")
+	}
+
+	var consent AuthorisedFundsConfirmationConsent
+	if err = json.Unmarshal(consentJson, &consent); err != nil {
+		return NoAuthorisedFundsConfirmationConsent, errors.Wrap(err, "error getting funds confirmation consent")
+	}
+
+	return consent, nil
+}
+
+func (s fileFundsConfirmationConsentStore) Delete(identification string) error {
+	err := os.Remove(s.filename(identification))
+	if os.IsNotExist(err) {
+		return ErrNotFound
+	} else if err != nil {
+		return errors.Wrap(err, "error deleting funds confirmation consent")
+	}
+
+	return nil
+}
+
+func (s fileFundsConfirmationConsentStore) filename(identification string) string {
+	return path.Join(s.folder, "funds-confirmation-"+url.PathEscape(identification)+".json")
+}
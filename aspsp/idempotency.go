@@ -0,0 +1,39 @@
+package aspsp
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"strings"
+)
+
+type idempotencyKeyContext struct{}
+
+type resourceKeyContext struct{}
+
+// WithIdempotencyKey sets the key the x-idempotency-key of consents and payments created with ctx derives
+// from, per request path, overriding the key derived from the resource, so a caller re-running a creation
+// gets the same resource back and each endpoint called with ctx gets its own key
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyContext{}, key)
+}
+
+// IdempotencyKey derives a key from the identifiers of a resource, stable across runs and at most
+// the 40 characters OB allows
+func IdempotencyKey(ids ...string) string {
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(strings.Join(ids, "/"))).String()
+}
+
+// idempotent sets the key of the resource created with ctx, only POSTs with a key are retried by the transport
+func idempotent(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, resourceKeyContext{}, key)
+}
+
+// idempotencyKey is the x-idempotency-key of a POST to path, the caller key of path if set, see
+// WithIdempotencyKey, else the key of the resource
+func idempotencyKey(ctx context.Context, path string) string {
+	if key, _ := ctx.Value(idempotencyKeyContext{}).(string); key != "" {
+		return IdempotencyKey(key, path)
+	}
+	key, _ := ctx.Value(resourceKeyContext{}).(string)
+	return key
+}
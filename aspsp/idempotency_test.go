@@ -0,0 +1,54 @@
+package aspsp
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestIdempotencyKey(t *testing.T) {
+	key := IdempotencyKey("/domestic-payment-consents", "8c3b1d9e")
+	if len(key) > 40 {
+		t.Errorf("key %s longer than 40 characters", key)
+	}
+	if again := IdempotencyKey("/domestic-payment-consents", "8c3b1d9e"); again != key {
+		t.Errorf("got key %s then %s, want a stable key", key, again)
+	}
+	if other := IdempotencyKey("/domestic-payments", "8c3b1d9e"); other == key {
+		t.Errorf("other endpoint got the same key %s", key)
+	}
+	if other := IdempotencyKey("/domestic-payment-consents", "8c3b1d9f"); other == key {
+		t.Errorf("other instruction got the same key %s", key)
+	}
+}
+
+func TestIdempotencyKeyPerRequest(t *testing.T) {
+	caller := WithIdempotencyKey(context.Background(), "run-1")
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		method string
+		path   string
+		want   string
+	}{
+		{name: "resource key", ctx: idempotent(context.Background(), "resource"), method: http.MethodPost, path: "/domestic-payments", want: "resource"},
+		{name: "caller key", ctx: caller, method: http.MethodPost, path: "/domestic-payment-consents", want: IdempotencyKey("run-1", "/domestic-payment-consents")},
+		{name: "caller key of another endpoint", ctx: caller, method: http.MethodPost, path: "/domestic-payments", want: IdempotencyKey("run-1", "/domestic-payments")},
+		{name: "caller key overrides the resource key", ctx: idempotent(caller, "resource"), method: http.MethodPost, path: "/domestic-payments", want: IdempotencyKey("run-1", "/domestic-payments")},
+		{name: "no key", ctx: context.Background(), method: http.MethodPost, path: "/domestic-payments", want: ""},
+		{name: "not a post", ctx: caller, method: http.MethodGet, path: "/domestic-payments/1", want: ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := &keyRecorder{}
+			client := testResourceClient(t, recorder.server(t, `{}`))
+
+			if err := client.DoContext(test.ctx, test.method, test.path, nil, nil); err != nil {
+				t.Fatal(err)
+			}
+			if len(recorder.keys) != 1 || recorder.keys[0] != test.want {
+				t.Errorf("sent keys %q, want [%q]", recorder.keys, test.want)
+			}
+		})
+	}
+}
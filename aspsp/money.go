@@ -0,0 +1,231 @@
+package aspsp
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"strconv"
+	"strings"
+)
+
+// moneyDecimals are the decimal places of OB amounts, Money keeps all of them
+const moneyDecimals = 5
+
+var moneyScale = pow10(moneyDecimals)
+
+var ErrCurrencyMismatch = errors.New("error money currencies do not match")
+
+// Money is a signed decimal amount in an ISO 4217 currency, held as an integer
+// number of 1/100000 units, enough for the 13 integer digits of OB amounts
+type Money struct {
+	units    int64
+	currency string
+}
+
+var NoMoney = Money{}
+
+// ParseMoney parses an OB amount such as 1230.50, an optional leading minus sign is accepted
+func ParseMoney(amount, currency string) (Money, error) {
+	value := strings.TrimSpace(amount)
+	negative := strings.HasPrefix(value, "-")
+	value = strings.TrimPrefix(value, "-")
+
+	integer, fraction := value, ""
+	if dot := strings.Index(value, "."); dot >= 0 {
+		integer, fraction = value[:dot], value[dot+1:]
+	}
+	if integer == "" || len(integer) > 13 || len(fraction) > moneyDecimals || !isDigits(integer) || !isDigits(fraction) || strings.HasSuffix(value, ".") {
+		return NoMoney, errors.Errorf("error invalid amount %q", amount)
+	}
+
+	integerUnits, _ := strconv.ParseInt(integer, 10, 64)
+	fractionUnits := int64(0)
+	if fraction != "" {
+		fractionUnits, _ = strconv.ParseInt(fraction+strings.Repeat("0", moneyDecimals-len(fraction)), 10, 64)
+	}
+
+	units := integerUnits*moneyScale + fractionUnits
+	if negative {
+		units = -units
+	}
+	return Money{units: units, currency: strings.ToUpper(currency)}, nil
+}
+
+// NewMoney returns an amount of minor units of currency, e.g. pence for GBP
+func NewMoney(minorUnits int64, currency string) Money {
+	currency = strings.ToUpper(currency)
+	return Money{units: minorUnits * pow10(moneyDecimals-CurrencyMinorUnits(currency)), currency: currency}
+}
+
+// signedMoney parses an OB amount as negative for Debit credit debit indicators
+func signedMoney(amount AmountResponse, creditDebitIndicator string) (Money, error) {
+	money, err := ParseMoney(amount.Amount, amount.Currency)
+	if err != nil {
+		return NoMoney, err
+	}
+	if strings.EqualFold(creditDebitIndicator, creditDebitIndicatorDebit) {
+		return money.Neg(), nil
+	}
+	return money, nil
+}
+
+// optionalMoney parses optional OB amounts, a missing one is NoMoney
+func optionalMoney(amount AmountResponse) (Money, error) {
+	if amount.Amount == "" {
+		return NoMoney, nil
+	}
+	return ParseMoney(amount.Amount, amount.Currency)
+}
+
+func (m Money) Currency() string {
+	return m.currency
+}
+
+func (m Money) IsZero() bool {
+	return m.units == 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.units < 0
+}
+
+func (m Money) Neg() Money {
+	return Money{units: -m.units, currency: m.currency}
+}
+
+func (m Money) Abs() Money {
+	if m.units < 0 {
+		return m.Neg()
+	}
+	return m
+}
+
+// Add sums amounts of the same currency, a zero NoMoney can be added to any currency
+func (m Money) Add(other Money) (Money, error) {
+	currency, err := m.commonCurrency(other)
+	if err != nil {
+		return NoMoney, err
+	}
+	return Money{units: m.units + other.units, currency: currency}, nil
+}
+
+func (m Money) Sub(other Money) (Money, error) {
+	return m.Add(other.Neg())
+}
+
+// Cmp returns -1, 0 or 1 when m is less, equal or greater than other
+func (m Money) Cmp(other Money) (int, error) {
+	if _, err := m.commonCurrency(other); err != nil {
+		return 0, err
+	}
+	switch {
+	case m.units < other.units:
+		return -1, nil
+	case m.units > other.units:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func (m Money) commonCurrency(other Money) (string, error) {
+	switch {
+	case m.currency == other.currency:
+		return m.currency, nil
+	case m == NoMoney:
+		return other.currency, nil
+	case other == NoMoney:
+		return m.currency, nil
+	}
+	return "", errors.Wrapf(ErrCurrencyMismatch, "%s and %s", m.currency, other.currency)
+}
+
+// MinorUnits returns the amount in minor units of its currency, rounding half away from zero
+func (m Money) MinorUnits() int64 {
+	divisor := pow10(moneyDecimals - CurrencyMinorUnits(m.currency))
+	units := m.units
+	if units < 0 {
+		return -((-units + divisor/2) / divisor)
+	}
+	return (units + divisor/2) / divisor
+}
+
+// Amount formats the signed amount with the minor units of its currency, extra
+// precision is only shown when present, e.g. 1230.50 GBP or 1230 JPY
+func (m Money) Amount() string {
+	units := m.units
+	sign := ""
+	if units < 0 {
+		sign = "-"
+		units = -units
+	}
+
+	fraction := strconv.FormatInt(units%moneyScale+moneyScale, 10)[1:]
+	decimals := CurrencyMinorUnits(m.currency)
+	for len(fraction) > decimals && fraction[len(fraction)-1] == '0' {
+		fraction = fraction[:len(fraction)-1]
+	}
+
+	integer := strconv.FormatInt(units/moneyScale, 10)
+	if fraction == "" {
+		return sign + integer
+	}
+	return sign + integer + "." + fraction
+}
+
+func (m Money) String() string {
+	return strings.TrimSpace(m.Amount() + " " + m.currency)
+}
+
+type moneyJson struct {
+	Amount   string `json:"Amount"`
+	Currency string `json:"Currency"`
+}
+
+// MarshalJSON writes money as an OB amount object
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJson{Amount: m.Amount(), Currency: m.currency})
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var value moneyJson
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	money, err := optionalMoney(AmountResponse(value))
+	if err != nil {
+		return err
+	}
+	*m = money
+	return nil
+}
+
+// currencyMinorUnits are ISO 4217 currencies without the usual 2 decimal places
+var currencyMinorUnits = map[string]int{
+	"BHD": 3, "CLP": 0, "IQD": 3, "ISK": 0, "JOD": 3, "JPY": 0, "KRW": 0, "KWD": 3,
+	"LYD": 3, "OMR": 3, "PYG": 0, "TND": 3, "UGX": 0, "VND": 0, "XAF": 0, "XOF": 0,
+}
+
+// CurrencyMinorUnits returns the decimal places of an ISO 4217 currency
+func CurrencyMinorUnits(currency string) int {
+	if decimals, ok := currencyMinorUnits[strings.ToUpper(currency)]; ok {
+		return decimals
+	}
+	return 2
+}
+
+func isDigits(value string) bool {
+	for _, c := range value {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func pow10(exponent int) int64 {
+	result := int64(1)
+	for i := 0; i < exponent; i++ {
+		result *= 10
+	}
+	return result
+}
@@ -0,0 +1,128 @@
+package aspsp
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		name       string
+		amount     string
+		currency   string
+		want       string
+		minorUnits int64
+		wantErr    bool
+	}{
+		{name: "pounds and pence", amount: "1230.50", currency: "GBP", want: "1230.50 GBP", minorUnits: 123050},
+		{name: "integer", amount: "12", currency: "GBP", want: "12.00 GBP", minorUnits: 1200},
+		{name: "negative", amount: "-0.01", currency: "GBP", want: "-0.01 GBP", minorUnits: -1},
+		{name: "extra precision is kept", amount: "10.12345", currency: "GBP", want: "10.12345 GBP", minorUnits: 1012},
+		{name: "minor units round half away from zero", amount: "-10.125", currency: "GBP", want: "-10.125 GBP", minorUnits: -1013},
+		{name: "currency without minor units", amount: "1230", currency: "JPY", want: "1230 JPY", minorUnits: 1230},
+		{name: "lower case currency", amount: "1", currency: "eur", want: "1.00 EUR", minorUnits: 100},
+		{name: "surrounding spaces", amount: " 5.5 ", currency: "GBP", want: "5.50 GBP", minorUnits: 550},
+		{name: "13 integer digits", amount: "9999999999999.99999", currency: "GBP", want: "9999999999999.99999 GBP", minorUnits: 1000000000000000},
+		{name: "empty", amount: "", currency: "GBP", wantErr: true},
+		{name: "trailing dot", amount: "10.", currency: "GBP", wantErr: true},
+		{name: "leading dot", amount: ".5", currency: "GBP", wantErr: true},
+		{name: "too many decimals", amount: "1.123456", currency: "GBP", wantErr: true},
+		{name: "too many integer digits", amount: "10000000000000", currency: "GBP", wantErr: true},
+		{name: "plus sign", amount: "+1", currency: "GBP", wantErr: true},
+		{name: "thousands separator", amount: "1,000.00", currency: "GBP", wantErr: true},
+		{name: "exponent", amount: "1e3", currency: "GBP", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			money, err := ParseMoney(test.amount, test.currency)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("ParseMoney(%q) = %s, want error", test.amount, money)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseMoney(%q): %v", test.amount, err)
+			}
+			if money.String() != test.want {
+				t.Errorf("ParseMoney(%q) = %s, want %s", test.amount, money, test.want)
+			}
+			if money.MinorUnits() != test.minorUnits {
+				t.Errorf("ParseMoney(%q).MinorUnits() = %d, want %d", test.amount, money.MinorUnits(), test.minorUnits)
+			}
+		})
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	gbp := func(amount string) Money {
+		money, err := ParseMoney(amount, "GBP")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return money
+	}
+
+	tests := []struct {
+		name    string
+		sum     func() (Money, error)
+		want    string
+		wantErr bool
+	}{
+		{name: "add", sum: func() (Money, error) { return gbp("0.10").Add(gbp("0.20")) }, want: "0.30 GBP"},
+		{name: "sub below zero", sum: func() (Money, error) { return gbp("0.10").Sub(gbp("0.25")) }, want: "-0.15 GBP"},
+		{name: "no money takes the other currency", sum: func() (Money, error) { return NoMoney.Add(gbp("1")) }, want: "1.00 GBP"},
+		{name: "minor units", sum: func() (Money, error) { return NewMoney(1999, "GBP").Add(NewMoney(1, "GBP")) }, want: "20.00 GBP"},
+		{name: "currency mismatch", sum: func() (Money, error) { return gbp("1").Add(NewMoney(1, "EUR")) }, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			money, err := test.sum()
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("got %s, want error", money)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if money.String() != test.want {
+				t.Errorf("got %s, want %s", money, test.want)
+			}
+		})
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want Money
+	}{
+		{name: "amount", json: `{"Amount":"10.50","Currency":"GBP"}`, want: NewMoney(1050, "GBP")},
+		{name: "missing amount", json: `{}`, want: NoMoney},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var money Money
+			if err := json.Unmarshal([]byte(test.json), &money); err != nil {
+				t.Fatal(err)
+			}
+			if money != test.want {
+				t.Errorf("got %s, want %s", money, test.want)
+			}
+			if test.want == NoMoney {
+				return
+			}
+
+			marshalled, err := json.Marshal(money)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(marshalled) != test.json {
+				t.Errorf("marshalled %s, want %s", marshalled, test.json)
+			}
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package aspsp
+
+import "time"
+
+type Offer struct {
+	Id            string
+	AccountId     AccountId
+	Type          string
+	Description   string
+	StartDateTime time.Time
+	EndDateTime   time.Time
+	Rate          string
+	Term          string
+	URL           string
+	Amount        Money
+}
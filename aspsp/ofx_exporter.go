@@ -0,0 +1,187 @@
+package aspsp
+
+import (
+	"encoding/xml"
+	"github.com/pkg/errors"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	ofxHeader         = `<?xml version="1.0" encoding="UTF-8" standalone="no"?>` + "\n" + `<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>` + "\n"
+	ofxDateTimeLayout = "20060102150405"
+)
+
+// ofxExporter writes OFX 2.2 bank statement responses
+type ofxExporter struct{}
+
+func (ofxExporter) Export(w io.Writer, statement ExportStatement) error {
+	bankId, accountId := splitAccountIdentification(statement.identity())
+
+	response := ofxStatementResponse{
+		Currency: statement.Account.Currency(),
+		Account: ofxBankAccount{
+			BankId:      bankId,
+			AccountId:   accountId,
+			AccountType: ofxAccountType(statement.Account.Subtype()),
+		},
+		TransactionList: ofxTransactionList{
+			Start: statement.From.Format(ofxDateTimeLayout),
+			End:   statement.To.Format(ofxDateTimeLayout),
+		},
+	}
+
+	for _, transaction := range statement.bookedTransactions() {
+		response.TransactionList.Transactions = append(response.TransactionList.Transactions, ofxTransaction{
+			Type:      ofxTransactionType(transaction.Credit()),
+			Posted:    transaction.BookingDateTime.Format(ofxDateTimeLayout),
+			Available: ofxDate(transaction.ValueDateTime),
+			Amount:    transaction.Amount.Amount(),
+			Id:        transaction.Id,
+			Name:      truncate(transaction.Information, 32),
+			Memo:      truncate(ofxMemo(transaction), 255),
+		})
+	}
+
+	for _, balance := range statement.Balances {
+		ofxBalance := &ofxBalance{
+			Amount: balance.Amount.Amount(),
+			AsOf:   balance.DateTime.Format(ofxDateTimeLayout),
+		}
+		switch {
+		case strings.HasSuffix(balance.Type, "Booked") && response.LedgerBalance == nil:
+			response.LedgerBalance = ofxBalance
+		case strings.HasSuffix(balance.Type, "Available") && response.AvailableBalance == nil:
+			response.AvailableBalance = ofxBalance
+		}
+	}
+
+	document := ofxDocument{
+		SignOn: ofxSignOn{
+			Status:   ofxStatusOK,
+			Server:   time.Now().Format(ofxDateTimeLayout),
+			Language: "ENG",
+		},
+		Bank: ofxBankMessages{
+			Statement: ofxStatementTransaction{
+				TransactionId: "0",
+				Status:        ofxStatusOK,
+				Response:      response,
+			},
+		},
+	}
+
+	if _, err := io.WriteString(w, ofxHeader); err != nil {
+		return errors.Wrap(err, "error exporting ofx")
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(document); err != nil {
+		return errors.Wrap(err, "error exporting ofx")
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return errors.Wrap(err, "error exporting ofx")
+}
+
+func ofxAccountType(subtype string) string {
+	switch subtype {
+	case "Savings":
+		return "SAVINGS"
+	case "Loan", "Mortgage":
+		return "CREDITLINE"
+	}
+	return "CHECKING"
+}
+
+func ofxTransactionType(credit bool) string {
+	if credit {
+		return "CREDIT"
+	}
+	return "DEBIT"
+}
+
+func ofxDate(date time.Time) string {
+	if date.IsZero() {
+		return ""
+	}
+	return date.Format(ofxDateTimeLayout)
+}
+
+var ofxStatusOK = ofxStatus{Code: "0", Severity: "INFO"}
+
+type ofxDocument struct {
+	XMLName xml.Name        `xml:"OFX"`
+	SignOn  ofxSignOn       `xml:"SIGNONMSGSRSV1>SONRS"`
+	Bank    ofxBankMessages `xml:"BANKMSGSRSV1"`
+}
+
+type ofxStatus struct {
+	Code     string `xml:"CODE"`
+	Severity string `xml:"SEVERITY"`
+}
+
+type ofxSignOn struct {
+	Status   ofxStatus `xml:"STATUS"`
+	Server   string    `xml:"DTSERVER"`
+	Language string    `xml:"LANGUAGE"`
+}
+
+type ofxBankMessages struct {
+	Statement ofxStatementTransaction `xml:"STMTTRNRS"`
+}
+
+type ofxStatementTransaction struct {
+	TransactionId string               `xml:"TRNUID"`
+	Status        ofxStatus            `xml:"STATUS"`
+	Response      ofxStatementResponse `xml:"STMTRS"`
+}
+
+type ofxStatementResponse struct {
+	Currency         string             `xml:"CURDEF"`
+	Account          ofxBankAccount     `xml:"BANKACCTFROM"`
+	TransactionList  ofxTransactionList `xml:"BANKTRANLIST"`
+	LedgerBalance    *ofxBalance        `xml:"LEDGERBAL,omitempty"`
+	AvailableBalance *ofxBalance        `xml:"AVAILBAL,omitempty"`
+}
+
+type ofxBankAccount struct {
+	BankId      string `xml:"BANKID"`
+	AccountId   string `xml:"ACCTID"`
+	AccountType string `xml:"ACCTTYPE"`
+}
+
+type ofxTransactionList struct {
+	Start        string           `xml:"DTSTART"`
+	End          string           `xml:"DTEND"`
+	Transactions []ofxTransaction `xml:"STMTTRN"`
+}
+
+type ofxTransaction struct {
+	Type      string `xml:"TRNTYPE"`
+	Posted    string `xml:"DTPOSTED"`
+	Available string `xml:"DTAVAIL,omitempty"`
+	Amount    string `xml:"TRNAMT"`
+	Id        string `xml:"FITID"`
+	Name      string `xml:"NAME,omitempty"`
+	Memo      string `xml:"MEMO,omitempty"`
+}
+
+type ofxBalance struct {
+	Amount string `xml:"BALAMT"`
+	AsOf   string `xml:"DTASOF"`
+}
+
+// ofxMemo appends categories to the reference as OFX transactions have no category
+func ofxMemo(transaction Transaction) string {
+	if len(transaction.Categories) == 0 {
+		return transaction.Reference
+	}
+	categories := "[" + strings.Join(transaction.Categories, ", ") + "]"
+	if transaction.Reference == "" {
+		return categories
+	}
+	return transaction.Reference + " " + categories
+}
@@ -0,0 +1,15 @@
+package aspsp
+
+type Party struct {
+	Id            string
+	Number        string
+	Type          string
+	Name          string
+	FullLegalName string
+	EmailAddress  string
+	Phone         string
+	Mobile        string
+	AccountRole   string
+}
+
+var NoParty = Party{}
@@ -0,0 +1,272 @@
+package aspsp
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Payment types, each has its own consent and payment endpoints
+const (
+	PaymentDomestic              = "domestic"
+	PaymentDomesticScheduled     = "domestic-scheduled"
+	PaymentDomesticStandingOrder = "domestic-standing-order"
+	PaymentInternational         = "international"
+)
+
+// Exchange rate types of international payments
+const (
+	RateTypeActual     = "Actual"
+	RateTypeAgreed     = "Agreed"
+	RateTypeIndicative = "Indicative"
+)
+
+// PaymentOrder is what a payment consent asks the PSU to authorise, members
+// only apply to some payment types:
+// - domestic scheduled payments need RequestedExecutionDateTime
+// - standing orders need Frequency and FirstPaymentDateTime, Amount is the amount of every payment
+// - international payments need CurrencyOfTransfer, ExchangeRate is optional
+type PaymentOrder struct {
+	Type string
+	// InstructionId and EndToEndId are generated when empty
+	InstructionId              string
+	EndToEndId                 string
+	Amount                     Money
+	DebtorAccount              AccountIdentity
+	CreditorAccount            AccountIdentity
+	Reference                  string
+	Information                string
+	RequestedExecutionDateTime time.Time
+	Frequency                  string
+	FirstPaymentDateTime       time.Time
+	NumberOfPayments           int
+	FinalPaymentDateTime       time.Time
+	CurrencyOfTransfer         string
+	ExchangeRate               *ExchangeRate
+}
+
+// ExchangeRate of an international payment, Rate is the amount of the currency of
+// transfer per unit currency. Agreed rates need Rate and ContractId, Actual and
+// Indicative ones are quoted by the ASPSP
+type ExchangeRate struct {
+	UnitCurrency       string
+	Rate               string
+	RateType           string
+	ContractId         string
+	ExpirationDateTime time.Time
+}
+
+type PaymentConsent struct {
+	ConsentId                 string
+	Type                      string
+	Status                    string
+	CreationDateTime          time.Time
+	StatusUpdateDateTime      time.Time
+	CutOffDateTime            time.Time
+	ExpectedExecutionDateTime time.Time
+	// ExchangeRate is the rate quoted by the ASPSP for international payments
+	ExchangeRate *ExchangeRate
+	// Order is the order the consent was created with, payments must be submitted with it
+	Order PaymentOrder
+}
+
+var NoPaymentConsent = PaymentConsent{}
+
+type Payment struct {
+	Id                        string
+	Type                      string
+	ConsentId                 string
+	Status                    string
+	CreationDateTime          time.Time
+	StatusUpdateDateTime      time.Time
+	ExpectedExecutionDateTime time.Time
+	ExchangeRate              *ExchangeRate
+}
+
+var NoPayment = Payment{}
+
+type paymentEndpoint struct {
+	consents string
+	payments string
+}
+
+var paymentEndpoints = map[string]paymentEndpoint{
+	PaymentDomestic:              {"/domestic-payment-consents", "/domestic-payments"},
+	PaymentDomesticScheduled:     {"/domestic-scheduled-payment-consents", "/domestic-scheduled-payments"},
+	PaymentDomesticStandingOrder: {"/domestic-standing-order-consents", "/domestic-standing-orders"},
+	PaymentInternational:         {"/international-payment-consents", "/international-payments"},
+}
+
+func endpointOf(paymentType string) (paymentEndpoint, error) {
+	endpoint, ok := paymentEndpoints[paymentType]
+	if !ok {
+		return paymentEndpoint{}, errors.Errorf("error unknown payment type %s", paymentType)
+	}
+	return endpoint, nil
+}
+
+// withIds fills the identifications the consent and payment submission must share
+func (o PaymentOrder) withIds() PaymentOrder {
+	if o.InstructionId == "" {
+		o.InstructionId = strings.Replace(uuid.New().String(), "-", "", -1)
+	}
+	if o.EndToEndId == "" {
+		o.EndToEndId = o.InstructionId
+	}
+	return o
+}
+
+// initiation validates the order and builds the OB initiation of its payment type
+func (o PaymentOrder) initiation() (PaymentInitiationRequest, error) {
+	if _, err := endpointOf(o.Type); err != nil {
+		return PaymentInitiationRequest{}, err
+	}
+	if o.CreditorAccount == nil {
+		return PaymentInitiationRequest{}, errors.New("error creditor account not provided")
+	}
+	if o.Amount.IsNegative() || o.Amount.IsZero() {
+		return PaymentInitiationRequest{}, errors.Errorf("error invalid payment amount %s", o.Amount)
+	}
+
+	amount := &AmountResponse{Amount: o.Amount.Amount(), Currency: o.Amount.Currency()}
+	initiation := PaymentInitiationRequest{
+		DebtorAccount:   cashAccountRequest(o.DebtorAccount),
+		CreditorAccount: cashAccountRequest(o.CreditorAccount),
+	}
+
+	if o.Type == PaymentDomesticStandingOrder {
+		if o.Frequency == "" || o.FirstPaymentDateTime.IsZero() {
+			return PaymentInitiationRequest{}, errors.New("error standing orders need frequency and first payment date")
+		}
+		initiation.Frequency = o.Frequency
+		initiation.Reference = o.Reference
+		initiation.FirstPaymentDateTime = o.FirstPaymentDateTime.Format(time.RFC3339)
+		initiation.FirstPaymentAmount = amount
+		initiation.RecurringPaymentAmount = amount
+		if o.NumberOfPayments > 0 {
+			initiation.NumberOfPayments = strconv.Itoa(o.NumberOfPayments)
+		}
+		if !o.FinalPaymentDateTime.IsZero() {
+			initiation.FinalPaymentDateTime = o.FinalPaymentDateTime.Format(time.RFC3339)
+		}
+		return initiation, nil
+	}
+
+	initiation.InstructionIdentification = o.InstructionId
+	initiation.EndToEndIdentification = o.EndToEndId
+	initiation.InstructedAmount = amount
+	if o.Reference != "" || o.Information != "" {
+		initiation.RemittanceInformation = &RemittanceInformationRequest{
+			Reference:    o.Reference,
+			Unstructured: o.Information,
+		}
+	}
+
+	switch o.Type {
+	case PaymentDomesticScheduled:
+		if o.RequestedExecutionDateTime.IsZero() {
+			return PaymentInitiationRequest{}, errors.New("error scheduled payments need a requested execution date")
+		}
+		initiation.RequestedExecutionDateTime = o.RequestedExecutionDateTime.Format(time.RFC3339)
+
+	case PaymentInternational:
+		if o.CurrencyOfTransfer == "" {
+			return PaymentInitiationRequest{}, errors.New("error international payments need a currency of transfer")
+		}
+		initiation.CurrencyOfTransfer = strings.ToUpper(o.CurrencyOfTransfer)
+		if o.ExchangeRate != nil {
+			rate, err := exchangeRateRequest(*o.ExchangeRate)
+			if err != nil {
+				return PaymentInitiationRequest{}, err
+			}
+			initiation.ExchangeRateInformation = rate
+		}
+	}
+
+	return initiation, nil
+}
+
+func exchangeRateRequest(rate ExchangeRate) (*ExchangeRateResponse, error) {
+	request := &ExchangeRateResponse{
+		UnitCurrency: strings.ToUpper(rate.UnitCurrency),
+		RateType:     rate.RateType,
+	}
+	switch rate.RateType {
+	case RateTypeAgreed:
+		if _, err := strconv.ParseFloat(rate.Rate, 64); err != nil || rate.ContractId == "" {
+			return nil, errors.New("error agreed exchange rates need a rate and contract id")
+		}
+		request.ExchangeRate = json.Number(rate.Rate)
+		request.ContractIdentification = rate.ContractId
+	case RateTypeActual, RateTypeIndicative:
+		if rate.Rate != "" || rate.ContractId != "" {
+			return nil, errors.Errorf("error %s exchange rates are quoted by the bank, rate and contract id not allowed", strings.ToLower(rate.RateType))
+		}
+	default:
+		return nil, errors.Errorf("error unknown exchange rate type %s", rate.RateType)
+	}
+	return request, nil
+}
+
+func cashAccountRequest(identity AccountIdentity) *CashAccountResponse {
+	if identity == nil {
+		return nil
+	}
+	return &CashAccountResponse{
+		SchemeName:              identity.SchemaName(),
+		Identification:          identity.Identification(),
+		Name:                    identity.Name(),
+		SecondaryIdentification: identity.SecondaryIdentification(),
+	}
+}
+
+// PaymentInitiationRequest is the OB initiation of all payment types, members of
+// other types are left empty so they are omitted
+type PaymentInitiationRequest struct {
+	InstructionIdentification  string                        `json:"InstructionIdentification,omitempty"`
+	EndToEndIdentification     string                        `json:"EndToEndIdentification,omitempty"`
+	RequestedExecutionDateTime string                        `json:"RequestedExecutionDateTime,omitempty"`
+	Frequency                  string                        `json:"Frequency,omitempty"`
+	Reference                  string                        `json:"Reference,omitempty"`
+	NumberOfPayments           string                        `json:"NumberOfPayments,omitempty"`
+	FirstPaymentDateTime       string                        `json:"FirstPaymentDateTime,omitempty"`
+	FinalPaymentDateTime       string                        `json:"FinalPaymentDateTime,omitempty"`
+	FirstPaymentAmount         *AmountResponse               `json:"FirstPaymentAmount,omitempty"`
+	RecurringPaymentAmount     *AmountResponse               `json:"RecurringPaymentAmount,omitempty"`
+	CurrencyOfTransfer         string                        `json:"CurrencyOfTransfer,omitempty"`
+	InstructedAmount           *AmountResponse               `json:"InstructedAmount,omitempty"`
+	ExchangeRateInformation    *ExchangeRateResponse         `json:"ExchangeRateInformation,omitempty"`
+	DebtorAccount              *CashAccountResponse          `json:"DebtorAccount,omitempty"`
+	CreditorAccount            *CashAccountResponse          `json:"CreditorAccount"`
+	RemittanceInformation      *RemittanceInformationRequest `json:"RemittanceInformation,omitempty"`
+}
+
+type RemittanceInformationRequest struct {
+	Reference    string `json:"Reference,omitempty"`
+	Unstructured string `json:"Unstructured,omitempty"`
+}
+
+// ExchangeRateResponse is the OB exchange rate information, the rate is a JSON number
+type ExchangeRateResponse struct {
+	UnitCurrency           string      `json:"UnitCurrency"`
+	ExchangeRate           json.Number `json:"ExchangeRate,omitempty"`
+	RateType               string      `json:"RateType"`
+	ContractIdentification string      `json:"ContractIdentification,omitempty"`
+	ExpirationDateTime     string      `json:"ExpirationDateTime,omitempty"`
+}
+
+func mapExchangeRate(rate *ExchangeRateResponse) *ExchangeRate {
+	if rate == nil {
+		return nil
+	}
+	return &ExchangeRate{
+		UnitCurrency:       rate.UnitCurrency,
+		Rate:               rate.ExchangeRate.String(),
+		RateType:           rate.RateType,
+		ContractId:         rate.ContractIdentification,
+		ExpirationDateTime: parseDateTime(rate.ExpirationDateTime),
+	}
+}
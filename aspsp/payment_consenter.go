@@ -0,0 +1,115 @@
+package aspsp
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"net/url"
+)
+
+// PaymentConsenter creates payment consents for the PSU to authorise, it needs a
+// client credentials token, see authorization.IntentAuthenticator
+type PaymentConsenter interface {
+	Create(PaymentOrder) (PaymentConsent, error)
+	CreateContext(context.Context, PaymentOrder) (PaymentConsent, error)
+	Get(string, string) (PaymentConsent, error)
+	GetContext(context.Context, string, string) (PaymentConsent, error)
+}
+
+type paymentConsenter struct {
+	client ResourceClient
+}
+
+func NewPaymentConsenter(client ResourceClient) PaymentConsenter {
+	return &paymentConsenter{
+		client: client,
+	}
+}
+
+func (p *paymentConsenter) Create(order PaymentOrder) (PaymentConsent, error) {
+	return p.CreateContext(context.Background(), order)
+}
+
+func (p *paymentConsenter) CreateContext(ctx context.Context, order PaymentOrder) (PaymentConsent, error) {
+	order = order.withIds()
+	initiation, err := order.initiation()
+	if err != nil {
+		return NoPaymentConsent, errors.Wrap(err, "error creating payment consent")
+	}
+	endpoint, _ := endpointOf(order.Type)
+
+	body := PaymentConsentRequest{
+		Data: PaymentConsentDataRequest{Initiation: initiation},
+		Risk: map[string]string{},
+	}
+	// future dated payments are created, not just validated, when the PSU authorises them
+	if order.Type == PaymentDomesticScheduled || order.Type == PaymentDomesticStandingOrder {
+		body.Data.Permission = "Create"
+	}
+
+	var response PaymentConsentResponse
+	ctx = idempotent(ctx, IdempotencyKey(endpoint.consents, order.InstructionId))
+	ctx = signed(ctx)
+	if err = p.client.PostContext(ctx, endpoint.consents, body, &response); err != nil {
+		return NoPaymentConsent, errors.Wrap(err, "error creating payment consent")
+	}
+
+	consent := mapPaymentConsent(order.Type, response.Data)
+	consent.Order = order
+	return consent, nil
+}
+
+func (p *paymentConsenter) Get(paymentType, consentId string) (PaymentConsent, error) {
+	return p.GetContext(context.Background(), paymentType, consentId)
+}
+
+// GetContext gets the consent status, its Order is not returned
+func (p *paymentConsenter) GetContext(ctx context.Context, paymentType, consentId string) (PaymentConsent, error) {
+	endpoint, err := endpointOf(paymentType)
+	if err != nil {
+		return NoPaymentConsent, errors.Wrap(err, "error getting payment consent")
+	}
+
+	var response PaymentConsentResponse
+	if err = p.client.GetContext(ctx, endpoint.consents+"/"+url.PathEscape(consentId), &response); err != nil {
+		return NoPaymentConsent, errors.Wrap(err, "error getting payment consent")
+	}
+
+	return mapPaymentConsent(paymentType, response.Data), nil
+}
+
+type PaymentConsentRequest struct {
+	Data PaymentConsentDataRequest `json:"Data"`
+	Risk map[string]string         `json:"Risk"`
+}
+
+type PaymentConsentDataRequest struct {
+	Permission string                   `json:"Permission,omitempty"`
+	Initiation PaymentInitiationRequest `json:"Initiation"`
+}
+
+type PaymentConsentResponse struct {
+	Data PaymentConsentDataResponse `json:"Data"`
+}
+
+type PaymentConsentDataResponse struct {
+	ConsentId                 string                `json:"ConsentId"`
+	Status                    string                `json:"Status"`
+	CreationDateTime          string                `json:"CreationDateTime"`
+	StatusUpdateDateTime      string                `json:"StatusUpdateDateTime"`
+	CutOffDateTime            string                `json:"CutOffDateTime"`
+	ExpectedExecutionDateTime string                `json:"ExpectedExecutionDateTime"`
+	ExchangeRateInformation   *ExchangeRateResponse `json:"ExchangeRateInformation"`
+}
+
+func mapPaymentConsent(paymentType string, consent PaymentConsentDataResponse) PaymentConsent {
+	return PaymentConsent{
+		ConsentId:                 consent.ConsentId,
+		Type:                      paymentType,
+		Status:                    consent.Status,
+		CreationDateTime:          parseDateTime(consent.CreationDateTime),
+		StatusUpdateDateTime:      parseDateTime(consent.StatusUpdateDateTime),
+		CutOffDateTime:            parseDateTime(consent.CutOffDateTime),
+		ExpectedExecutionDateTime: parseDateTime(consent.ExpectedExecutionDateTime),
+		ExchangeRate:              mapExchangeRate(consent.ExchangeRateInformation),
+	}
+}
@@ -0,0 +1,144 @@
+package aspsp
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"io"
+	"strings"
+)
+
+// FileTypePaymentInitiation is the OB JSON payment file of domestic payment initiations
+const FileTypePaymentInitiation = "UK.OBIE.PaymentInitiation.3.1"
+
+// Payee is one payment of a payment file
+type Payee struct {
+	Name           string
+	SchemeName     string
+	Identification string
+	Amount         Money
+	Reference      string
+}
+
+// PaymentFile is the file uploaded to a file payment consent, NumberOfTransactions
+// and ControlSum, the sum of its amounts, are declared on the consent
+type PaymentFile struct {
+	Type                 string
+	Content              []byte
+	NumberOfTransactions int
+	ControlSum           Money
+}
+
+var NoPaymentFile = PaymentFile{}
+
+// Hash is the base64 SHA-256 of the file content the ASPSP checks the upload against
+func (f PaymentFile) Hash() string {
+	hash := sha256.Sum256(f.Content)
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+// NewPaymentFile builds a UK.OBIE.PaymentInitiation.3.1 file paying payees, all in the same currency
+func NewPaymentFile(payees []Payee) (PaymentFile, error) {
+	if len(payees) == 0 {
+		return NoPaymentFile, errors.New("error building payment file: no payees")
+	}
+
+	controlSum := NewMoney(0, payees[0].Amount.Currency())
+	file := PaymentFileContent{}
+	for i, payee := range payees {
+		if payee.Identification == "" {
+			return NoPaymentFile, errors.Errorf("error building payment file: payee %d has no account identification", i+1)
+		}
+		if payee.Amount.IsNegative() || payee.Amount.IsZero() {
+			return NoPaymentFile, errors.Errorf("error building payment file: payee %d has invalid amount %s", i+1, payee.Amount)
+		}
+		sum, err := controlSum.Add(payee.Amount)
+		if err != nil {
+			return NoPaymentFile, errors.Wrapf(err, "error building payment file: payee %d", i+1)
+		}
+		controlSum = sum
+
+		order := PaymentOrder{
+			Type:            PaymentDomestic,
+			Amount:          payee.Amount,
+			CreditorAccount: NewAccountIdentity(payee.SchemeName, payee.Identification, payee.Name, "", ""),
+			Reference:       payee.Reference,
+		}.withIds()
+		initiation, err := order.initiation()
+		if err != nil {
+			return NoPaymentFile, errors.Wrapf(err, "error building payment file: payee %d", i+1)
+		}
+		file.Data.DomesticPayments = append(file.Data.DomesticPayments, initiation)
+	}
+
+	content, err := json.Marshal(file)
+	if err != nil {
+		return NoPaymentFile, errors.Wrap(err, "error building payment file")
+	}
+
+	return PaymentFile{
+		Type:                 FileTypePaymentInitiation,
+		Content:              content,
+		NumberOfTransactions: len(payees),
+		ControlSum:           controlSum,
+	}, nil
+}
+
+// ReadPayees reads a CSV with a header row naming its columns: name, identification,
+// amount, currency and reference, and optionally scheme which defaults to defaultScheme
+func ReadPayees(reader io.Reader, defaultScheme string) ([]Payee, error) {
+	records, err := csv.NewReader(reader).ReadAll()
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading payees")
+	}
+	if len(records) == 0 {
+		return nil, errors.New("error reading payees: missing header row")
+	}
+
+	columns := map[string]int{}
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"identification", "amount", "currency"} {
+		if _, ok := columns[required]; !ok {
+			return nil, errors.Errorf("error reading payees: missing %s column", required)
+		}
+	}
+	column := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var payees []Payee
+	for line, record := range records[1:] {
+		amount, err := ParseMoney(column(record, "amount"), column(record, "currency"))
+		if err != nil {
+			return nil, errors.Wrapf(err, "error reading payees line %d", line+2)
+		}
+		scheme := column(record, "scheme")
+		if scheme == "" {
+			scheme = defaultScheme
+		}
+		payees = append(payees, Payee{
+			Name:           column(record, "name"),
+			SchemeName:     scheme,
+			Identification: column(record, "identification"),
+			Amount:         amount,
+			Reference:      column(record, "reference"),
+		})
+	}
+
+	return payees, nil
+}
+
+type PaymentFileContent struct {
+	Data PaymentFileDataContent `json:"Data"`
+}
+
+type PaymentFileDataContent struct {
+	DomesticPayments []PaymentInitiationRequest `json:"DomesticPayments"`
+}
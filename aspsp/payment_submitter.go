@@ -0,0 +1,122 @@
+package aspsp
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"net/url"
+)
+
+// PaymentSubmitter submits payments of authorised consents, it needs the token the
+// PSU authorised the consent with, getting payments also works with client credentials
+type PaymentSubmitter interface {
+	Submit(PaymentConsent) (Payment, error)
+	SubmitContext(context.Context, PaymentConsent) (Payment, error)
+	Get(string, string) (Payment, error)
+	GetContext(context.Context, string, string) (Payment, error)
+}
+
+type paymentSubmitter struct {
+	client ResourceClient
+}
+
+func NewPaymentSubmitter(client ResourceClient) PaymentSubmitter {
+	return &paymentSubmitter{
+		client: client,
+	}
+}
+
+func (p *paymentSubmitter) Submit(consent PaymentConsent) (Payment, error) {
+	return p.SubmitContext(context.Background(), consent)
+}
+
+// SubmitContext submits the payment with the consent Order, which must be the one the consent was created with
+func (p *paymentSubmitter) SubmitContext(ctx context.Context, consent PaymentConsent) (Payment, error) {
+	initiation, err := consent.Order.initiation()
+	if err != nil {
+		return NoPayment, errors.Wrap(err, "error submitting payment")
+	}
+	endpoint, _ := endpointOf(consent.Order.Type)
+
+	body := PaymentRequest{
+		Data: PaymentDataRequest{
+			ConsentId:  consent.ConsentId,
+			Initiation: initiation,
+		},
+		Risk: map[string]string{},
+	}
+
+	var response PaymentResponse
+	ctx = idempotent(ctx, IdempotencyKey(endpoint.payments, consent.ConsentId))
+	ctx = signed(ctx)
+	if err = p.client.PostContext(ctx, endpoint.payments, body, &response); err != nil {
+		return NoPayment, errors.Wrap(err, "error submitting payment")
+	}
+
+	return mapPayment(consent.Order.Type, response.Data), nil
+}
+
+func (p *paymentSubmitter) Get(paymentType, paymentId string) (Payment, error) {
+	return p.GetContext(context.Background(), paymentType, paymentId)
+}
+
+func (p *paymentSubmitter) GetContext(ctx context.Context, paymentType, paymentId string) (Payment, error) {
+	endpoint, err := endpointOf(paymentType)
+	if err != nil {
+		return NoPayment, errors.Wrap(err, "error getting payment")
+	}
+
+	var response PaymentResponse
+	if err = p.client.GetContext(ctx, endpoint.payments+"/"+url.PathEscape(paymentId), &response); err != nil {
+		return NoPayment, errors.Wrap(err, "error getting payment")
+	}
+
+	return mapPayment(paymentType, response.Data), nil
+}
+
+type PaymentRequest struct {
+	Data PaymentDataRequest `json:"Data"`
+	Risk map[string]string  `json:"Risk"`
+}
+
+type PaymentDataRequest struct {
+	ConsentId  string                   `json:"ConsentId"`
+	Initiation PaymentInitiationRequest `json:"Initiation"`
+}
+
+type PaymentResponse struct {
+	Data PaymentDataResponse `json:"Data"`
+}
+
+// PaymentDataResponse holds the id members of all payment types, only the one of the type is set
+type PaymentDataResponse struct {
+	DomesticPaymentId          string                `json:"DomesticPaymentId"`
+	DomesticScheduledPaymentId string                `json:"DomesticScheduledPaymentId"`
+	DomesticStandingOrderId    string                `json:"DomesticStandingOrderId"`
+	InternationalPaymentId     string                `json:"InternationalPaymentId"`
+	ConsentId                  string                `json:"ConsentId"`
+	Status                     string                `json:"Status"`
+	CreationDateTime           string                `json:"CreationDateTime"`
+	StatusUpdateDateTime       string                `json:"StatusUpdateDateTime"`
+	ExpectedExecutionDateTime  string                `json:"ExpectedExecutionDateTime"`
+	ExchangeRateInformation    *ExchangeRateResponse `json:"ExchangeRateInformation"`
+}
+
+func mapPayment(paymentType string, payment PaymentDataResponse) Payment {
+	ids := map[string]string{
+		PaymentDomestic:              payment.DomesticPaymentId,
+		PaymentDomesticScheduled:     payment.DomesticScheduledPaymentId,
+		PaymentDomesticStandingOrder: payment.DomesticStandingOrderId,
+		PaymentInternational:         payment.InternationalPaymentId,
+	}
+
+	return Payment{
+		Id:                        ids[paymentType],
+		Type:                      paymentType,
+		ConsentId:                 payment.ConsentId,
+		Status:                    payment.Status,
+		CreationDateTime:          parseDateTime(payment.CreationDateTime),
+		StatusUpdateDateTime:      parseDateTime(payment.StatusUpdateDateTime),
+		ExpectedExecutionDateTime: parseDateTime(payment.ExpectedExecutionDateTime),
+		ExchangeRate:              mapExchangeRate(payment.ExchangeRateInformation),
+	}
+}
@@ -0,0 +1,147 @@
+package aspsp
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+	FormatCSV   = "csv"
+	FormatYAML  = "yaml"
+)
+
+// Table is printable data, header names are the stable field names of structured outputs
+type Table struct {
+	Header []string
+	Rows   [][]string
+}
+
+type Printer interface {
+	Print(w io.Writer, table Table) error
+}
+
+func NewPrinter(format string) (Printer, error) {
+	switch strings.ToLower(format) {
+	case FormatTable, "":
+		return tablePrinter{}, nil
+	case FormatJSON:
+		return jsonPrinter{}, nil
+	case FormatCSV:
+		return csvPrinter{}, nil
+	case FormatYAML:
+		return yamlPrinter{}, nil
+	}
+	return nil, errors.Errorf("error unknown output format %s", format)
+}
+
+type tablePrinter struct{}
+
+func (tablePrinter) Print(w io.Writer, table Table) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 1, ' ', tabwriter.AlignRight|tabwriter.Debug)
+	fmt.Fprintln(tw, strings.Join(table.Header, "\t"))
+	for _, row := range table.Rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+type csvPrinter struct{}
+
+func (csvPrinter) Print(w io.Writer, table Table) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(fieldNames(table.Header)); err != nil {
+		return errors.Wrap(err, "error printing csv")
+	}
+	if err := cw.WriteAll(table.Rows); err != nil {
+		return errors.Wrap(err, "error printing csv")
+	}
+	return nil
+}
+
+type jsonPrinter struct{}
+
+// Print writes an array of objects keeping header order on each object
+func (jsonPrinter) Print(w io.Writer, table Table) error {
+	names := fieldNames(table.Header)
+	var b strings.Builder
+	b.WriteString("[")
+	for i, row := range table.Rows {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n  {")
+		for j, name := range names {
+			if j > 0 {
+				b.WriteString(",")
+			}
+			fmt.Fprintf(&b, "\n    %s: %s", jsonString(name), jsonString(cell(row, j)))
+		}
+		b.WriteString("\n  }")
+	}
+	if len(table.Rows) > 0 {
+		b.WriteString("\n")
+	}
+	b.WriteString("]\n")
+
+	_, err := io.WriteString(w, b.String())
+	return errors.Wrap(err, "error printing json")
+}
+
+// jsonString quotes value without escaping &, < and >, the output is not embedded in HTML
+func jsonString(value string) string {
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(value)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+type yamlPrinter struct{}
+
+func (yamlPrinter) Print(w io.Writer, table Table) error {
+	names := fieldNames(table.Header)
+	records := make([]yaml.MapSlice, 0, len(table.Rows))
+	for _, row := range table.Rows {
+		record := make(yaml.MapSlice, 0, len(names))
+		for j, name := range names {
+			record = append(record, yaml.MapItem{Key: name, Value: cell(row, j)})
+		}
+		records = append(records, record)
+	}
+
+	data, err := yaml.Marshal(records)
+	if err != nil {
+		return errors.Wrap(err, "error printing yaml")
+	}
+
+	_, err = w.Write(data)
+	return errors.Wrap(err, "error printing yaml")
+}
+
+// fieldNames turns table headers into lower camel case field names
+func fieldNames(header []string) []string {
+	names := make([]string, len(header))
+	for i, name := range header {
+		r, size := utf8.DecodeRuneInString(name)
+		names[i] = string(unicode.ToLower(r)) + name[size:]
+	}
+	return names
+}
+
+func cell(row []string, i int) string {
+	if i < len(row) {
+		return row[i]
+	}
+	return ""
+}
@@ -0,0 +1,128 @@
+package aspsp
+
+import (
+	"bytes"
+	"encoding/json"
+	"gopkg.in/yaml.v2"
+	"testing"
+)
+
+func TestPrinters(t *testing.T) {
+	table := Table{
+		Header: []string{"Id", "Amount", "Information"},
+		Rows: [][]string{
+			{"123", "-25.50 GBP", `Fish & Chips, "large"`},
+			{"124", "1000.00 GBP", "Café"},
+		},
+	}
+	empty := Table{Header: table.Header}
+
+	tests := []struct {
+		name   string
+		format string
+		table  Table
+		want   string
+	}{
+		{name: "table", format: FormatTable, table: table, want: "" +
+			"  Id|      Amount|Information\n" +
+			" 123|  -25.50 GBP|Fish & Chips, \"large\"\n" +
+			" 124| 1000.00 GBP|Café\n"},
+		{name: "default", format: "", table: empty, want: " Id| Amount|Information\n"},
+		{name: "csv", format: FormatCSV, table: table, want: "" +
+			"id,amount,information\n" +
+			"123,-25.50 GBP,\"Fish & Chips, \"\"large\"\"\"\n" +
+			"124,1000.00 GBP,Café\n"},
+		{name: "csv without rows", format: FormatCSV, table: empty, want: "id,amount,information\n"},
+		{name: "json", format: FormatJSON, table: table, want: `[
+  {
+    "id": "123",
+    "amount": "-25.50 GBP",
+    "information": "Fish & Chips, \"large\""
+  },
+  {
+    "id": "124",
+    "amount": "1000.00 GBP",
+    "information": "Café"
+  }
+]
+`},
+		{name: "json without rows", format: FormatJSON, table: empty, want: "[]\n"},
+		{name: "yaml", format: FormatYAML, table: table, want: "" +
+			"- id: \"123\"\n" +
+			"  amount: -25.50 GBP\n" +
+			"  information: Fish & Chips, \"large\"\n" +
+			"- id: \"124\"\n" +
+			"  amount: 1000.00 GBP\n" +
+			"  information: Café\n"},
+		{name: "yaml without rows", format: FormatYAML, table: empty, want: "[]\n"},
+		{name: "format ignores case", format: "JSON", table: empty, want: "[]\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			printer, err := NewPrinter(test.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var out bytes.Buffer
+			if err = printer.Print(&out, test.table); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != test.want {
+				t.Errorf("got\n%s\nwant\n%s", out.String(), test.want)
+			}
+		})
+	}
+}
+
+func TestStructuredPrintersDecode(t *testing.T) {
+	table := Table{
+		Header: []string{"AccountId", "Amount"},
+		Rows:   [][]string{{"22289", "-25.50 GBP"}, {"22290"}},
+	}
+	want := []map[string]string{
+		{"accountId": "22289", "amount": "-25.50 GBP"},
+		{"accountId": "22290", "amount": ""},
+	}
+
+	tests := []struct {
+		format string
+		decode func([]byte, interface{}) error
+	}{
+		{format: FormatJSON, decode: json.Unmarshal},
+		{format: FormatYAML, decode: yaml.Unmarshal},
+	}
+	for _, test := range tests {
+		t.Run(test.format, func(t *testing.T) {
+			printer, err := NewPrinter(test.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var out bytes.Buffer
+			if err = printer.Print(&out, table); err != nil {
+				t.Fatal(err)
+			}
+
+			var got []map[string]string
+			if err = test.decode(out.Bytes(), &got); err != nil {
+				t.Fatalf("%v decoding\n%s", err, out.String())
+			}
+			if len(got) != len(want) {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+			for i := range want {
+				for name, value := range want[i] {
+					if got[i][name] != value {
+						t.Errorf("record %d got %s %q, want %q", i, name, got[i][name], value)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestNewPrinterUnknownFormat(t *testing.T) {
+	if _, err := NewPrinter("xml"); err == nil {
+		t.Error("got a printer for xml, want error")
+	}
+}
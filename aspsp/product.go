@@ -0,0 +1,9 @@
+package aspsp
+
+type Product struct {
+	Id          string
+	AccountId   AccountId
+	Name        string
+	Type        string
+	SecondaryId string
+}
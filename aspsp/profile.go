@@ -0,0 +1,40 @@
+package aspsp
+
+import (
+	"strings"
+)
+
+// APIs of an ASPSP, the path below the version of their resources
+const (
+	APIAccounts           = "aisp"
+	APIPayments           = "pisp"
+	APIFundsConfirmations = "cbpii"
+	APIEvents             = ""
+)
+
+// BankProfile is where an ASPSP serves its APIs and the OB version they implement,
+// resource clients of the profile translate the version payloads, see Codec
+type BankProfile struct {
+	Name    string `json:"name"`
+	BaseURL string `json:"baseUrl"`
+	// Version such as v3.0, v3.1.10 or v4.0, empty is v3.1
+	Version string `json:"version"`
+}
+
+// Endpoint returns the base of the resources of api, e.g. https://bank/open-banking/v3.1/aisp
+func (p BankProfile) Endpoint(api string) string {
+	version := p.Version
+	if version == "" {
+		version = Version31
+	}
+	endpoint := strings.TrimRight(p.BaseURL, "/") + "/open-banking/" + version
+	if api != "" {
+		endpoint += "/" + api
+	}
+	return endpoint
+}
+
+// Codec returns the codec of the profile version, an error if it is not supported
+func (p BankProfile) Codec() (Codec, error) {
+	return NewCodec(p.Version)
+}
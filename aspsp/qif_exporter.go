@@ -0,0 +1,53 @@
+package aspsp
+
+import (
+	"bufio"
+	"github.com/pkg/errors"
+	"io"
+	"strings"
+)
+
+const qifDateLayout = "01/02/2006"
+
+// qifExporter writes Quicken interchange format bank transactions, dates are MM/DD/YYYY
+type qifExporter struct{}
+
+func (qifExporter) Export(w io.Writer, statement ExportStatement) error {
+	identity := statement.identity()
+	bw := bufio.NewWriter(w)
+
+	name := identity.Name()
+	if name == "" {
+		name = statement.Account.Nickname()
+	}
+
+	bw.WriteString("!Account\n")
+	bw.WriteString("N" + qifLine(name) + "\n")
+	bw.WriteString("TBank\n")
+	bw.WriteString("D" + qifLine(identity.Identification()) + "\n")
+	bw.WriteString("^\n")
+	bw.WriteString("!Type:Bank\n")
+
+	for _, transaction := range statement.bookedTransactions() {
+		bw.WriteString("D" + transaction.BookingDateTime.Format(qifDateLayout) + "\n")
+		bw.WriteString("T" + transaction.Amount.Amount() + "\n")
+		bw.WriteString("C*\n")
+		if transaction.Information != "" {
+			bw.WriteString("P" + qifLine(transaction.Information) + "\n")
+		}
+		if transaction.Reference != "" {
+			bw.WriteString("M" + qifLine(transaction.Reference) + "\n")
+		}
+		if len(transaction.Categories) > 0 {
+			bw.WriteString("L" + qifLine(transaction.Categories[0]) + "\n")
+		}
+		bw.WriteString("^\n")
+	}
+
+	return errors.Wrap(bw.Flush(), "error exporting qif")
+}
+
+// qifLine removes line breaks as each QIF field takes a single line
+func qifLine(value string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(value)
+}
@@ -0,0 +1,290 @@
+package aspsp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/jmatosp/obclient/authorization"
+	"github.com/pkg/errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ResourceClient calls ASPSP resource endpoints, injecting access token
+// and FAPI headers and validating the echoed interaction id
+type ResourceClient interface {
+	Get(path string, out interface{}) error
+	GetContext(ctx context.Context, path string, out interface{}) error
+	Post(path string, body, out interface{}) error
+	PostContext(ctx context.Context, path string, body, out interface{}) error
+	Do(method, path string, body, out interface{}) error
+	DoContext(ctx context.Context, method, path string, body, out interface{}) error
+	Download(path, accept string) ([]byte, error)
+	DownloadContext(ctx context.Context, path, accept string) ([]byte, error)
+	Upload(path, contentType string, content []byte, out interface{}) error
+	UploadContext(ctx context.Context, path, contentType string, content []byte, out interface{}) error
+}
+
+type TokenSource interface {
+	Token() (authorization.Token, error)
+}
+
+type staticTokenSource struct {
+	token authorization.Token
+}
+
+func NewStaticTokenSource(token authorization.Token) TokenSource {
+	return staticTokenSource{
+		token: token,
+	}
+}
+
+func (s staticTokenSource) Token() (authorization.Token, error) {
+	return s.token, nil
+}
+
+// NewGrantTokenSource uses a client credentials grant, as consent endpoints require
+func NewGrantTokenSource(grant authorization.GrantToken) TokenSource {
+	return staticTokenSource{
+		token: authorization.Token{
+			AccessToken: grant.AccessToken,
+			TokenType:   grant.TokenType,
+			ExpiresIn:   grant.ExpiresIn,
+		},
+	}
+}
+
+// StatusError is returned when an ASPSP answers with a non 2xx status code
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e StatusError) Error() string {
+	return fmt.Sprintf("unexpected response status code %d: %s", e.StatusCode, e.Body)
+}
+
+type resourceClient struct {
+	transport   authorization.Transport
+	endpoint    string
+	tokenSource TokenSource
+	headers     authorization.FapiHeaders
+	codec       Codec
+	signer      authorization.DetachedSigner
+}
+
+// NewResourceClient calls an endpoint of the OB version in its path, v3.1 when it has none, see VersionOf,
+// an error is returned for versions without a codec
+func NewResourceClient(transport authorization.Transport, endpoint string, tokenSource TokenSource, headers authorization.FapiHeaders) (ResourceClient, error) {
+	codec, err := NewCodec(VersionOf(endpoint))
+	if err != nil {
+		return nil, err
+	}
+	return NewVersionedResourceClient(transport, endpoint, tokenSource, headers, codec), nil
+}
+
+// NewVersionedResourceClient translates JSON payloads with codec, see BankProfile
+func NewVersionedResourceClient(transport authorization.Transport, endpoint string, tokenSource TokenSource, headers authorization.FapiHeaders, codec Codec) ResourceClient {
+	return NewSigningResourceClient(transport, endpoint, tokenSource, headers, codec, nil)
+}
+
+// NewSigningResourceClient signs consent and payment request bodies with signer into x-jws-signature,
+// see UnencodedPayload for the signer of the endpoint version
+func NewSigningResourceClient(transport authorization.Transport, endpoint string, tokenSource TokenSource, headers authorization.FapiHeaders, codec Codec, signer authorization.DetachedSigner) ResourceClient {
+	return &resourceClient{
+		transport:   transport,
+		endpoint:    endpoint,
+		tokenSource: tokenSource,
+		headers:     headers,
+		codec:       codec,
+		signer:      signer,
+	}
+}
+
+func (c *resourceClient) Get(path string, out interface{}) error {
+	return c.DoContext(context.Background(), http.MethodGet, path, nil, out)
+}
+
+func (c *resourceClient) GetContext(ctx context.Context, path string, out interface{}) error {
+	return c.DoContext(ctx, http.MethodGet, path, nil, out)
+}
+
+func (c *resourceClient) Post(path string, body, out interface{}) error {
+	return c.DoContext(context.Background(), http.MethodPost, path, body, out)
+}
+
+func (c *resourceClient) PostContext(ctx context.Context, path string, body, out interface{}) error {
+	return c.DoContext(ctx, http.MethodPost, path, body, out)
+}
+
+func (c *resourceClient) Do(method, path string, body, out interface{}) error {
+	return c.DoContext(context.Background(), method, path, body, out)
+}
+
+func (c *resourceClient) DoContext(ctx context.Context, method, path string, body, out interface{}) error {
+	var payload []byte
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return errors.Wrapf(err, "error calling %s", path)
+		}
+		if payload, err = c.codec.Request(data); err != nil {
+			return errors.Wrapf(err, "error calling %s", path)
+		}
+	}
+
+	response, err := c.send(ctx, method, path, "application/json", "application/json", payload)
+	if err != nil {
+		return err
+	}
+
+	return c.decodeResponse(response, path, out)
+}
+
+func (c *resourceClient) Upload(path, contentType string, content []byte, out interface{}) error {
+	return c.UploadContext(context.Background(), path, contentType, content, out)
+}
+
+// UploadContext posts content as is, e.g. a payment file, decoding the JSON response into out if any
+func (c *resourceClient) UploadContext(ctx context.Context, path, contentType string, content []byte, out interface{}) error {
+	response, err := c.send(ctx, http.MethodPost, path, "application/json", contentType, content)
+	if err != nil {
+		return err
+	}
+
+	return c.decodeResponse(response, path, out)
+}
+
+func (c *resourceClient) decodeResponse(response *http.Response, path string, out interface{}) error {
+	defer response.Body.Close()
+
+	if out == nil || response.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	content, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return errors.Wrapf(err, "error calling %s", path)
+	}
+
+	if content, err = c.codec.Response(path, content); err != nil {
+		return errors.Wrapf(err, "error calling %s", path)
+	}
+
+	if err = json.Unmarshal(content, out); err != nil {
+		return errors.Wrapf(err, "error calling %s", path)
+	}
+
+	return nil
+}
+
+func (c *resourceClient) Download(path, accept string) ([]byte, error) {
+	return c.DownloadContext(context.Background(), path, accept)
+}
+
+func (c *resourceClient) DownloadContext(ctx context.Context, path, accept string) ([]byte, error) {
+	response, err := c.send(ctx, http.MethodGet, path, accept, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	content, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error calling %s", path)
+	}
+
+	return content, nil
+}
+
+// send executes the request returning a 2xx response, callers must close its body
+func (c *resourceClient) send(ctx context.Context, method, path, accept, contentType string, content []byte) (*http.Response, error) {
+	client, err := c.transport.Client()
+	if err != nil {
+		return nil, errors.Wrapf(err, "error calling %s", path)
+	}
+
+	token, err := c.tokenSource.Token()
+	if err != nil {
+		return nil, errors.Wrapf(err, "error calling %s", path)
+	}
+
+	var payload io.Reader
+	if content != nil {
+		payload = bytes.NewBuffer(content)
+	}
+
+	target, err := c.url(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error calling %s", path)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, target, payload)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error calling %s", path)
+	}
+	request.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	request.Header.Set("Accept", accept)
+	if content != nil {
+		request.Header.Set("Content-Type", contentType)
+	}
+	// consents and payments carry the key of their resource so the ASPSP does not create them twice,
+	// see WithIdempotencyKey
+	if key := idempotencyKey(ctx, path); key != "" && method == http.MethodPost {
+		request.Header.Set("x-idempotency-key", key)
+	}
+	if c.signer != nil && content != nil && isSigned(ctx) {
+		signature, err := c.signer.SignDetached(content)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error calling %s", path)
+		}
+		request.Header.Set("x-jws-signature", signature)
+	}
+	interactionId := c.headers.Apply(request)
+
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error calling %s", path)
+	}
+
+	if err = authorization.CheckInteractionId(response, interactionId); err != nil {
+		response.Body.Close()
+		return nil, errors.Wrapf(err, "error calling %s", path)
+	}
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		message, _ := ioutil.ReadAll(response.Body)
+		response.Body.Close()
+		return nil, errors.Wrapf(StatusError{response.StatusCode, string(message)}, "error calling %s", path)
+	}
+
+	return response, nil
+}
+
+// url resolves path against the endpoint, absolute urls such as paging links are
+// only accepted on the endpoint host so the access token is not sent elsewhere
+func (c *resourceClient) url(path string) (string, error) {
+	if !strings.HasPrefix(path, "https://") && !strings.HasPrefix(path, "http://") {
+		return c.endpoint + path, nil
+	}
+
+	target, err := url.Parse(path)
+	if err != nil {
+		return "", err
+	}
+
+	endpoint, err := url.Parse(c.endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	if target.Scheme != endpoint.Scheme || target.Host != endpoint.Host {
+		return "", errors.Errorf("error url host %s is not the endpoint host", target.Host)
+	}
+
+	return path, nil
+}
@@ -0,0 +1,78 @@
+package aspsp
+
+import (
+	"time"
+)
+
+// AmountResponse is an OB amount and currency pair
+type AmountResponse struct {
+	Amount   string `json:"Amount"`
+	Currency string `json:"Currency"`
+}
+
+// CashAccountResponse is an OB account identification, as used for creditors and debtors,
+// optional members are omitted when sending it
+type CashAccountResponse struct {
+	SchemeName              string `json:"SchemeName"`
+	Identification          string `json:"Identification"`
+	Name                    string `json:"Name,omitempty"`
+	SecondaryIdentification string `json:"SecondaryIdentification,omitempty"`
+}
+
+// AgentResponse is an OB financial institution identification
+type AgentResponse struct {
+	SchemeName     string `json:"SchemeName"`
+	Identification string `json:"Identification"`
+	Name           string `json:"Name"`
+}
+
+// LinksResponse are the OB paging links, absolute urls
+type LinksResponse struct {
+	Self  string `json:"Self"`
+	First string `json:"First"`
+	Prev  string `json:"Prev"`
+	Next  string `json:"Next"`
+	Last  string `json:"Last"`
+}
+
+// MetaResponse is the OB paging metadata
+type MetaResponse struct {
+	TotalPages             int    `json:"TotalPages"`
+	FirstAvailableDateTime string `json:"FirstAvailableDateTime"`
+	LastAvailableDateTime  string `json:"LastAvailableDateTime"`
+}
+
+func mapAccountIdentity(account *CashAccountResponse, agent *AgentResponse) AccountIdentity {
+	if account == nil {
+		return nil
+	}
+
+	servicer := ""
+	if agent != nil {
+		servicer = agent.Identification
+	}
+
+	return NewAccountIdentity(
+		account.SchemeName,
+		account.Identification,
+		account.Name,
+		account.SecondaryIdentification,
+		servicer,
+	)
+}
+
+var dateTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// parseDateTime parses OB ISO 8601 date times, an empty or invalid value returns a zero time
+func parseDateTime(value string) time.Time {
+	for _, layout := range dateTimeLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,13 @@
+package aspsp
+
+import "time"
+
+type ScheduledPayment struct {
+	Id        string
+	AccountId AccountId
+	DateTime  time.Time
+	Type      string
+	Reference string
+	Amount    Money
+	Creditor  AccountIdentity
+}
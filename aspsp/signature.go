@@ -0,0 +1,33 @@
+package aspsp
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+type signatureContext struct{}
+
+// signed has the resource client send the detached JWS of the request body in x-jws-signature,
+// as consents and payments require
+func signed(ctx context.Context) context.Context {
+	return context.WithValue(ctx, signatureContext{}, true)
+}
+
+func isSigned(ctx context.Context) bool {
+	value, _ := ctx.Value(signatureContext{}).(bool)
+	return value
+}
+
+// UnencodedPayload tells whether the x-jws-signature of version signs the payload as is with b64 false,
+// as versions before v3.1.4 do, an empty version is v3.1
+func UnencodedPayload(version string) bool {
+	switch {
+	case version == "" || version == Version30 || version == Version31:
+		return true
+	case strings.HasPrefix(version, Version31+"."):
+		patch, err := strconv.Atoi(strings.TrimPrefix(version, Version31+"."))
+		return err == nil && patch < 4
+	}
+	return false
+}
@@ -0,0 +1,16 @@
+package aspsp
+
+import "time"
+
+type StandingOrder struct {
+	Id                   string
+	AccountId            AccountId
+	Frequency            string
+	Reference            string
+	Status               string
+	FirstPaymentDateTime time.Time
+	NextPaymentDateTime  time.Time
+	FinalPaymentDateTime time.Time
+	NextPaymentAmount    Money
+	Creditor             AccountIdentity
+}
@@ -0,0 +1,99 @@
+package aspsp
+
+import (
+	"context"
+	"github.com/pkg/errors"
+)
+
+type StandingOrderLister interface {
+	List(AccountId) ([]StandingOrder, error)
+	ListContext(context.Context, AccountId) ([]StandingOrder, error)
+	ListAll() ([]StandingOrder, error)
+	ListAllContext(context.Context) ([]StandingOrder, error)
+}
+
+type standingOrderLister struct {
+	client ResourceClient
+}
+
+func NewStandingOrderLister(client ResourceClient) StandingOrderLister {
+	return &standingOrderLister{
+		client: client,
+	}
+}
+
+func (s *standingOrderLister) List(accountId AccountId) ([]StandingOrder, error) {
+	return s.ListContext(context.Background(), accountId)
+}
+
+func (s *standingOrderLister) ListContext(ctx context.Context, accountId AccountId) ([]StandingOrder, error) {
+	return s.list(ctx, accountPath(accountId, "/standing-orders"))
+}
+
+func (s *standingOrderLister) ListAll() ([]StandingOrder, error) {
+	return s.ListAllContext(context.Background())
+}
+
+func (s *standingOrderLister) ListAllContext(ctx context.Context) ([]StandingOrder, error) {
+	return s.list(ctx, "/standing-orders")
+}
+
+func (s *standingOrderLister) list(ctx context.Context, path string) ([]StandingOrder, error) {
+	var standingOrdersResponse StandingOrdersResponse
+	if err := s.client.GetContext(ctx, path, &standingOrdersResponse); err != nil {
+		return []StandingOrder{}, errors.Wrap(err, "error listing standing orders")
+	}
+
+	var standingOrders []StandingOrder
+	for _, standingOrder := range standingOrdersResponse.Data.StandingOrder {
+		mapped, err := mapStandingOrder(standingOrder)
+		if err != nil {
+			return []StandingOrder{}, errors.Wrap(err, "error listing standing orders")
+		}
+		standingOrders = append(standingOrders, mapped)
+	}
+
+	return standingOrders, nil
+}
+
+type StandingOrdersResponse struct {
+	Data StandingOrdersDataResponse `json:"Data"`
+}
+
+type StandingOrdersDataResponse struct {
+	StandingOrder []StandingOrderResponse `json:"StandingOrder"`
+}
+
+type StandingOrderResponse struct {
+	AccountId               string               `json:"AccountId"`
+	StandingOrderId         string               `json:"StandingOrderId"`
+	Frequency               string               `json:"Frequency"`
+	Reference               string               `json:"Reference"`
+	StandingOrderStatusCode string               `json:"StandingOrderStatusCode"`
+	FirstPaymentDateTime    string               `json:"FirstPaymentDateTime"`
+	NextPaymentDateTime     string               `json:"NextPaymentDateTime"`
+	FinalPaymentDateTime    string               `json:"FinalPaymentDateTime"`
+	NextPaymentAmount       AmountResponse       `json:"NextPaymentAmount"`
+	CreditorAgent           *AgentResponse       `json:"CreditorAgent"`
+	CreditorAccount         *CashAccountResponse `json:"CreditorAccount"`
+}
+
+func mapStandingOrder(standingOrder StandingOrderResponse) (StandingOrder, error) {
+	amount, err := optionalMoney(standingOrder.NextPaymentAmount)
+	if err != nil {
+		return StandingOrder{}, err
+	}
+
+	return StandingOrder{
+		Id:                   standingOrder.StandingOrderId,
+		AccountId:            AccountId(standingOrder.AccountId),
+		Frequency:            standingOrder.Frequency,
+		Reference:            standingOrder.Reference,
+		Status:               standingOrder.StandingOrderStatusCode,
+		FirstPaymentDateTime: parseDateTime(standingOrder.FirstPaymentDateTime),
+		NextPaymentDateTime:  parseDateTime(standingOrder.NextPaymentDateTime),
+		FinalPaymentDateTime: parseDateTime(standingOrder.FinalPaymentDateTime),
+		NextPaymentAmount:    amount,
+		Creditor:             mapAccountIdentity(standingOrder.CreditorAccount, standingOrder.CreditorAgent),
+	}, nil
+}
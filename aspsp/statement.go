@@ -0,0 +1,14 @@
+package aspsp
+
+import "time"
+
+type Statement struct {
+	Id               string
+	AccountId        AccountId
+	Reference        string
+	Type             string
+	StartDateTime    time.Time
+	EndDateTime      time.Time
+	CreationDateTime time.Time
+	Description      []string
+}
@@ -0,0 +1,222 @@
+package aspsp
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	GroupByDay   = "day"
+	GroupByWeek  = "week"
+	GroupByMonth = "month"
+)
+
+const uncategorised = "Uncategorised"
+
+// Summary aggregates the booked transactions of an account, debits are negative
+type Summary struct {
+	AccountId  AccountId
+	From       time.Time
+	To         time.Time
+	Count      int
+	Credits    Money
+	Debits     Money
+	Net        Money
+	Periods    []PeriodSummary
+	Merchants  []MerchantSummary
+	Categories []CategorySummary
+}
+
+type PeriodSummary struct {
+	Period  string
+	Start   time.Time
+	Count   int
+	Credits Money
+	Debits  Money
+	Net     Money
+}
+
+// MerchantSummary is the spending on a merchant, transaction information is used
+// when the ASPSP sends no merchant details
+type MerchantSummary struct {
+	Merchant string
+	Count    int
+	Spent    Money
+}
+
+// CategorySummary groups by merchant category code, falling back to bank transaction codes
+type CategorySummary struct {
+	Category string
+	Count    int
+	Credits  Money
+	Debits   Money
+}
+
+// LoadSummary summarises account transactions booked in [from, to]
+func LoadSummary(ctx context.Context, account Account, from, to time.Time, groupBy string, topMerchants int) (Summary, error) {
+	if err := CheckGroupBy(groupBy); err != nil {
+		return Summary{}, err
+	}
+
+	transactions, err := account.TransactionsContext(ctx, from, to)
+	if err != nil {
+		return Summary{}, errors.Wrap(err, "error summarising transactions")
+	}
+
+	summary, err := Summarise(account.Id(), from, to, transactions, groupBy, topMerchants)
+	if err != nil {
+		return Summary{}, errors.Wrap(err, "error summarising transactions")
+	}
+
+	return summary, nil
+}
+
+// CheckGroupBy returns an error unless groupBy is GroupByDay, GroupByWeek or GroupByMonth
+func CheckGroupBy(groupBy string) error {
+	_, err := periodOf(time.Time{}, groupBy)
+	return err
+}
+
+// Summarise aggregates transactions booked in [from, to] by period, merchant and category, zero
+// times leave it open, pending transactions are left out as their amounts may still change
+func Summarise(accountId AccountId, from, to time.Time, transactions []Transaction, groupBy string, topMerchants int) (Summary, error) {
+	if err := CheckGroupBy(groupBy); err != nil {
+		return Summary{}, err
+	}
+
+	summary := Summary{AccountId: accountId, From: from, To: to}
+	periods := map[string]*PeriodSummary{}
+	merchants := map[string]*MerchantSummary{}
+	categories := map[string]*CategorySummary{}
+
+	for _, transaction := range transactions {
+		if !transaction.Booked() {
+			continue
+		}
+		if (!from.IsZero() && transaction.BookingDateTime.Before(from)) || (!to.IsZero() && transaction.BookingDateTime.After(to)) {
+			continue
+		}
+		amount := transaction.Amount
+
+		period, _ := periodOf(transaction.BookingDateTime, groupBy)
+		if periods[period] == nil {
+			periods[period] = &PeriodSummary{Period: period, Start: periodStart(transaction.BookingDateTime, groupBy)}
+		}
+		category := transactionCategory(transaction)
+		if categories[category] == nil {
+			categories[category] = &CategorySummary{Category: category}
+		}
+
+		var err error
+		if summary.Net, err = summary.Net.Add(amount); err != nil {
+			return Summary{}, err
+		}
+		if periods[period].Net, err = periods[period].Net.Add(amount); err != nil {
+			return Summary{}, err
+		}
+
+		// adding to net already checked all amounts share the currency
+		if transaction.Credit() {
+			summary.Credits, _ = summary.Credits.Add(amount)
+			periods[period].Credits, _ = periods[period].Credits.Add(amount)
+			categories[category].Credits, _ = categories[category].Credits.Add(amount)
+		} else {
+			summary.Debits, _ = summary.Debits.Add(amount)
+			periods[period].Debits, _ = periods[period].Debits.Add(amount)
+			categories[category].Debits, _ = categories[category].Debits.Add(amount)
+
+			merchant := transactionMerchant(transaction)
+			if merchants[merchant] == nil {
+				merchants[merchant] = &MerchantSummary{Merchant: merchant}
+			}
+			merchants[merchant].Spent, _ = merchants[merchant].Spent.Add(amount.Neg())
+			merchants[merchant].Count++
+		}
+
+		summary.Count++
+		periods[period].Count++
+		categories[category].Count++
+	}
+
+	for _, period := range periods {
+		summary.Periods = append(summary.Periods, *period)
+	}
+	sort.Slice(summary.Periods, func(i, j int) bool {
+		return summary.Periods[i].Start.Before(summary.Periods[j].Start)
+	})
+
+	for _, merchant := range merchants {
+		summary.Merchants = append(summary.Merchants, *merchant)
+	}
+	sort.Slice(summary.Merchants, func(i, j int) bool {
+		if cmp, _ := summary.Merchants[i].Spent.Cmp(summary.Merchants[j].Spent); cmp != 0 {
+			return cmp > 0
+		}
+		return summary.Merchants[i].Merchant < summary.Merchants[j].Merchant
+	})
+	if topMerchants > 0 && len(summary.Merchants) > topMerchants {
+		summary.Merchants = summary.Merchants[:topMerchants]
+	}
+
+	for _, category := range categories {
+		summary.Categories = append(summary.Categories, *category)
+	}
+	sort.Slice(summary.Categories, func(i, j int) bool {
+		return summary.Categories[i].Category < summary.Categories[j].Category
+	})
+
+	return summary, nil
+}
+
+func periodOf(date time.Time, groupBy string) (string, error) {
+	switch groupBy {
+	case GroupByDay:
+		return date.Format("2006-01-02"), nil
+	case GroupByWeek:
+		year, week := date.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week), nil
+	case GroupByMonth:
+		return date.Format("2006-01"), nil
+	}
+	return "", errors.Errorf("error unknown summary grouping %s", groupBy)
+}
+
+// periodStart returns the first day of the period, weeks start on monday
+func periodStart(date time.Time, groupBy string) time.Time {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	switch groupBy {
+	case GroupByWeek:
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case GroupByMonth:
+		return day.AddDate(0, 0, 1-day.Day())
+	}
+	return day
+}
+
+func transactionMerchant(transaction Transaction) string {
+	if transaction.MerchantName != "" {
+		return transaction.MerchantName
+	}
+	if information := strings.TrimSpace(transaction.Information); information != "" {
+		return information
+	}
+	return uncategorised
+}
+
+func transactionCategory(transaction Transaction) string {
+	switch {
+	case len(transaction.Categories) > 0:
+		return transaction.Categories[0]
+	case transaction.MerchantCategoryCode != "":
+		return "MCC " + transaction.MerchantCategoryCode
+	case transaction.BankTransactionCode != "":
+		return transaction.BankTransactionCode
+	case transaction.ProprietaryBankTransactionCode != "":
+		return transaction.ProprietaryBankTransactionCode
+	}
+	return uncategorised
+}
@@ -0,0 +1,150 @@
+package aspsp
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// syncOverlap re-reads the last synced day, ASPSPs may book late transactions with an earlier date
+const syncOverlap = time.Hour * 24
+
+// TransactionSyncer downloads new transactions of an account into a TransactionStore
+type TransactionSyncer interface {
+	Sync(AccountId) (SyncResult, error)
+	SyncContext(context.Context, AccountId) (SyncResult, error)
+}
+
+type SyncResult struct {
+	AccountId AccountId
+	From      time.Time
+	Added     int
+	Updated   int
+	Removed   int
+	Total     int
+}
+
+type transactionSyncer struct {
+	resources AccountResources
+	store     TransactionStore
+	lookback  time.Duration
+}
+
+// NewTransactionSyncer syncs transactions from lookback ago on the first run, then only
+// from the last booked transaction or the oldest pending one
+func NewTransactionSyncer(resources AccountResources, store TransactionStore, lookback time.Duration) TransactionSyncer {
+	return transactionSyncer{
+		resources: resources,
+		store:     store,
+		lookback:  lookback,
+	}
+}
+
+func (s transactionSyncer) Sync(accountId AccountId) (SyncResult, error) {
+	return s.SyncContext(context.Background(), accountId)
+}
+
+func (s transactionSyncer) SyncContext(ctx context.Context, accountId AccountId) (SyncResult, error) {
+	stored, err := s.store.Get(accountId)
+	if err != nil && err != ErrNotFound {
+		return SyncResult{}, errors.Wrap(err, "error syncing transactions")
+	}
+
+	now := time.Now()
+	from := s.syncFrom(stored, now)
+	result := SyncResult{AccountId: accountId, From: from}
+
+	// keys[i] is the key of stored.Transactions[i]
+	var keys []string
+	known := map[string]int{}
+	storedKeys := transactionKeys{}
+	for index, transaction := range stored.Transactions {
+		key := storedKeys.key(transaction)
+		keys = append(keys, key)
+		known[key] = index
+	}
+
+	fetched := map[string]bool{}
+	fetchedKeys := transactionKeys{}
+	iterator := s.resources.TransactionIterator(ctx, accountId, from, time.Time{})
+	for iterator.Next() {
+		transaction := iterator.Transaction()
+		key := fetchedKeys.key(transaction)
+		fetched[key] = true
+
+		index, found := known[key]
+		if !found {
+			known[key] = len(stored.Transactions)
+			keys = append(keys, key)
+			stored.Transactions = append(stored.Transactions, transaction)
+			result.Added++
+		} else if !sameTransaction(stored.Transactions[index], transaction) {
+			stored.Transactions[index] = transaction
+			result.Updated++
+		}
+	}
+	if err = iterator.Err(); err != nil {
+		return SyncResult{}, errors.Wrap(err, "error syncing transactions")
+	}
+
+	// pending transactions not listed again were booked under a new id or cancelled
+	var transactions []Transaction
+	for index, transaction := range stored.Transactions {
+		if !transaction.Booked() && !transaction.BookingDateTime.Before(from) && !fetched[keys[index]] {
+			result.Removed++
+			continue
+		}
+		transactions = append(transactions, transaction)
+		if transaction.Booked() && transaction.BookingDateTime.After(stored.LastBooked) {
+			stored.LastBooked = transaction.BookingDateTime
+		}
+	}
+	sort.SliceStable(transactions, func(i, j int) bool {
+		return transactions[i].BookingDateTime.Before(transactions[j].BookingDateTime)
+	})
+
+	stored.Transactions = transactions
+	stored.SyncedAt = now
+	if err = s.store.Store(accountId, stored); err != nil {
+		return SyncResult{}, errors.Wrap(err, "error syncing transactions")
+	}
+
+	result.Total = len(transactions)
+	return result, nil
+}
+
+func (s transactionSyncer) syncFrom(stored StoredTransactions, now time.Time) time.Time {
+	if stored.LastBooked.IsZero() {
+		return now.Add(-s.lookback)
+	}
+
+	from := stored.LastBooked
+	for _, transaction := range stored.Transactions {
+		if !transaction.Booked() && transaction.BookingDateTime.Before(from) {
+			from = transaction.BookingDateTime
+		}
+	}
+	return from.Add(-syncOverlap)
+}
+
+// transactionKeys identifies the transactions of a list, falling back to their contents numbered by
+// occurrence when the ASPSP sends no id, so genuine duplicates in a list are kept apart
+type transactionKeys map[string]int
+
+func (k transactionKeys) key(transaction Transaction) string {
+	if transaction.Id != "" {
+		return transaction.Id
+	}
+	content := transaction.BookingDateTime.UTC().Format(time.RFC3339) + "|" + transaction.Amount.String() + "|" + transaction.Reference + "|" + transaction.Information
+	k[content]++
+	return content + "|" + strconv.Itoa(k[content])
+}
+
+func sameTransaction(a, b Transaction) bool {
+	a.BookingDateTime, b.BookingDateTime = a.BookingDateTime.UTC(), b.BookingDateTime.UTC()
+	a.ValueDateTime, b.ValueDateTime = a.ValueDateTime.UTC(), b.ValueDateTime.UTC()
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,158 @@
+package aspsp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTransactionSyncer(t *testing.T) {
+	may := func(day, hour int) time.Time {
+		return time.Date(2019, 5, day, hour, 0, 0, 0, time.UTC)
+	}
+	booked := func(id string, at time.Time, pence int64, information string) Transaction {
+		return Transaction{Id: id, Amount: NewMoney(pence, "GBP"), Status: "Booked", BookingDateTime: at, Information: information}
+	}
+	pending := func(id string, at time.Time, pence int64, information string) Transaction {
+		transaction := booked(id, at, pence, information)
+		transaction.Status = "Pending"
+		return transaction
+	}
+
+	var bank []Transaction
+	var requestedFrom time.Time
+	resources := loaderResources{load: func(from, to time.Time) ([]Transaction, error) {
+		requestedFrom = from
+		var listed []Transaction
+		for _, transaction := range bank {
+			if !transaction.BookingDateTime.Before(from) {
+				listed = append(listed, transaction)
+			}
+		}
+		return listed, nil
+	}}
+	store := NewFileTransactionStore(t.TempDir())
+	syncer := NewTransactionSyncer(resources, store, 24*time.Hour*365*20)
+
+	if _, err := store.Get("22289"); err != ErrNotFound {
+		t.Fatalf("empty store got %v, want ErrNotFound", err)
+	}
+
+	steps := []struct {
+		name string
+		bank []Transaction
+		// wantFrom is the from date requested from the bank, zero for the first sync
+		wantFrom time.Time
+		want     SyncResult
+		wantIds  []string
+	}{
+		{
+			name: "first sync keeps duplicates without id",
+			bank: []Transaction{
+				booked("123", may(1, 9), -1000, "Rent"),
+				booked("", may(2, 8), -250, "Coffee"),
+				booked("", may(2, 8), -250, "Coffee"),
+				pending("p1", may(3, 18), -999, "Groceries"),
+			},
+			want:    SyncResult{Added: 4, Total: 4},
+			wantIds: []string{"123", "", "", "p1"},
+		},
+		{
+			name: "same listing",
+			bank: []Transaction{
+				booked("123", may(1, 9), -1000, "Rent"),
+				booked("", may(2, 8), -250, "Coffee"),
+				booked("", may(2, 8), -250, "Coffee"),
+				pending("p1", may(3, 18), -999, "Groceries"),
+			},
+			wantFrom: may(1, 8),
+			want:     SyncResult{Total: 4},
+			wantIds:  []string{"123", "", "", "p1"},
+		},
+		{
+			name: "pending booked under a new id and a late booking in the overlap",
+			bank: []Transaction{
+				booked("123", may(1, 9), -1000, "Rent"),
+				booked("", may(2, 8), -250, "Coffee"),
+				booked("", may(2, 8), -250, "Coffee"),
+				booked("124", may(2, 6), -500, "Late"),
+				booked("125", may(3, 20), -999, "Groceries"),
+			},
+			wantFrom: may(1, 8),
+			want:     SyncResult{Added: 2, Removed: 1, Total: 5},
+			wantIds:  []string{"123", "124", "", "", "125"},
+		},
+		{
+			name: "update in the overlap, older transactions not listed again",
+			bank: []Transaction{
+				booked("123", may(1, 9), -1000, "Rent"),
+				booked("", may(2, 8), -250, "Coffee"),
+				booked("", may(2, 8), -250, "Coffee"),
+				booked("124", may(2, 6), -500, "Late"),
+				booked("125", may(3, 20), -999, "Groceries and wine"),
+			},
+			wantFrom: may(2, 20),
+			want:     SyncResult{Updated: 1, Total: 5},
+			wantIds:  []string{"123", "124", "", "", "125"},
+		},
+	}
+	for _, step := range steps {
+		bank = step.bank
+		result, err := syncer.Sync("22289")
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+
+		if !step.wantFrom.IsZero() && !requestedFrom.Equal(step.wantFrom) {
+			t.Errorf("%s: requested from %s, want %s", step.name, requestedFrom, step.wantFrom)
+		}
+		result.AccountId, result.From = "", time.Time{}
+		if result != step.want {
+			t.Errorf("%s: got %+v, want %+v", step.name, result, step.want)
+		}
+
+		stored, err := store.Get("22289")
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		var ids []string
+		for _, transaction := range stored.Transactions {
+			ids = append(ids, transaction.Id)
+		}
+		if len(ids) != len(step.wantIds) {
+			t.Fatalf("%s: stored %q, want %q", step.name, ids, step.wantIds)
+		}
+		for i := range ids {
+			if ids[i] != step.wantIds[i] {
+				t.Fatalf("%s: stored %q, want %q", step.name, ids, step.wantIds)
+			}
+		}
+	}
+}
+
+func TestStoredTransactionsBetween(t *testing.T) {
+	may := func(day int) time.Time {
+		return time.Date(2019, 5, day, 0, 0, 0, 0, time.UTC)
+	}
+	stored := StoredTransactions{Transactions: []Transaction{
+		{Id: "1", BookingDateTime: may(1)},
+		{Id: "2", BookingDateTime: may(2)},
+		{Id: "3", BookingDateTime: may(3)},
+	}}
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		want     int
+	}{
+		{name: "unbounded", want: 3},
+		{name: "from is included", from: may(2), want: 2},
+		{name: "to is included", to: may(2), want: 2},
+		{name: "single day", from: may(2), to: may(2), want: 1},
+		{name: "none", from: may(4), want: 0},
+	}
+	for _, test := range tests {
+		if got := stored.Between(test.from, test.to); len(got) != test.want {
+			t.Errorf("%s: got %d transactions, want %d", test.name, len(got), test.want)
+		}
+	}
+}
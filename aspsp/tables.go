@@ -0,0 +1,480 @@
+package aspsp
+
+import (
+	"github.com/jmatosp/obclient/authorization"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func AccountsTable(accounts []Account) Table {
+	table := Table{Header: []string{"Id", "Currency", "Nickname", "Type", "SubType"}}
+	for _, account := range accounts {
+		table.Rows = append(table.Rows, []string{
+			string(account.Id()),
+			account.Currency(),
+			account.Nickname(),
+			account.Type(),
+			account.Subtype(),
+		})
+	}
+	return table
+}
+
+func TransactionsTable(transactions []Transaction) Table {
+	table := Table{Header: []string{"Id", "Booked", "Reference", "Information", "Amount", "Currency", "Credit", "Status", "Categories"}}
+	for _, transaction := range transactions {
+		table.Rows = append(table.Rows, []string{
+			transaction.Id,
+			datePrint(transaction.BookingDateTime),
+			transaction.Reference,
+			transaction.Information,
+			amountPrint(transaction.Amount),
+			transaction.Amount.Currency(),
+			strconv.FormatBool(transaction.Credit()),
+			transaction.Status,
+			strings.Join(transaction.Categories, ", "),
+		})
+	}
+	return table
+}
+
+func BalancesTable(balances []Balance) Table {
+	table := Table{Header: []string{"AccountId", "Type", "Amount", "Currency", "Credit", "DateTime"}}
+	for _, balance := range balances {
+		table.Rows = append(table.Rows, []string{
+			string(balance.AccountId),
+			balance.Type,
+			amountPrint(balance.Amount),
+			balance.Amount.Currency(),
+			strconv.FormatBool(balance.Credit()),
+			balance.DateTime.Format(time.RFC3339),
+		})
+	}
+	return table
+}
+
+func StandingOrdersTable(standingOrders []StandingOrder) Table {
+	table := Table{Header: []string{"Id", "AccountId", "Frequency", "Reference", "NextPayment", "Amount", "Currency", "Creditor", "Status"}}
+	for _, standingOrder := range standingOrders {
+		table.Rows = append(table.Rows, []string{
+			standingOrder.Id,
+			string(standingOrder.AccountId),
+			standingOrder.Frequency,
+			standingOrder.Reference,
+			datePrint(standingOrder.NextPaymentDateTime),
+			amountPrint(standingOrder.NextPaymentAmount),
+			standingOrder.NextPaymentAmount.Currency(),
+			identityPrint(standingOrder.Creditor),
+			standingOrder.Status,
+		})
+	}
+	return table
+}
+
+func DirectDebitsTable(directDebits []DirectDebit) Table {
+	table := Table{Header: []string{"Id", "AccountId", "Name", "Mandate", "PreviousPayment", "Amount", "Currency", "Status"}}
+	for _, directDebit := range directDebits {
+		table.Rows = append(table.Rows, []string{
+			directDebit.Id,
+			string(directDebit.AccountId),
+			directDebit.Name,
+			directDebit.MandateIdentification,
+			datePrint(directDebit.PreviousPaymentDateTime),
+			amountPrint(directDebit.PreviousPaymentAmount),
+			directDebit.PreviousPaymentAmount.Currency(),
+			directDebit.Status,
+		})
+	}
+	return table
+}
+
+func BeneficiariesTable(beneficiaries []Beneficiary) Table {
+	table := Table{Header: []string{"Id", "AccountId", "Reference", "Name", "Creditor"}}
+	for _, beneficiary := range beneficiaries {
+		name := ""
+		if beneficiary.Creditor != nil {
+			name = beneficiary.Creditor.Name()
+		}
+		table.Rows = append(table.Rows, []string{
+			beneficiary.Id,
+			string(beneficiary.AccountId),
+			beneficiary.Reference,
+			name,
+			identityPrint(beneficiary.Creditor),
+		})
+	}
+	return table
+}
+
+func ProductsTable(products []Product) Table {
+	table := Table{Header: []string{"Id", "AccountId", "Name", "Type"}}
+	for _, product := range products {
+		table.Rows = append(table.Rows, []string{
+			product.Id,
+			string(product.AccountId),
+			product.Name,
+			product.Type,
+		})
+	}
+	return table
+}
+
+func OffersTable(offers []Offer) Table {
+	table := Table{Header: []string{"Id", "AccountId", "Type", "Description", "Rate", "Amount", "Currency", "End"}}
+	for _, offer := range offers {
+		table.Rows = append(table.Rows, []string{
+			offer.Id,
+			string(offer.AccountId),
+			offer.Type,
+			offer.Description,
+			offer.Rate,
+			amountPrint(offer.Amount),
+			offer.Amount.Currency(),
+			datePrint(offer.EndDateTime),
+		})
+	}
+	return table
+}
+
+func PartiesTable(parties []Party) Table {
+	table := Table{Header: []string{"Id", "Number", "Type", "Name", "Email", "Phone", "Role"}}
+	for _, party := range parties {
+		table.Rows = append(table.Rows, []string{
+			party.Id,
+			party.Number,
+			party.Type,
+			party.Name,
+			party.EmailAddress,
+			party.Phone,
+			party.AccountRole,
+		})
+	}
+	return table
+}
+
+func ScheduledPaymentsTable(scheduledPayments []ScheduledPayment) Table {
+	table := Table{Header: []string{"Id", "AccountId", "Date", "Type", "Reference", "Amount", "Currency", "Creditor"}}
+	for _, scheduledPayment := range scheduledPayments {
+		table.Rows = append(table.Rows, []string{
+			scheduledPayment.Id,
+			string(scheduledPayment.AccountId),
+			datePrint(scheduledPayment.DateTime),
+			scheduledPayment.Type,
+			scheduledPayment.Reference,
+			amountPrint(scheduledPayment.Amount),
+			scheduledPayment.Amount.Currency(),
+			identityPrint(scheduledPayment.Creditor),
+		})
+	}
+	return table
+}
+
+func StatementsTable(statements []Statement) Table {
+	table := Table{Header: []string{"Id", "AccountId", "Reference", "Type", "Start", "End"}}
+	for _, statement := range statements {
+		table.Rows = append(table.Rows, []string{
+			statement.Id,
+			string(statement.AccountId),
+			statement.Reference,
+			statement.Type,
+			datePrint(statement.StartDateTime),
+			datePrint(statement.EndDateTime),
+		})
+	}
+	return table
+}
+
+func SyncResultsTable(results []SyncResult) Table {
+	table := Table{Header: []string{"AccountId", "From", "Added", "Updated", "Removed", "Total"}}
+	for _, result := range results {
+		table.Rows = append(table.Rows, []string{
+			string(result.AccountId),
+			datePrint(result.From),
+			strconv.Itoa(result.Added),
+			strconv.Itoa(result.Updated),
+			strconv.Itoa(result.Removed),
+			strconv.Itoa(result.Total),
+		})
+	}
+	return table
+}
+
+func SummaryTable(summaries []Summary) Table {
+	table := Table{Header: []string{"AccountId", "From", "To", "Transactions", "Credits", "Debits", "Net", "Currency"}}
+	for _, summary := range summaries {
+		table.Rows = append(table.Rows, []string{
+			string(summary.AccountId),
+			datePrint(summary.From),
+			datePrint(summary.To),
+			strconv.Itoa(summary.Count),
+			summary.Credits.Amount(),
+			summary.Debits.Amount(),
+			summary.Net.Amount(),
+			summary.Net.Currency(),
+		})
+	}
+	return table
+}
+
+func SummaryPeriodsTable(summaries []Summary) Table {
+	table := Table{Header: []string{"AccountId", "Period", "Transactions", "Credits", "Debits", "Net"}}
+	for _, summary := range summaries {
+		for _, period := range summary.Periods {
+			table.Rows = append(table.Rows, []string{
+				string(summary.AccountId),
+				period.Period,
+				strconv.Itoa(period.Count),
+				period.Credits.Amount(),
+				period.Debits.Amount(),
+				period.Net.Amount(),
+			})
+		}
+	}
+	return table
+}
+
+func SummaryMerchantsTable(summaries []Summary) Table {
+	table := Table{Header: []string{"AccountId", "Merchant", "Transactions", "Spent"}}
+	for _, summary := range summaries {
+		for _, merchant := range summary.Merchants {
+			table.Rows = append(table.Rows, []string{
+				string(summary.AccountId),
+				merchant.Merchant,
+				strconv.Itoa(merchant.Count),
+				merchant.Spent.Amount(),
+			})
+		}
+	}
+	return table
+}
+
+func SummaryCategoriesTable(summaries []Summary) Table {
+	table := Table{Header: []string{"AccountId", "Category", "Transactions", "Credits", "Debits"}}
+	for _, summary := range summaries {
+		for _, category := range summary.Categories {
+			table.Rows = append(table.Rows, []string{
+				string(summary.AccountId),
+				category.Category,
+				strconv.Itoa(category.Count),
+				category.Credits.Amount(),
+				category.Debits.Amount(),
+			})
+		}
+	}
+	return table
+}
+
+func FundsConfirmationConsentsTable(consents []FundsConfirmationConsent) Table {
+	table := Table{Header: []string{"ConsentId", "Status", "DebtorAccount", "Created", "Expires"}}
+	for _, consent := range consents {
+		table.Rows = append(table.Rows, []string{
+			consent.ConsentId,
+			consent.Status,
+			identityPrint(consent.DebtorAccount),
+			datePrint(consent.CreationDateTime),
+			datePrint(consent.ExpirationDateTime),
+		})
+	}
+	return table
+}
+
+func FundsConfirmationsTable(confirmations []FundsConfirmation) Table {
+	table := Table{Header: []string{"Id", "ConsentId", "Reference", "Amount", "Currency", "FundsAvailable", "DateTime"}}
+	for _, confirmation := range confirmations {
+		table.Rows = append(table.Rows, []string{
+			confirmation.Id,
+			confirmation.ConsentId,
+			confirmation.Reference,
+			amountPrint(confirmation.Amount),
+			confirmation.Amount.Currency(),
+			strconv.FormatBool(confirmation.FundsAvailable),
+			confirmation.CreationDateTime.Format(time.RFC3339),
+		})
+	}
+	return table
+}
+
+func PaymentConsentsTable(consents []PaymentConsent) Table {
+	table := Table{Header: []string{"ConsentId", "Type", "Status", "Created", "Execution", "ExchangeRate"}}
+	for _, consent := range consents {
+		table.Rows = append(table.Rows, []string{
+			consent.ConsentId,
+			consent.Type,
+			consent.Status,
+			consent.CreationDateTime.Format(time.RFC3339),
+			datePrint(consent.ExpectedExecutionDateTime),
+			exchangeRatePrint(consent.ExchangeRate),
+		})
+	}
+	return table
+}
+
+func PaymentsTable(payments []Payment) Table {
+	table := Table{Header: []string{"Id", "Type", "ConsentId", "Status", "Created", "Execution", "ExchangeRate"}}
+	for _, payment := range payments {
+		table.Rows = append(table.Rows, []string{
+			payment.Id,
+			payment.Type,
+			payment.ConsentId,
+			payment.Status,
+			payment.CreationDateTime.Format(time.RFC3339),
+			datePrint(payment.ExpectedExecutionDateTime),
+			exchangeRatePrint(payment.ExchangeRate),
+		})
+	}
+	return table
+}
+
+func FilePaymentConsentsTable(consents []FilePaymentConsent) Table {
+	table := Table{Header: []string{"ConsentId", "Status", "Created", "File", "Payments", "ControlSum", "Currency"}}
+	for _, consent := range consents {
+		table.Rows = append(table.Rows, []string{
+			consent.ConsentId,
+			consent.Status,
+			consent.CreationDateTime.Format(time.RFC3339),
+			consent.Order.Reference,
+			strconv.Itoa(consent.Order.File.NumberOfTransactions),
+			amountPrint(consent.Order.File.ControlSum),
+			consent.Order.File.ControlSum.Currency(),
+		})
+	}
+	return table
+}
+
+func FilePaymentsTable(payments []FilePayment) Table {
+	table := Table{Header: []string{"Id", "ConsentId", "Status", "Created"}}
+	for _, payment := range payments {
+		table.Rows = append(table.Rows, []string{
+			payment.Id,
+			payment.ConsentId,
+			payment.Status,
+			payment.CreationDateTime.Format(time.RFC3339),
+		})
+	}
+	return table
+}
+
+func VRPConsentsTable(consents []VRPConsent) Table {
+	table := Table{Header: []string{"ConsentId", "Status", "Creditor", "MaxAmount", "Limits", "ValidFrom", "ValidTo"}}
+	for _, consent := range consents {
+		var limits []string
+		for _, limit := range consent.ControlParameters.PeriodicLimits {
+			limits = append(limits, limit.PeriodType+" "+limit.Amount.String())
+		}
+		table.Rows = append(table.Rows, []string{
+			consent.ConsentId,
+			consent.Status,
+			identityPrint(consent.CreditorAccount),
+			consent.ControlParameters.MaximumIndividualAmount.String(),
+			strings.Join(limits, ", "),
+			datePrint(consent.ControlParameters.ValidFromDateTime),
+			datePrint(consent.ControlParameters.ValidToDateTime),
+		})
+	}
+	return table
+}
+
+func VRPsTable(payments []VRP) Table {
+	table := Table{Header: []string{"Id", "ConsentId", "Status", "Amount", "Currency", "Created"}}
+	for _, payment := range payments {
+		table.Rows = append(table.Rows, []string{
+			payment.Id,
+			payment.ConsentId,
+			payment.Status,
+			amountPrint(payment.Amount),
+			payment.Amount.Currency(),
+			payment.CreationDateTime.Format(time.RFC3339),
+		})
+	}
+	return table
+}
+
+func CallbackURLsTable(callbacks []CallbackURL) Table {
+	table := Table{Header: []string{"Id", "URL", "Version"}}
+	for _, callback := range callbacks {
+		table.Rows = append(table.Rows, []string{callback.Id, callback.URL, callback.Version})
+	}
+	return table
+}
+
+func EventsTable(events []Event) Table {
+	table := Table{Header: []string{"Id", "Type", "ResourceType", "ResourceId", "Reason", "Occurred"}}
+	for _, event := range events {
+		table.Rows = append(table.Rows, []string{
+			event.Id,
+			strings.TrimPrefix(event.Type, "urn:uk:org:openbanking:events:"),
+			event.ResourceType,
+			event.ResourceId,
+			event.Reason,
+			event.OccurredAt.Format(time.RFC3339),
+		})
+	}
+	return table
+}
+
+func StoredTokensTable(tokens []StoredToken) Table {
+	table := Table{Header: []string{"ConsentId", "Status", "ConsentExpires", "TokenExpires", "Reason"}}
+	for _, token := range tokens {
+		table.Rows = append(table.Rows, []string{
+			token.ConsentId,
+			token.ConsentStatus,
+			dateTimePrint(token.ConsentExpires),
+			dateTimePrint(token.TokenExpires),
+			token.Reason,
+		})
+	}
+	return table
+}
+
+func IntrospectionsTable(introspections []authorization.Introspection) Table {
+	table := Table{Header: []string{"Active", "ConsentId", "Scope", "ClientId", "TokenType", "Issued", "Expires"}}
+	for _, introspection := range introspections {
+		table.Rows = append(table.Rows, []string{
+			strconv.FormatBool(introspection.Active),
+			introspection.ConsentId,
+			introspection.Scope,
+			introspection.ClientId,
+			introspection.TokenType,
+			dateTimePrint(introspection.IssuedAt),
+			dateTimePrint(introspection.Expires),
+		})
+	}
+	return table
+}
+
+func identityPrint(identity AccountIdentity) string {
+	if identity == nil {
+		return ""
+	}
+	return identity.Identification()
+}
+
+func datePrint(date time.Time) string {
+	if date.IsZero() {
+		return ""
+	}
+	return date.Format("2006-01-02")
+}
+
+func dateTimePrint(date time.Time) string {
+	if date.IsZero() {
+		return ""
+	}
+	return date.Format(time.RFC3339)
+}
+
+// amountPrint prints amounts unsigned as tables show credit separately, missing amounts are empty
+func amountPrint(amount Money) string {
+	if amount == NoMoney {
+		return ""
+	}
+	return amount.Abs().Amount()
+}
+
+func exchangeRatePrint(rate *ExchangeRate) string {
+	if rate == nil {
+		return ""
+	}
+	return rate.Rate + " " + rate.UnitCurrency + " (" + rate.RateType + ")"
+}
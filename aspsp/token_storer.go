@@ -1,21 +1,61 @@
 package aspsp
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"github.com/jmatosp/obclient/authorization"
 	"github.com/pkg/errors"
 	"io/ioutil"
 	"os"
 	"path"
+	"time"
 )
 
+const (
+	ConsentAuthorised = "Authorised"
+	ConsentRevoked    = "Revoked"
+	ConsentExpired    = "Expired"
+	ConsentConsumed   = "Consumed"
+)
+
+// TokenStorer keeps the AIS token bound to the account access consent it was authorised for,
+// Get returns an InvalidTokenError once the consent is no longer authorised or the token expired
+// and can not be refreshed
 type TokenStorer interface {
-	Store(authorization.Token) error
-	Get() (authorization.Token, error)
+	Store(StoredToken) error
+	Get() (StoredToken, error)
+	GetContext(context.Context) (StoredToken, error)
+	// Invalidate records the consent status and why, tokens of other consents are kept
+	Invalidate(consentId, status, reason string) error
+	// Delete forgets the token, ErrNotFound if there is none
+	Delete() error
+}
+
+// StoredToken is a token and its consent, zero expiration times never expire
+type StoredToken struct {
+	Token          authorization.Token
+	ConsentId      string
+	ConsentStatus  string
+	Reason         string
+	TokenExpires   time.Time
+	ConsentExpires time.Time
+}
+
+// InvalidTokenError tells why a stored token can no longer be used, the PSU must authenticate again
+type InvalidTokenError struct {
+	ConsentId string
+	Status    string
+	Reason    string
+}
+
+func (e InvalidTokenError) Error() string {
+	return fmt.Sprintf("token of consent %s is no longer valid, consent %s: %s", e.ConsentId, e.Status, e.Reason)
 }
 
 type fileTokenStorer struct {
-	folder string
+	folder    string
+	generator authorization.TokenGenerator
 }
 
 func NewFileTokenStorer(folder string) TokenStorer {
@@ -24,32 +64,125 @@ func NewFileTokenStorer(folder string) TokenStorer {
 	}
 }
 
-func (s fileTokenStorer) Store(token authorization.Token) error {
+// NewRefreshingFileTokenStorer refreshes expired access tokens that have a refresh token with generator,
+// storing the new token
+func NewRefreshingFileTokenStorer(folder string, generator authorization.TokenGenerator) TokenStorer {
+	return fileTokenStorer{
+		folder:    folder,
+		generator: generator,
+	}
+}
+
+func (s fileTokenStorer) Store(token StoredToken) error {
 	tokenJson, err := json.Marshal(token)
 	if err != nil {
 		return errors.Wrap(err, "error storing token")
 	}
 
-	err = ioutil.WriteFile(s.filename(), tokenJson, 0644)
+	err = ioutil.WriteFile(s.filename(), tokenJson, 0600)
 	if err != nil {
 		return errors.Wrap(err, "error storing token")
 	}
 
+	// tokens stored by earlier versions were readable by anyone
+	if err = os.Chmod(s.filename(), 0600); err != nil {
+		return errors.Wrap(err, "error storing token")
+	}
+
 	return nil
 }
 
-func (s fileTokenStorer) Get() (authorization.Token, error) {
-	clientJson, err := ioutil.ReadFile(s.filename())
+func (s fileTokenStorer) Get() (StoredToken, error) {
+	return s.GetContext(context.Background())
+}
+
+func (s fileTokenStorer) GetContext(ctx context.Context) (StoredToken, error) {
+	token, err := s.read()
+	if err != nil {
+		return token, err
+	}
+
+	now := time.Now()
+	switch {
+	case token.ConsentStatus != ConsentAuthorised:
+		return token, InvalidTokenError{token.ConsentId, token.ConsentStatus, token.Reason}
+	case !token.ConsentExpires.IsZero() && now.After(token.ConsentExpires):
+		return token, InvalidTokenError{token.ConsentId, ConsentExpired, "consent expired on " + token.ConsentExpires.Format(time.RFC3339)}
+	case !token.TokenExpires.IsZero() && now.After(token.TokenExpires):
+		return s.refresh(ctx, token)
+	}
+
+	return token, nil
+}
+
+// refresh replaces the expired access token of token, an InvalidTokenError if it has no refresh token or it is rejected
+func (s fileTokenStorer) refresh(ctx context.Context, token StoredToken) (StoredToken, error) {
+	expired := "access token expired on " + token.TokenExpires.Format(time.RFC3339)
+	if s.generator == nil || token.Token.RefreshToken == "" {
+		return token, InvalidTokenError{token.ConsentId, token.ConsentStatus, expired}
+	}
+
+	refreshed, err := s.generator.RefreshContext(ctx, token.Token)
+	if err != nil {
+		return token, InvalidTokenError{token.ConsentId, token.ConsentStatus, expired + " and could not be refreshed, " + err.Error()}
+	}
+
+	token.Token = refreshed
+	token.TokenExpires = time.Time{}
+	if refreshed.ExpiresIn > 0 {
+		token.TokenExpires = time.Now().Add(time.Duration(refreshed.ExpiresIn) * time.Second)
+	}
+	if err = s.Store(token); err != nil {
+		return token, err
+	}
+
+	return token, nil
+}
+
+func (s fileTokenStorer) Invalidate(consentId, status, reason string) error {
+	token, err := s.read()
+	if err == ErrNotFound || token.ConsentId != consentId {
+		return nil
+	} else if err != nil {
+		return errors.Wrap(err, "error invalidating token")
+	}
+
+	token.ConsentStatus = status
+	token.Reason = reason
+	return s.Store(token)
+}
+
+func (s fileTokenStorer) Delete() error {
+	err := os.Remove(s.filename())
 	if os.IsNotExist(err) {
-		return authorization.NoToken, ErrNotFound
+		return ErrNotFound
 	} else if err != nil {
-		return authorization.NoToken, errors.Wrap(err, "error getting token")
+		return errors.Wrap(err, "error deleting token")
 	}
 
-	var token authorization.Token
-	err = json.Unmarshal(clientJson, &token)
-	if err != nil {
-		return authorization.NoToken, errors.Wrap(err, "error getting token")
+	return nil
+}
+
+// read also accepts tokens stored before they were bound to a consent, as authorised
+func (s fileTokenStorer) read() (StoredToken, error) {
+	tokenJson, err := ioutil.ReadFile(s.filename())
+	if os.IsNotExist(err) {
+		return StoredToken{}, ErrNotFound
+	} else if err != nil {
+		return StoredToken{}, errors.Wrap(err, "error getting token")
+	}
+
+	var token StoredToken
+	if err = json.Unmarshal(tokenJson, &token); err != nil {
+		return StoredToken{}, errors.Wrap(err, "error getting token")
+	}
+
+	if token.Token == authorization.NoToken {
+		var unbound authorization.Token
+		if err = json.Unmarshal(tokenJson, &unbound); err != nil {
+			return StoredToken{}, errors.Wrap(err, "error getting token")
+		}
+		token = StoredToken{Token: unbound, ConsentStatus: ConsentAuthorised}
 	}
 
 	return token, nil
@@ -0,0 +1,229 @@
+package aspsp
+
+import (
+	"context"
+	"github.com/jmatosp/obclient/authorization"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// refreshGenerator answers refreshes with token or err, counting them
+type refreshGenerator struct {
+	token     authorization.Token
+	err       error
+	refreshes int
+}
+
+func (g *refreshGenerator) Request(code authorization.Code) (authorization.Token, error) {
+	return g.RequestContext(context.Background(), code)
+}
+
+func (g *refreshGenerator) RequestContext(context.Context, authorization.Code) (authorization.Token, error) {
+	return authorization.NoToken, errors.New("unexpected code grant")
+}
+
+func (g *refreshGenerator) Refresh(token authorization.Token) (authorization.Token, error) {
+	return g.RefreshContext(context.Background(), token)
+}
+
+func (g *refreshGenerator) RefreshContext(_ context.Context, token authorization.Token) (authorization.Token, error) {
+	g.refreshes++
+	if token.RefreshToken != "refresh-1" {
+		return authorization.NoToken, errors.Errorf("refreshed with %q", token.RefreshToken)
+	}
+	return g.token, g.err
+}
+
+func TestFileTokenStorerGet(t *testing.T) {
+	now := time.Now()
+	stored := func(change func(*StoredToken)) StoredToken {
+		token := StoredToken{
+			Token:         authorization.Token{AccessToken: "access-1", TokenType: "Bearer", ExpiresIn: 3600, RefreshToken: "refresh-1"},
+			ConsentId:     "aac-1",
+			ConsentStatus: ConsentAuthorised,
+			TokenExpires:  now.Add(time.Hour),
+		}
+		if change != nil {
+			change(&token)
+		}
+		return token
+	}
+	expired := func(token *StoredToken) {
+		token.TokenExpires = now.Add(-time.Minute)
+	}
+	refreshed := authorization.Token{AccessToken: "access-2", TokenType: "Bearer", ExpiresIn: 600, RefreshToken: "refresh-2"}
+
+	tests := []struct {
+		name      string
+		token     StoredToken
+		generator *refreshGenerator
+		// want is the access token got, and stored, when wantErr is false
+		want          string
+		wantRefreshes int
+		// wantExpires is how long the token got is valid for, zero never expires
+		wantExpires time.Duration
+		wantErr     bool
+	}{
+		{name: "valid", token: stored(nil), generator: &refreshGenerator{token: refreshed}, want: "access-1", wantExpires: time.Hour},
+		{name: "without expiry", token: stored(func(token *StoredToken) {
+			token.TokenExpires = time.Time{}
+		}), generator: &refreshGenerator{token: refreshed}, want: "access-1"},
+		{name: "expired is refreshed", token: stored(expired), generator: &refreshGenerator{token: refreshed}, want: "access-2", wantRefreshes: 1, wantExpires: 10 * time.Minute},
+		{name: "refreshed without expiry", token: stored(expired), generator: &refreshGenerator{token: authorization.Token{AccessToken: "access-2"}}, want: "access-2", wantRefreshes: 1},
+		{name: "expired without refresh token", token: stored(func(token *StoredToken) {
+			expired(token)
+			token.Token.RefreshToken = ""
+		}), generator: &refreshGenerator{token: refreshed}, wantErr: true},
+		{name: "expired without generator", token: stored(expired), wantErr: true},
+		{name: "refresh rejected", token: stored(expired), generator: &refreshGenerator{err: errors.New("unexpected status code 400: invalid_grant")}, wantRefreshes: 1, wantErr: true},
+		{name: "revoked consent is not refreshed", token: stored(func(token *StoredToken) {
+			expired(token)
+			token.ConsentStatus = ConsentRevoked
+		}), generator: &refreshGenerator{token: refreshed}, wantErr: true},
+		{name: "expired consent is not refreshed", token: stored(func(token *StoredToken) {
+			expired(token)
+			token.ConsentExpires = now.Add(-time.Minute)
+		}), generator: &refreshGenerator{token: refreshed}, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			folder := t.TempDir()
+			storer := NewFileTokenStorer(folder)
+			if test.generator != nil {
+				storer = NewRefreshingFileTokenStorer(folder, test.generator)
+			}
+			if err := storer.Store(test.token); err != nil {
+				t.Fatal(err)
+			}
+
+			token, err := storer.Get()
+			refreshes := 0
+			if test.generator != nil {
+				refreshes = test.generator.refreshes
+			}
+			if refreshes != test.wantRefreshes {
+				t.Errorf("got %d refreshes, want %d", refreshes, test.wantRefreshes)
+			}
+			if test.wantErr {
+				if _, ok := err.(InvalidTokenError); !ok {
+					t.Fatalf("got %v, want an InvalidTokenError", err)
+				}
+				if again, _ := NewFileTokenStorer(folder).Get(); again.Token != test.token.Token {
+					t.Errorf("stored token changed to %+v", again.Token)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if token.Token.AccessToken != test.want || token.ConsentId != "aac-1" {
+				t.Errorf("got token %s of consent %s, want %s of aac-1", token.Token.AccessToken, token.ConsentId, test.want)
+			}
+			if test.wantExpires == 0 && !token.TokenExpires.IsZero() {
+				t.Errorf("token expires on %s, want never", token.TokenExpires)
+			}
+			if test.wantExpires != 0 {
+				if valid := time.Until(token.TokenExpires); valid > test.wantExpires || valid < test.wantExpires-time.Minute {
+					t.Errorf("token valid for %s, want %s", valid, test.wantExpires)
+				}
+			}
+
+			again, err := NewFileTokenStorer(folder).Get()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if again.Token != token.Token || !again.TokenExpires.Equal(token.TokenExpires) {
+				t.Errorf("stored %+v expiring %s, want the token got", again.Token, again.TokenExpires)
+			}
+		})
+	}
+}
+
+func TestFileTokenStorerRefreshRejectedReason(t *testing.T) {
+	storer := NewRefreshingFileTokenStorer(t.TempDir(), &refreshGenerator{err: errors.New("unexpected status code 400: invalid_grant")})
+	token := StoredToken{
+		Token:         authorization.Token{AccessToken: "access-1", RefreshToken: "refresh-1"},
+		ConsentId:     "aac-1",
+		ConsentStatus: ConsentAuthorised,
+		TokenExpires:  time.Now().Add(-time.Minute),
+	}
+	if err := storer.Store(token); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := storer.Get()
+	invalid, ok := err.(InvalidTokenError)
+	if !ok || invalid.ConsentId != "aac-1" || !strings.Contains(invalid.Reason, "invalid_grant") {
+		t.Errorf("got %v, want the refresh error of consent aac-1", err)
+	}
+}
+
+func TestFileTokenStorerInvalidate(t *testing.T) {
+	storer := NewFileTokenStorer(t.TempDir())
+	if _, err := storer.Get(); err != ErrNotFound {
+		t.Fatalf("got %v, want ErrNotFound", err)
+	}
+	if err := storer.Invalidate("aac-1", ConsentRevoked, "revoked by the PSU"); err != nil {
+		t.Fatalf("invalidating without token: %v", err)
+	}
+
+	token := StoredToken{Token: authorization.Token{AccessToken: "access-1"}, ConsentId: "aac-1", ConsentStatus: ConsentAuthorised}
+	if err := storer.Store(token); err != nil {
+		t.Fatal(err)
+	}
+	if err := storer.Invalidate("aac-2", ConsentRevoked, "revoked by the PSU"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := storer.Get(); err != nil {
+		t.Fatalf("token of another consent invalidated: %v", err)
+	}
+
+	if err := storer.Invalidate("aac-1", ConsentRevoked, "revoked by the PSU"); err != nil {
+		t.Fatal(err)
+	}
+	_, err := storer.Get()
+	if invalid, ok := err.(InvalidTokenError); !ok || invalid.Status != ConsentRevoked || invalid.Reason != "revoked by the PSU" {
+		t.Errorf("got %v, want the revoked consent", err)
+	}
+
+	if err = storer.Delete(); err != nil {
+		t.Fatal(err)
+	}
+	if err = storer.Delete(); err != ErrNotFound {
+		t.Errorf("deleting twice got %v, want ErrNotFound", err)
+	}
+}
+
+func TestFileTokenStorerUnbound(t *testing.T) {
+	folder := t.TempDir()
+	filename := filepath.Join(folder, "token.json")
+	if err := ioutil.WriteFile(filename, []byte(`{"access_token":"access-1","token_type":"Bearer","expires_in":3600}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	storer := NewFileTokenStorer(folder)
+	token, err := storer.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Token.AccessToken != "access-1" || token.ConsentStatus != ConsentAuthorised {
+		t.Errorf("got %+v, want an authorised token of access-1", token)
+	}
+
+	if err = storer.Store(token); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("token file mode %s, want -rw-------", info.Mode().Perm())
+	}
+}
@@ -0,0 +1,109 @@
+package aspsp
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// TransactionIterator fetches transaction pages lazily following Links.Next,
+// stop calling Next to end early
+//
+//	for iterator.Next() {
+//		transaction := iterator.Transaction()
+//	}
+//	if err := iterator.Err(); err != nil {
+//	}
+type TransactionIterator interface {
+	Next() bool
+	Transaction() Transaction
+	Err() error
+	Page() TransactionPage
+}
+
+// TransactionPage is the metadata of the last fetched page
+type TransactionPage struct {
+	Number                 int
+	TotalPages             int
+	FirstAvailableDateTime time.Time
+	LastAvailableDateTime  time.Time
+}
+
+type transactionIterator struct {
+	ctx          context.Context
+	client       ResourceClient
+	next         string
+	transactions []Transaction
+	index        int
+	current      Transaction
+	page         TransactionPage
+	err          error
+}
+
+// NewTransactionIterator iterates transactions of path and its next pages, pages are
+// only requested when Next runs out of transactions
+func NewTransactionIterator(ctx context.Context, client ResourceClient, path string) TransactionIterator {
+	return &transactionIterator{
+		ctx:    ctx,
+		client: client,
+		next:   path,
+	}
+}
+
+func (i *transactionIterator) Next() bool {
+	for i.index >= len(i.transactions) {
+		if i.err != nil || i.next == "" {
+			return false
+		}
+		i.fetch()
+	}
+
+	i.current = i.transactions[i.index]
+	i.index++
+	return true
+}
+
+func (i *transactionIterator) Transaction() Transaction {
+	return i.current
+}
+
+func (i *transactionIterator) Err() error {
+	return i.err
+}
+
+func (i *transactionIterator) Page() TransactionPage {
+	return i.page
+}
+
+func (i *transactionIterator) fetch() {
+	var transactionsResponse TransactionsResponse
+	if err := i.client.GetContext(i.ctx, i.next, &transactionsResponse); err != nil {
+		i.err = errors.Wrap(err, "error listing transactions")
+		return
+	}
+
+	i.transactions = i.transactions[:0]
+	for _, transaction := range transactionsResponse.Data.Transaction {
+		mapped, err := mapTransaction(transaction)
+		if err != nil {
+			i.err = errors.Wrap(err, "error listing transactions")
+			return
+		}
+		i.transactions = append(i.transactions, mapped)
+	}
+	i.index = 0
+
+	i.page = TransactionPage{
+		Number:                 i.page.Number + 1,
+		TotalPages:             transactionsResponse.Meta.TotalPages,
+		FirstAvailableDateTime: parseDateTime(transactionsResponse.Meta.FirstAvailableDateTime),
+		LastAvailableDateTime:  parseDateTime(transactionsResponse.Meta.LastAvailableDateTime),
+	}
+
+	// a next link pointing to the same page would loop forever
+	if transactionsResponse.Links.Next == i.next || transactionsResponse.Links.Next == transactionsResponse.Links.Self {
+		i.next = ""
+		return
+	}
+	i.next = transactionsResponse.Links.Next
+}
@@ -0,0 +1,104 @@
+package aspsp
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"time"
+)
+
+type TransactionLoaderFunc func(from, to time.Time) ([]Transaction, error)
+
+func ErrNotImplementedTransactionLoaderFunc(_, _ time.Time) ([]Transaction, error) {
+	return nil, errors.New("transactions loader not implemented")
+}
+
+var errResourceNotImplemented = errors.New("account resource not implemented")
+
+// NewAccount loads transactions with transactionLoader, other account resources are not implemented
+//
+// Deprecated: use NewResourceAccount
+func NewAccount(id AccountId, currency, accType, subtype, nickname string, identity AccountIdentity, transactionLoader TransactionLoaderFunc) Account {
+	return NewResourceAccount(id, currency, accType, subtype, nickname, identity, loaderResources{load: transactionLoader})
+}
+
+// loaderResources adapts a TransactionLoaderFunc to AccountResources
+type loaderResources struct {
+	load TransactionLoaderFunc
+}
+
+func (r loaderResources) Transactions(_ context.Context, _ AccountId, from, to time.Time) ([]Transaction, error) {
+	return r.load(from, to)
+}
+
+func (r loaderResources) TransactionIterator(_ context.Context, _ AccountId, from, to time.Time) TransactionIterator {
+	transactions, err := r.load(from, to)
+	return &loadedTransactionIterator{transactions: transactions, err: err}
+}
+
+func (r loaderResources) Balances(context.Context, AccountId) ([]Balance, error) {
+	return nil, errResourceNotImplemented
+}
+
+func (r loaderResources) Products(context.Context, AccountId) ([]Product, error) {
+	return nil, errResourceNotImplemented
+}
+
+func (r loaderResources) Offers(context.Context, AccountId) ([]Offer, error) {
+	return nil, errResourceNotImplemented
+}
+
+func (r loaderResources) Party(context.Context, AccountId) (Party, error) {
+	return Party{}, errResourceNotImplemented
+}
+
+func (r loaderResources) Parties(context.Context, AccountId) ([]Party, error) {
+	return nil, errResourceNotImplemented
+}
+
+func (r loaderResources) ScheduledPayments(context.Context, AccountId) ([]ScheduledPayment, error) {
+	return nil, errResourceNotImplemented
+}
+
+func (r loaderResources) Statements(context.Context, AccountId, time.Time, time.Time) ([]Statement, error) {
+	return nil, errResourceNotImplemented
+}
+
+func (r loaderResources) StatementFile(context.Context, AccountId, string) ([]byte, error) {
+	return nil, errResourceNotImplemented
+}
+
+func (r loaderResources) StatementTransactions(context.Context, AccountId, string) ([]Transaction, error) {
+	return nil, errResourceNotImplemented
+}
+
+// loadedTransactionIterator iterates transactions already loaded as a single page
+type loadedTransactionIterator struct {
+	transactions []Transaction
+	index        int
+	current      Transaction
+	err          error
+}
+
+func (i *loadedTransactionIterator) Next() bool {
+	if i.err != nil || i.index >= len(i.transactions) {
+		return false
+	}
+	i.current = i.transactions[i.index]
+	i.index++
+	return true
+}
+
+func (i *loadedTransactionIterator) Transaction() Transaction {
+	return i.current
+}
+
+func (i *loadedTransactionIterator) Err() error {
+	return i.err
+}
+
+func (i *loadedTransactionIterator) Page() TransactionPage {
+	if i.err != nil {
+		return TransactionPage{}
+	}
+	return TransactionPage{Number: 1, TotalPages: 1}
+}
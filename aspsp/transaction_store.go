@@ -0,0 +1,93 @@
+package aspsp
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"time"
+)
+
+// TransactionStore keeps synced transactions per account
+type TransactionStore interface {
+	Store(AccountId, StoredTransactions) error
+	Get(AccountId) (StoredTransactions, error)
+}
+
+// StoredTransactions are the transactions of an account ordered by booking date
+type StoredTransactions struct {
+	SyncedAt     time.Time
+	LastBooked   time.Time
+	Transactions []Transaction
+}
+
+var NoStoredTransactions = StoredTransactions{}
+
+// Between returns transactions booked in [from, to], zero times are unbounded
+func (s StoredTransactions) Between(from, to time.Time) []Transaction {
+	var transactions []Transaction
+	for _, transaction := range s.Transactions {
+		if !from.IsZero() && transaction.BookingDateTime.Before(from) {
+			continue
+		}
+		if !to.IsZero() && transaction.BookingDateTime.After(to) {
+			continue
+		}
+		transactions = append(transactions, transaction)
+	}
+	return transactions
+}
+
+type fileTransactionStore struct {
+	folder string
+}
+
+// NewFileTransactionStore stores a json file per account in folder
+func NewFileTransactionStore(folder string) TransactionStore {
+	return fileTransactionStore{
+		folder: folder,
+	}
+}
+
+func (s fileTransactionStore) Store(accountId AccountId, transactions StoredTransactions) error {
+	transactionsJson, err := json.Marshal(transactions)
+	if err != nil {
+		return errors.Wrap(err, "error storing transactions")
+	}
+
+	// write and rename so an interrupted sync never leaves a truncated store
+	filename := s.filename(accountId)
+	err = ioutil.WriteFile(filename+".tmp", transactionsJson, 0644)
+	if err != nil {
+		return errors.Wrap(err, "error storing transactions")
+	}
+
+	if err = os.Rename(filename+".tmp", filename); err != nil {
+		return errors.Wrap(err, "error storing transactions")
+	}
+
+	return nil
+}
+
+func (s fileTransactionStore) Get(accountId AccountId) (StoredTransactions, error) {
+	transactionsJson, err := ioutil.ReadFile(s.filename(accountId))
+	if os.IsNotExist(err) {
+		return NoStoredTransactions, ErrNotFound
+	} else if err != nil {
+		return NoStoredTransactions, errors.Wrap(err, "error getting stored transactions")
+	}
+
+	var transactions StoredTransactions
+	err = json.Unmarshal(transactionsJson, &transactions)
+	if err != nil {
+		return NoStoredTransactions, errors.Wrap(err, "error getting stored transactions")
+	}
+
+	return transactions, nil
+}
+
+func (s fileTransactionStore) filename(accountId AccountId) string {
+	return path.Join(s.folder, "transactions-"+url.PathEscape(string(accountId))+".json")
+}
@@ -1,7 +1,157 @@
 package aspsp
 
-import "time"
+import (
+	"context"
+	"net/url"
+	"time"
+)
 
 type TransactionLister interface {
 	List(to, from *time.Time) ([]Transaction, error)
+	ListContext(ctx context.Context, to, from *time.Time) ([]Transaction, error)
+	Iterate(to, from *time.Time) TransactionIterator
+	IterateContext(ctx context.Context, to, from *time.Time) TransactionIterator
+}
+
+type transactionLister struct {
+	client    ResourceClient
+	accountId AccountId
+}
+
+// NewTransactionLister lists transactions of accountId, or of all accounts when accountId is empty
+func NewTransactionLister(client ResourceClient, accountId AccountId) TransactionLister {
+	return &transactionLister{
+		client:    client,
+		accountId: accountId,
+	}
+}
+
+func (t *transactionLister) List(to, from *time.Time) ([]Transaction, error) {
+	return t.ListContext(context.Background(), to, from)
+}
+
+func (t *transactionLister) ListContext(ctx context.Context, to, from *time.Time) ([]Transaction, error) {
+	return collectTransactions(t.IterateContext(ctx, to, from))
+}
+
+func (t *transactionLister) Iterate(to, from *time.Time) TransactionIterator {
+	return t.IterateContext(context.Background(), to, from)
+}
+
+func (t *transactionLister) IterateContext(ctx context.Context, to, from *time.Time) TransactionIterator {
+	query := url.Values{}
+	if from != nil {
+		query.Set("fromBookingDateTime", from.Format(queryDateTimeLayout))
+	}
+	if to != nil {
+		query.Set("toBookingDateTime", to.Format(queryDateTimeLayout))
+	}
+
+	path := "/transactions"
+	if t.accountId != "" {
+		path = accountPath(t.accountId, path)
+	}
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	return NewTransactionIterator(ctx, t.client, path)
+}
+
+const queryDateTimeLayout = "2006-01-02T15:04:05"
+
+// collectTransactions reads every page of iterator
+func collectTransactions(iterator TransactionIterator) ([]Transaction, error) {
+	var transactions []Transaction
+	for iterator.Next() {
+		transactions = append(transactions, iterator.Transaction())
+	}
+	if err := iterator.Err(); err != nil {
+		return []Transaction{}, err
+	}
+
+	return transactions, nil
+}
+
+type TransactionsResponse struct {
+	Data  TransactionsDataResponse `json:"Data"`
+	Links LinksResponse            `json:"Links"`
+	Meta  MetaResponse             `json:"Meta"`
+}
+
+type TransactionsDataResponse struct {
+	Transaction []TransactionResponse `json:"Transaction"`
+}
+
+type TransactionResponse struct {
+	AccountId                      string                                  `json:"AccountId"`
+	TransactionId                  string                                  `json:"TransactionId"`
+	TransactionReference           string                                  `json:"TransactionReference"`
+	CreditDebitIndicator           string                                  `json:"CreditDebitIndicator"`
+	Status                         string                                  `json:"Status"`
+	BookingDateTime                string                                  `json:"BookingDateTime"`
+	ValueDateTime                  string                                  `json:"ValueDateTime"`
+	TransactionInformation         string                                  `json:"TransactionInformation"`
+	Amount                         AmountResponse                          `json:"Amount"`
+	BankTransactionCode            *BankTransactionCodeResponse            `json:"BankTransactionCode"`
+	ProprietaryBankTransactionCode *ProprietaryBankTransactionCodeResponse `json:"ProprietaryBankTransactionCode"`
+	MerchantDetails                *MerchantDetailsResponse                `json:"MerchantDetails"`
+	CreditorAccount                *CashAccountResponse                    `json:"CreditorAccount"`
+	DebtorAccount                  *CashAccountResponse                    `json:"DebtorAccount"`
+}
+
+type BankTransactionCodeResponse struct {
+	Code    string `json:"Code"`
+	SubCode string `json:"SubCode"`
+}
+
+type ProprietaryBankTransactionCodeResponse struct {
+	Code   string `json:"Code"`
+	Issuer string `json:"Issuer"`
+}
+
+type MerchantDetailsResponse struct {
+	MerchantName         string `json:"MerchantName"`
+	MerchantCategoryCode string `json:"MerchantCategoryCode"`
+}
+
+func mapTransaction(transaction TransactionResponse) (Transaction, error) {
+	amount, err := signedMoney(transaction.Amount, transaction.CreditDebitIndicator)
+	if err != nil {
+		return Transaction{}, err
+	}
+
+	mapped := Transaction{
+		Id:                   transaction.TransactionId,
+		Reference:            transaction.TransactionReference,
+		Amount:               amount,
+		CreditDebitIndicator: transaction.CreditDebitIndicator,
+		Status:               transaction.Status,
+		BookingDateTime:      parseDateTime(transaction.BookingDateTime),
+		ValueDateTime:        parseDateTime(transaction.ValueDateTime),
+		Information:          transaction.TransactionInformation,
+	}
+	if code := transaction.BankTransactionCode; code != nil && code.Code != "" {
+		mapped.BankTransactionCode = code.Code + "/" + code.SubCode
+	}
+	if code := transaction.ProprietaryBankTransactionCode; code != nil {
+		mapped.ProprietaryBankTransactionCode = code.Code
+	}
+	if merchant := transaction.MerchantDetails; merchant != nil {
+		mapped.MerchantName = merchant.MerchantName
+		mapped.MerchantCategoryCode = merchant.MerchantCategoryCode
+	}
+	counterparty := transaction.CreditorAccount
+	if mapped.Credit() {
+		counterparty = transaction.DebtorAccount
+	}
+	if counterparty != nil {
+		mapped.Counterparty = Counterparty{
+			SchemeName:     counterparty.SchemeName,
+			Identification: counterparty.Identification,
+			Name:           counterparty.Name,
+		}
+	}
+
+	return mapped, nil
 }
@@ -0,0 +1,530 @@
+package aspsp
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// VRP types, sweeping moves money between accounts of the same PSU
+const (
+	VRPTypeSweeping = "UK.OBIE.VRPType.Sweeping"
+	VRPTypeOther    = "UK.OBIE.VRPType.Other"
+)
+
+// Periodic limits apply per period starting at the consent ValidFromDateTime or at calendar boundaries
+const (
+	PeriodAlignmentConsent  = "Consent"
+	PeriodAlignmentCalendar = "Calendar"
+)
+
+var periodTypes = []string{"Day", "Week", "Fortnight", "Month", "Half-year", "Year"}
+
+// VRPConsenter manages variable recurring payment consents, it needs a client
+// credentials token, see authorization.IntentAuthenticator
+type VRPConsenter interface {
+	Create(VRPConsentRequest) (VRPConsent, error)
+	CreateContext(context.Context, VRPConsentRequest) (VRPConsent, error)
+	Get(string) (VRPConsent, error)
+	GetContext(context.Context, string) (VRPConsent, error)
+	Delete(string) error
+	DeleteContext(context.Context, string) error
+}
+
+// VRPSubmitter confirms funds and submits payments of an authorised VRP consent without
+// the PSU, it needs the token the PSU authorised the consent with, refreshed as it expires
+type VRPSubmitter interface {
+	ConfirmFunds(FundsConfirmationRequest) (FundsConfirmation, error)
+	ConfirmFundsContext(context.Context, FundsConfirmationRequest) (FundsConfirmation, error)
+	Submit(VRPConsent, VRPInstruction) (VRP, error)
+	SubmitContext(context.Context, VRPConsent, VRPInstruction) (VRP, error)
+	Get(string) (VRP, error)
+	GetContext(context.Context, string) (VRP, error)
+}
+
+// VRPControlParameters limit the payments of a VRP consent, zero validity times are not sent
+type VRPControlParameters struct {
+	// VRPType defaults to VRPTypeSweeping
+	VRPType                 string
+	ValidFromDateTime       time.Time
+	ValidToDateTime         time.Time
+	MaximumIndividualAmount Money
+	PeriodicLimits          []PeriodicLimit
+}
+
+// PeriodicLimit caps the sum of payments per period, PeriodType is one of Day, Week,
+// Fortnight, Month, Half-year or Year and PeriodAlignment defaults to PeriodAlignmentConsent
+type PeriodicLimit struct {
+	PeriodType      string
+	PeriodAlignment string
+	Amount          Money
+}
+
+type VRPConsentRequest struct {
+	DebtorAccount     AccountIdentity
+	CreditorAccount   AccountIdentity
+	Reference         string
+	ControlParameters VRPControlParameters
+}
+
+type VRPConsent struct {
+	ConsentId            string
+	Status               string
+	CreationDateTime     time.Time
+	StatusUpdateDateTime time.Time
+	DebtorAccount        AccountIdentity
+	CreditorAccount      AccountIdentity
+	Reference            string
+	ControlParameters    VRPControlParameters
+}
+
+var NoVRPConsent = VRPConsent{}
+
+// VRPInstruction is one payment of a VRP consent, InstructionId and EndToEndId are generated when empty
+type VRPInstruction struct {
+	InstructionId string
+	EndToEndId    string
+	Amount        Money
+	Reference     string
+}
+
+type VRP struct {
+	Id                   string
+	ConsentId            string
+	Status               string
+	CreationDateTime     time.Time
+	StatusUpdateDateTime time.Time
+	Amount               Money
+}
+
+var NoVRP = VRP{}
+
+type vrpConsenter struct {
+	client ResourceClient
+}
+
+func NewVRPConsenter(client ResourceClient) VRPConsenter {
+	return &vrpConsenter{
+		client: client,
+	}
+}
+
+func (v *vrpConsenter) Create(request VRPConsentRequest) (VRPConsent, error) {
+	return v.CreateContext(context.Background(), request)
+}
+
+func (v *vrpConsenter) CreateContext(ctx context.Context, request VRPConsentRequest) (VRPConsent, error) {
+	if request.CreditorAccount == nil {
+		return NoVRPConsent, errors.New("error creating VRP consent: creditor account not provided")
+	}
+	parameters, err := controlParametersRequest(request.ControlParameters)
+	if err != nil {
+		return NoVRPConsent, errors.Wrap(err, "error creating VRP consent")
+	}
+
+	body := VRPConsentRequestBody{
+		Data: VRPConsentDataRequest{
+			ReadRefundAccount: "No",
+			ControlParameters: parameters,
+			Initiation:        vrpInitiation(request.DebtorAccount, request.CreditorAccount, request.Reference),
+		},
+		Risk: map[string]string{},
+	}
+
+	// consents have no identification of their own, the same control parameters, accounts and reference are
+	// the same consent
+	data, err := json.Marshal(body.Data)
+	if err != nil {
+		return NoVRPConsent, errors.Wrap(err, "error creating VRP consent")
+	}
+
+	var response VRPConsentResponse
+	ctx = idempotent(ctx, IdempotencyKey("/domestic-vrp-consents", string(data)))
+	ctx = signed(ctx)
+	if err = v.client.PostContext(ctx, "/domestic-vrp-consents", body, &response); err != nil {
+		return NoVRPConsent, errors.Wrap(err, "error creating VRP consent")
+	}
+
+	consent, err := mapVRPConsent(response.Data)
+	if err != nil {
+		return NoVRPConsent, errors.Wrap(err, "error creating VRP consent")
+	}
+	return consent, nil
+}
+
+func (v *vrpConsenter) Get(consentId string) (VRPConsent, error) {
+	return v.GetContext(context.Background(), consentId)
+}
+
+func (v *vrpConsenter) GetContext(ctx context.Context, consentId string) (VRPConsent, error) {
+	var response VRPConsentResponse
+	if err := v.client.GetContext(ctx, "/domestic-vrp-consents/"+url.PathEscape(consentId), &response); err != nil {
+		return NoVRPConsent, errors.Wrap(err, "error getting VRP consent")
+	}
+
+	consent, err := mapVRPConsent(response.Data)
+	if err != nil {
+		return NoVRPConsent, errors.Wrap(err, "error getting VRP consent")
+	}
+	return consent, nil
+}
+
+func (v *vrpConsenter) Delete(consentId string) error {
+	return v.DeleteContext(context.Background(), consentId)
+}
+
+func (v *vrpConsenter) DeleteContext(ctx context.Context, consentId string) error {
+	if err := v.client.DoContext(ctx, http.MethodDelete, "/domestic-vrp-consents/"+url.PathEscape(consentId), nil, nil); err != nil {
+		return errors.Wrap(err, "error deleting VRP consent")
+	}
+
+	return nil
+}
+
+type vrpSubmitter struct {
+	client ResourceClient
+}
+
+func NewVRPSubmitter(client ResourceClient) VRPSubmitter {
+	return &vrpSubmitter{
+		client: client,
+	}
+}
+
+func (v *vrpSubmitter) ConfirmFunds(request FundsConfirmationRequest) (FundsConfirmation, error) {
+	return v.ConfirmFundsContext(context.Background(), request)
+}
+
+func (v *vrpSubmitter) ConfirmFundsContext(ctx context.Context, request FundsConfirmationRequest) (FundsConfirmation, error) {
+	if request.Amount.IsNegative() || request.Amount.IsZero() {
+		return NoFundsConfirmation, errors.Errorf("error confirming funds: invalid amount %s", request.Amount)
+	}
+
+	body := FundsConfirmationRequestBody{
+		Data: FundsConfirmationDataRequest{
+			ConsentId: request.ConsentId,
+			Reference: request.Reference,
+			InstructedAmount: AmountResponse{
+				Amount:   request.Amount.Amount(),
+				Currency: request.Amount.Currency(),
+			},
+		},
+	}
+
+	var response VRPFundsConfirmationResponse
+	path := "/domestic-vrp-consents/" + url.PathEscape(request.ConsentId) + "/funds-confirmation"
+	if err := v.client.PostContext(signed(ctx), path, body, &response); err != nil {
+		return NoFundsConfirmation, errors.Wrap(err, "error confirming funds")
+	}
+
+	amount, err := optionalMoney(response.Data.InstructedAmount)
+	if err != nil {
+		return NoFundsConfirmation, errors.Wrap(err, "error confirming funds")
+	}
+
+	return FundsConfirmation{
+		Id:               response.Data.FundsConfirmationId,
+		ConsentId:        response.Data.ConsentId,
+		Reference:        response.Data.Reference,
+		Amount:           amount,
+		FundsAvailable:   response.Data.FundsAvailableResult.FundsAvailable == "Available",
+		CreationDateTime: parseDateTime(response.Data.CreationDateTime),
+	}, nil
+}
+
+func (v *vrpSubmitter) Submit(consent VRPConsent, instruction VRPInstruction) (VRP, error) {
+	return v.SubmitContext(context.Background(), consent, instruction)
+}
+
+// SubmitContext pays instruction to the consent creditor, the ASPSP checks it against the consent control parameters
+func (v *vrpSubmitter) SubmitContext(ctx context.Context, consent VRPConsent, instruction VRPInstruction) (VRP, error) {
+	if consent.CreditorAccount == nil {
+		return NoVRP, errors.New("error submitting VRP: consent creditor account not provided")
+	}
+	if instruction.Amount.IsNegative() || instruction.Amount.IsZero() {
+		return NoVRP, errors.Errorf("error submitting VRP: invalid amount %s", instruction.Amount)
+	}
+	if instruction.InstructionId == "" {
+		instruction.InstructionId = strings.Replace(uuid.New().String(), "-", "", -1)
+	}
+	if instruction.EndToEndId == "" {
+		instruction.EndToEndId = instruction.InstructionId
+	}
+
+	body := VRPRequest{
+		Data: VRPDataRequest{
+			ConsentId:               consent.ConsentId,
+			PSUAuthenticationMethod: "UK.OBIE.SCANotRequired",
+			Initiation:              vrpInitiation(consent.DebtorAccount, consent.CreditorAccount, consent.Reference),
+			Instruction: VRPInstructionRequest{
+				InstructionIdentification: instruction.InstructionId,
+				EndToEndIdentification:    instruction.EndToEndId,
+				InstructedAmount:          AmountResponse{Amount: instruction.Amount.Amount(), Currency: instruction.Amount.Currency()},
+				CreditorAccount:           cashAccountRequest(consent.CreditorAccount),
+			},
+		},
+		Risk: map[string]string{},
+	}
+	if instruction.Reference != "" {
+		body.Data.Instruction.RemittanceInformation = &RemittanceInformationRequest{Reference: instruction.Reference}
+	}
+
+	var response VRPResponse
+	ctx = idempotent(ctx, IdempotencyKey("/domestic-vrps", consent.ConsentId, instruction.InstructionId))
+	ctx = signed(ctx)
+	if err := v.client.PostContext(ctx, "/domestic-vrps", body, &response); err != nil {
+		return NoVRP, errors.Wrap(err, "error submitting VRP")
+	}
+
+	payment, err := mapVRP(response.Data)
+	if err != nil {
+		return NoVRP, errors.Wrap(err, "error submitting VRP")
+	}
+	return payment, nil
+}
+
+func (v *vrpSubmitter) Get(paymentId string) (VRP, error) {
+	return v.GetContext(context.Background(), paymentId)
+}
+
+func (v *vrpSubmitter) GetContext(ctx context.Context, paymentId string) (VRP, error) {
+	var response VRPResponse
+	if err := v.client.GetContext(ctx, "/domestic-vrps/"+url.PathEscape(paymentId), &response); err != nil {
+		return NoVRP, errors.Wrap(err, "error getting VRP")
+	}
+
+	payment, err := mapVRP(response.Data)
+	if err != nil {
+		return NoVRP, errors.Wrap(err, "error getting VRP")
+	}
+	return payment, nil
+}
+
+func controlParametersRequest(parameters VRPControlParameters) (VRPControlParametersRequest, error) {
+	if parameters.MaximumIndividualAmount.IsNegative() || parameters.MaximumIndividualAmount.IsZero() {
+		return VRPControlParametersRequest{}, errors.Errorf("error invalid maximum individual amount %s", parameters.MaximumIndividualAmount)
+	}
+
+	vrpType := parameters.VRPType
+	if vrpType == "" {
+		vrpType = VRPTypeSweeping
+	}
+	request := VRPControlParametersRequest{
+		PSUAuthenticationMethods: []string{"UK.OBIE.SCANotRequired"},
+		VRPType:                  []string{vrpType},
+		MaximumIndividualAmount: AmountResponse{
+			Amount:   parameters.MaximumIndividualAmount.Amount(),
+			Currency: parameters.MaximumIndividualAmount.Currency(),
+		},
+		PeriodicLimits: []PeriodicLimitRequest{},
+	}
+	if !parameters.ValidFromDateTime.IsZero() {
+		request.ValidFromDateTime = parameters.ValidFromDateTime.Format(time.RFC3339)
+	}
+	if !parameters.ValidToDateTime.IsZero() {
+		request.ValidToDateTime = parameters.ValidToDateTime.Format(time.RFC3339)
+	}
+
+	for _, limit := range parameters.PeriodicLimits {
+		if !containsString(periodTypes, limit.PeriodType) {
+			return VRPControlParametersRequest{}, errors.Errorf("error unknown period type %s", limit.PeriodType)
+		}
+		alignment := limit.PeriodAlignment
+		if alignment == "" {
+			alignment = PeriodAlignmentConsent
+		}
+		if alignment != PeriodAlignmentConsent && alignment != PeriodAlignmentCalendar {
+			return VRPControlParametersRequest{}, errors.Errorf("error unknown period alignment %s", alignment)
+		}
+		if limit.Amount.IsNegative() || limit.Amount.IsZero() {
+			return VRPControlParametersRequest{}, errors.Errorf("error invalid periodic limit amount %s", limit.Amount)
+		}
+		request.PeriodicLimits = append(request.PeriodicLimits, PeriodicLimitRequest{
+			Amount:          limit.Amount.Amount(),
+			Currency:        limit.Amount.Currency(),
+			PeriodAlignment: alignment,
+			PeriodType:      limit.PeriodType,
+		})
+	}
+
+	return request, nil
+}
+
+func vrpInitiation(debtor, creditor AccountIdentity, reference string) VRPInitiationRequest {
+	initiation := VRPInitiationRequest{
+		DebtorAccount:   cashAccountRequest(debtor),
+		CreditorAccount: cashAccountRequest(creditor),
+	}
+	if reference != "" {
+		initiation.RemittanceInformation = &RemittanceInformationRequest{Reference: reference}
+	}
+	return initiation
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+type VRPConsentRequestBody struct {
+	Data VRPConsentDataRequest `json:"Data"`
+	Risk map[string]string     `json:"Risk"`
+}
+
+type VRPConsentDataRequest struct {
+	ReadRefundAccount string                      `json:"ReadRefundAccount"`
+	ControlParameters VRPControlParametersRequest `json:"ControlParameters"`
+	Initiation        VRPInitiationRequest        `json:"Initiation"`
+}
+
+type VRPControlParametersRequest struct {
+	PSUAuthenticationMethods []string               `json:"PSUAuthenticationMethods"`
+	VRPType                  []string               `json:"VRPType"`
+	ValidFromDateTime        string                 `json:"ValidFromDateTime,omitempty"`
+	ValidToDateTime          string                 `json:"ValidToDateTime,omitempty"`
+	MaximumIndividualAmount  AmountResponse         `json:"MaximumIndividualAmount"`
+	PeriodicLimits           []PeriodicLimitRequest `json:"PeriodicLimits"`
+}
+
+type PeriodicLimitRequest struct {
+	Amount          string `json:"Amount"`
+	Currency        string `json:"Currency"`
+	PeriodAlignment string `json:"PeriodAlignment"`
+	PeriodType      string `json:"PeriodType"`
+}
+
+type VRPInitiationRequest struct {
+	DebtorAccount         *CashAccountResponse          `json:"DebtorAccount,omitempty"`
+	CreditorAccount       *CashAccountResponse          `json:"CreditorAccount"`
+	RemittanceInformation *RemittanceInformationRequest `json:"RemittanceInformation,omitempty"`
+}
+
+type VRPConsentResponse struct {
+	Data VRPConsentDataResponse `json:"Data"`
+}
+
+type VRPConsentDataResponse struct {
+	ConsentId            string                      `json:"ConsentId"`
+	Status               string                      `json:"Status"`
+	CreationDateTime     string                      `json:"CreationDateTime"`
+	StatusUpdateDateTime string                      `json:"StatusUpdateDateTime"`
+	ControlParameters    VRPControlParametersRequest `json:"ControlParameters"`
+	Initiation           VRPInitiationRequest        `json:"Initiation"`
+}
+
+type VRPFundsConfirmationResponse struct {
+	Data VRPFundsConfirmationDataResponse `json:"Data"`
+}
+
+type VRPFundsConfirmationDataResponse struct {
+	FundsConfirmationId  string         `json:"FundsConfirmationId"`
+	ConsentId            string         `json:"ConsentId"`
+	CreationDateTime     string         `json:"CreationDateTime"`
+	Reference            string         `json:"Reference"`
+	InstructedAmount     AmountResponse `json:"InstructedAmount"`
+	FundsAvailableResult struct {
+		FundsAvailableDateTime string `json:"FundsAvailableDateTime"`
+		FundsAvailable         string `json:"FundsAvailable"`
+	} `json:"FundsAvailableResult"`
+}
+
+type VRPRequest struct {
+	Data VRPDataRequest    `json:"Data"`
+	Risk map[string]string `json:"Risk"`
+}
+
+type VRPDataRequest struct {
+	ConsentId               string                `json:"ConsentId"`
+	PSUAuthenticationMethod string                `json:"PSUAuthenticationMethod"`
+	Initiation              VRPInitiationRequest  `json:"Initiation"`
+	Instruction             VRPInstructionRequest `json:"Instruction"`
+}
+
+type VRPInstructionRequest struct {
+	InstructionIdentification string                        `json:"InstructionIdentification"`
+	EndToEndIdentification    string                        `json:"EndToEndIdentification"`
+	RemittanceInformation     *RemittanceInformationRequest `json:"RemittanceInformation,omitempty"`
+	InstructedAmount          AmountResponse                `json:"InstructedAmount"`
+	CreditorAccount           *CashAccountResponse          `json:"CreditorAccount"`
+}
+
+type VRPResponse struct {
+	Data VRPDataResponse `json:"Data"`
+}
+
+type VRPDataResponse struct {
+	DomesticVRPId        string                `json:"DomesticVRPId"`
+	ConsentId            string                `json:"ConsentId"`
+	Status               string                `json:"Status"`
+	CreationDateTime     string                `json:"CreationDateTime"`
+	StatusUpdateDateTime string                `json:"StatusUpdateDateTime"`
+	Instruction          VRPInstructionRequest `json:"Instruction"`
+}
+
+func mapVRPConsent(consent VRPConsentDataResponse) (VRPConsent, error) {
+	maximum, err := optionalMoney(consent.ControlParameters.MaximumIndividualAmount)
+	if err != nil {
+		return NoVRPConsent, err
+	}
+
+	parameters := VRPControlParameters{
+		ValidFromDateTime:       parseDateTime(consent.ControlParameters.ValidFromDateTime),
+		ValidToDateTime:         parseDateTime(consent.ControlParameters.ValidToDateTime),
+		MaximumIndividualAmount: maximum,
+	}
+	if len(consent.ControlParameters.VRPType) > 0 {
+		parameters.VRPType = consent.ControlParameters.VRPType[0]
+	}
+	for _, limit := range consent.ControlParameters.PeriodicLimits {
+		amount, err := ParseMoney(limit.Amount, limit.Currency)
+		if err != nil {
+			return NoVRPConsent, err
+		}
+		parameters.PeriodicLimits = append(parameters.PeriodicLimits, PeriodicLimit{
+			PeriodType:      limit.PeriodType,
+			PeriodAlignment: limit.PeriodAlignment,
+			Amount:          amount,
+		})
+	}
+
+	reference := ""
+	if consent.Initiation.RemittanceInformation != nil {
+		reference = consent.Initiation.RemittanceInformation.Reference
+	}
+
+	return VRPConsent{
+		ConsentId:            consent.ConsentId,
+		Status:               consent.Status,
+		CreationDateTime:     parseDateTime(consent.CreationDateTime),
+		StatusUpdateDateTime: parseDateTime(consent.StatusUpdateDateTime),
+		DebtorAccount:        mapAccountIdentity(consent.Initiation.DebtorAccount, nil),
+		CreditorAccount:      mapAccountIdentity(consent.Initiation.CreditorAccount, nil),
+		Reference:            reference,
+		ControlParameters:    parameters,
+	}, nil
+}
+
+func mapVRP(payment VRPDataResponse) (VRP, error) {
+	amount, err := optionalMoney(payment.Instruction.InstructedAmount)
+	if err != nil {
+		return NoVRP, err
+	}
+
+	return VRP{
+		Id:                   payment.DomesticVRPId,
+		ConsentId:            payment.ConsentId,
+		Status:               payment.Status,
+		CreationDateTime:     parseDateTime(payment.CreationDateTime),
+		StatusUpdateDateTime: parseDateTime(payment.StatusUpdateDateTime),
+		Amount:               amount,
+	}, nil
+}
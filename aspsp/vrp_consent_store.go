@@ -0,0 +1,94 @@
+package aspsp
+
+import (
+	"encoding/json"
+	"github.com/jmatosp/obclient/authorization"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"time"
+)
+
+// AuthorisedVRPConsent is a VRP consent kept under a name together with the token the
+// PSU authorised it with, TokenExpires tells when to refresh the token with its refresh token
+type AuthorisedVRPConsent struct {
+	Name      string
+	ConsentId string
+	Token     authorization.Token
+	// TokenExpires is zero when the ASPSP gave no expires_in, the token then does not expire as for StoredToken
+	TokenExpires time.Time
+	// PendingInstruction is the payment submitted last until the ASPSP answers, resubmitting
+	// it sends the same x-idempotency-key so it is not paid twice
+	PendingInstruction VRPInstruction
+}
+
+var NoAuthorisedVRPConsent = AuthorisedVRPConsent{}
+
+// TokenExpiring tells whether the token expires before now plus margin, a token without expiry never does
+func (c AuthorisedVRPConsent) TokenExpiring(now time.Time, margin time.Duration) bool {
+	return !c.TokenExpires.IsZero() && !now.Add(margin).Before(c.TokenExpires)
+}
+
+// VRPConsentStore keeps authorised VRP consents by name
+type VRPConsentStore interface {
+	Store(AuthorisedVRPConsent) error
+	Get(string) (AuthorisedVRPConsent, error)
+	Delete(string) error
+}
+
+type fileVRPConsentStore struct {
+	folder string
+}
+
+func NewFileVRPConsentStore(folder string) VRPConsentStore {
+	return fileVRPConsentStore{
+		folder: folder,
+	}
+}
+
+func (s fileVRPConsentStore) Store(consent AuthorisedVRPConsent) error {
+	consentJson, err := json.Marshal(consent)
+	if err != nil {
+		return errors.Wrap(err, "error storing VRP consent")
+	}
+
+	err = ioutil.WriteFile(s.filename(consent.Name), consentJson, 0600)
+	if err != nil {
+		return errors.Wrap(err, "error storing VRP consent")
+	}
+
+	return nil
+}
+
+func (s fileVRPConsentStore) Get(name string) (AuthorisedVRPConsent, error) {
+	consentJson, err := ioutil.ReadFile(s.filename(name))
+	if os.IsNotExist(err) {
+		return NoAuthorisedVRPConsent, ErrNotFound
+	} else if err != nil {
+		return NoAuthorisedVRPConsent, errors.Wrap(err, "error getting VRP consent")
+	}
+
+	var consent AuthorisedVRPConsent
+	if err = json.Unmarshal(consentJson, &consent); err != nil {
+		return NoAuthorisedVRPConsent, errors.Wrap(err, "error getting VRP consent")
+	}
+
+	return consent, nil
+}
+
+func (s fileVRPConsentStore) Delete(name string) error {
+	err := os.Remove(s.filename(name))
+	if os.IsNotExist(err) {
+		return ErrNotFound
+	} else if err != nil {
+		return errors.Wrap(err, "error deleting VRP consent")
+	}
+
+	return nil
+}
+
+func (s fileVRPConsentStore) filename(name string) string {
+	return path.Join(s.folder, "vrp-"+url.PathEscape(name)+".json")
+}
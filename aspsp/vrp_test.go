@@ -0,0 +1,114 @@
+package aspsp
+
+import (
+	"context"
+	"github.com/jmatosp/obclient/authorization"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// plainTransport calls test servers without TLS
+type plainTransport struct{}
+
+func (plainTransport) Client() (*http.Client, error) {
+	return http.DefaultClient, nil
+}
+
+// keyRecorder answers every request with body, recording the x-idempotency-key of each
+type keyRecorder struct {
+	mutex sync.Mutex
+	keys  []string
+}
+
+func (k *keyRecorder) server(t *testing.T, body string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		k.mutex.Lock()
+		k.keys = append(k.keys, r.Header.Get("x-idempotency-key"))
+		k.mutex.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func testResourceClient(t *testing.T, server *httptest.Server) ResourceClient {
+	t.Helper()
+
+	client, err := NewResourceClient(plainTransport{}, server.URL+"/open-banking/v3.1/pisp", NewStaticTokenSource(authorization.Token{AccessToken: "token"}), authorization.FapiHeaders{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestVRPConsentIdempotencyKey(t *testing.T) {
+	request := func(reference string) VRPConsentRequest {
+		return VRPConsentRequest{
+			CreditorAccount: NewAccountIdentity("UK.OBIE.SortCodeAccountNumber", "80200110203348", "Savings", "", ""),
+			Reference:       reference,
+			ControlParameters: VRPControlParameters{
+				MaximumIndividualAmount: NewMoney(10000, "GBP"),
+				PeriodicLimits:          []PeriodicLimit{{PeriodType: "Month", Amount: NewMoney(50000, "GBP")}},
+			},
+		}
+	}
+
+	recorder := &keyRecorder{}
+	consenter := NewVRPConsenter(testResourceClient(t, recorder.server(t, `{"Data":{"ConsentId":"vrp-1","Status":"AwaitingAuthorisation"}}`)))
+	creations := []struct {
+		ctx     context.Context
+		request VRPConsentRequest
+	}{
+		{ctx: context.Background(), request: request("sweeping")},
+		{ctx: context.Background(), request: request("sweeping")},
+		{ctx: context.Background(), request: request("savings")},
+		{ctx: WithIdempotencyKey(context.Background(), "caller-key"), request: request("sweeping")},
+	}
+	for _, creation := range creations {
+		if _, err := consenter.CreateContext(creation.ctx, creation.request); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	keys := recorder.keys
+	if len(keys) != len(creations) {
+		t.Fatalf("got %d requests, want %d", len(keys), len(creations))
+	}
+	if keys[0] == "" || keys[0] != keys[1] {
+		t.Errorf("same consent sent keys %q and %q, want the same key", keys[0], keys[1])
+	}
+	if keys[2] == keys[0] {
+		t.Errorf("other reference sent the same key %q", keys[2])
+	}
+	if want := IdempotencyKey("caller-key", "/domestic-vrp-consents"); keys[3] != want {
+		t.Errorf("got key %q, want %q of the caller key", keys[3], want)
+	}
+}
+
+func TestVRPTokenExpiring(t *testing.T) {
+	now := time.Date(2019, 5, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		expires time.Time
+		want    bool
+	}{
+		{name: "no expiry", want: false},
+		{name: "expires later", expires: now.Add(time.Hour), want: false},
+		{name: "expires within the margin", expires: now.Add(30 * time.Second), want: true},
+		{name: "expires at the margin", expires: now.Add(time.Minute), want: true},
+		{name: "expired", expires: now.Add(-time.Hour), want: true},
+	}
+	for _, test := range tests {
+		consent := AuthorisedVRPConsent{TokenExpires: test.expires}
+		if got := consent.TokenExpiring(now, time.Minute); got != test.want {
+			t.Errorf("%s: TokenExpiring() = %t, want %t", test.name, got, test.want)
+		}
+	}
+}
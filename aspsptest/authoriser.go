@@ -0,0 +1,17 @@
+package aspsptest
+
+import (
+	"github.com/jmatosp/obclient/authorization"
+	"net/http"
+)
+
+// NewAuthoriser approves consents against the mock authorization endpoint without
+// a browser, client must trust the mock bank CA, see authorization.NewHeadlessAuthoriser
+func NewAuthoriser(client *http.Client) authorization.Authoriser {
+	return authorization.NewHeadlessAuthoriser(client)
+}
+
+// Authoriser returns an authoriser for this server
+func (s *Server) Authoriser() authorization.Authoriser {
+	return NewAuthoriser(s.Client())
+}
@@ -0,0 +1,175 @@
+package aspsptest
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/jmatosp/obclient/aspsp"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type cashAccount struct {
+	SchemeName              string `json:"SchemeName"`
+	Identification          string `json:"Identification"`
+	Name                    string `json:"Name,omitempty"`
+	SecondaryIdentification string `json:"SecondaryIdentification,omitempty"`
+}
+
+type amount struct {
+	Amount   string `json:"Amount"`
+	Currency string `json:"Currency"`
+}
+
+func (s *Server) cbpii(w http.ResponseWriter, r *http.Request, resource string) {
+	switch {
+	case strings.HasPrefix(resource, "/funds-confirmation-consents"):
+		s.fundsConfirmationConsents(w, r, strings.TrimPrefix(resource, "/funds-confirmation-consents"))
+	case resource == "/funds-confirmations":
+		s.fundsConfirmations(w, r)
+	default:
+		writeOBError(w, http.StatusNotFound, "UK.OBIE.Resource.NotFound", "resource not found")
+	}
+}
+
+func (s *Server) fundsConfirmationConsents(w http.ResponseWriter, r *http.Request, resource string) {
+	g, ok := s.clientCredentials(w, r)
+	if !ok {
+		return
+	}
+
+	if resource == "" && r.Method == http.MethodPost {
+		var request struct {
+			Data struct {
+				ExpirationDateTime string       `json:"ExpirationDateTime"`
+				DebtorAccount      *cashAccount `json:"DebtorAccount"`
+			} `json:"Data"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Data.DebtorAccount == nil || request.Data.DebtorAccount.Identification == "" {
+			writeOBError(w, http.StatusBadRequest, "UK.OBIE.Field.Missing", "Data.DebtorAccount required")
+			return
+		}
+
+		data := map[string]interface{}{"DebtorAccount": request.Data.DebtorAccount}
+		if request.Data.ExpirationDateTime != "" {
+			data["ExpirationDateTime"] = request.Data.ExpirationDateTime
+		}
+		intent := s.createConsent(g, "fcc-", "fundsconfirmations", CBPIIPath+"/funds-confirmation-consents", data)
+		writeJSON(w, http.StatusCreated, consentDocument(r, intent))
+		return
+	}
+
+	s.consentResource(w, r, g, CBPIIPath+"/funds-confirmation-consents", strings.TrimPrefix(resource, "/"))
+}
+
+// fundsConfirmations answers from the available balance of the fixture account with the consent debtor account
+func (s *Server) fundsConfirmations(w http.ResponseWriter, r *http.Request) {
+	g, ok := s.bearer(w, r)
+	if !ok {
+		return
+	}
+	if !s.authorised(w, g, "fundsconfirmations") {
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Data struct {
+			ConsentId        string `json:"ConsentId"`
+			Reference        string `json:"Reference"`
+			InstructedAmount amount `json:"InstructedAmount"`
+		} `json:"Data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeOBError(w, http.StatusBadRequest, "UK.OBIE.Field.Invalid", err.Error())
+		return
+	}
+	if request.Data.ConsentId != g.consentId {
+		writeOBError(w, http.StatusBadRequest, "UK.OBIE.Field.Invalid", "Data.ConsentId does not match the access token consent")
+		return
+	}
+	instructed, err := aspsp.ParseMoney(request.Data.InstructedAmount.Amount, request.Data.InstructedAmount.Currency)
+	if err != nil {
+		writeOBError(w, http.StatusBadRequest, "UK.OBIE.Field.Invalid", err.Error())
+		return
+	}
+
+	s.mutex.Lock()
+	debtor, _ := s.consents[g.consentId].data["DebtorAccount"].(*cashAccount)
+	s.mutex.Unlock()
+
+	available := false
+	if debtor != nil {
+		if balance, found := s.availableBalance(debtor.Identification); found {
+			cmp, err := instructed.Cmp(balance)
+			available = err == nil && cmp <= 0
+		}
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"Data": map[string]interface{}{
+			"FundsConfirmationId": "fc-" + uuid.New().String(),
+			"ConsentId":           request.Data.ConsentId,
+			"CreationDateTime":    time.Now().UTC().Format(time.RFC3339),
+			"FundsAvailable":      available,
+			"Reference":           request.Data.Reference,
+			"InstructedAmount":    request.Data.InstructedAmount,
+		},
+		"Links": map[string]string{"Self": baseURL(r) + CBPIIPath + "/funds-confirmations"},
+		"Meta":  map[string]interface{}{},
+	})
+}
+
+// availableBalance finds the fixture account by identification and returns its
+// InterimAvailable balance, or the first one listed
+func (s *Server) availableBalance(identification string) (aspsp.Money, bool) {
+	var accounts struct {
+		Data struct {
+			Account []struct {
+				AccountId string        `json:"AccountId"`
+				Account   []cashAccount `json:"Account"`
+			} `json:"Account"`
+		} `json:"Data"`
+	}
+	if err := json.Unmarshal(s.Fixtures["/accounts"], &accounts); err != nil {
+		return aspsp.NoMoney, false
+	}
+
+	for _, account := range accounts.Data.Account {
+		for _, identity := range account.Account {
+			if identity.Identification != identification {
+				continue
+			}
+			var balances struct {
+				Data struct {
+					Balance []struct {
+						Type                 string `json:"Type"`
+						CreditDebitIndicator string `json:"CreditDebitIndicator"`
+						Amount               amount `json:"Amount"`
+					} `json:"Balance"`
+				} `json:"Data"`
+			}
+			if err := json.Unmarshal(s.Fixtures["/accounts/"+account.AccountId+"/balances"], &balances); err != nil || len(balances.Data.Balance) == 0 {
+				return aspsp.NoMoney, false
+			}
+			balance := balances.Data.Balance[0]
+			for _, candidate := range balances.Data.Balance {
+				if candidate.Type == "InterimAvailable" {
+					balance = candidate
+				}
+			}
+			money, err := aspsp.ParseMoney(balance.Amount.Amount, balance.Amount.Currency)
+			if err != nil {
+				return aspsp.NoMoney, false
+			}
+			if balance.CreditDebitIndicator == "Debit" {
+				money = money.Neg()
+			}
+			return money, true
+		}
+	}
+	return aspsp.NoMoney, false
+}
@@ -0,0 +1,163 @@
+package aspsptest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"path"
+	"time"
+)
+
+// Certificates is a generated test PKI: a CA issuing the mock bank server and
+// client transport certificates, plus client and bank signing keys
+type Certificates struct {
+	CA            *x509.Certificate
+	CAPEM         []byte
+	Server        tls.Certificate
+	ClientCertPEM []byte
+	ClientKeyPEM  []byte
+	SigningKey    *rsa.PrivateKey
+	BankKey       *rsa.PrivateKey
+}
+
+// Files are the paths of certificates written by WriteFiles
+type Files struct {
+	RootCA            string
+	CertFile          string
+	KeyFile           string
+	SigPublicKeyFile  string
+	SigPrivateKeyFile string
+}
+
+func NewCertificates() (*Certificates, error) {
+	caKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, errors.Wrap(err, "error generating ca key")
+	}
+
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Mock Bank Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating ca certificate")
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating ca certificate")
+	}
+
+	serverCertPEM, serverKeyPEM, err := issue(ca, caKey, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("::1")},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating server certificate")
+	}
+	server, err := tls.X509KeyPair(serverCertPEM, serverKeyPEM)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating server certificate")
+	}
+
+	clientCertPEM, clientKeyPEM, err := issue(ca, caKey, &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "obcli", Organization: []string{"Mock TPP"}},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating client certificate")
+	}
+
+	signingKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, errors.Wrap(err, "error generating signing key")
+	}
+
+	bankKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, errors.Wrap(err, "error generating bank signing key")
+	}
+
+	return &Certificates{
+		CA:            ca,
+		CAPEM:         pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}),
+		Server:        server,
+		ClientCertPEM: clientCertPEM,
+		ClientKeyPEM:  clientKeyPEM,
+		SigningKey:    signingKey,
+		BankKey:       bankKey,
+	}, nil
+}
+
+// WriteFiles writes the client side certificates and keys to dir
+func (c *Certificates) WriteFiles(dir string) (Files, error) {
+	publicKey, err := x509.MarshalPKIXPublicKey(&c.SigningKey.PublicKey)
+	if err != nil {
+		return Files{}, errors.Wrap(err, "error writing certificates")
+	}
+
+	files := Files{
+		RootCA:            path.Join(dir, "ca.crt"),
+		CertFile:          path.Join(dir, "transport.pem"),
+		KeyFile:           path.Join(dir, "transport.key"),
+		SigPublicKeyFile:  path.Join(dir, "sign.pem"),
+		SigPrivateKeyFile: path.Join(dir, "sign.key"),
+	}
+
+	contents := map[string][]byte{
+		files.RootCA:            c.CAPEM,
+		files.CertFile:          c.ClientCertPEM,
+		files.KeyFile:           c.ClientKeyPEM,
+		files.SigPublicKeyFile:  pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey}),
+		files.SigPrivateKeyFile: pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(c.SigningKey)}),
+	}
+	for filename, content := range contents {
+		if err := ioutil.WriteFile(filename, content, 0600); err != nil {
+			return Files{}, errors.Wrap(err, "error writing certificates")
+		}
+	}
+
+	return files, nil
+}
+
+func (c *Certificates) caPool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(c.CA)
+	return pool
+}
+
+func issue(ca *x509.Certificate, caKey *rsa.PrivateKey, template *x509.Certificate) ([]byte, []byte, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().AddDate(1, 0, 0)
+	template.KeyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return certPEM, keyPEM, nil
+}
@@ -0,0 +1,260 @@
+package aspsptest
+
+import (
+	"encoding/json"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/google/uuid"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	eventResourceUpdate              = "urn:uk:org:openbanking:events:resource-update"
+	eventConsentAuthorizationRevoked = "urn:uk:org:openbanking:events:consent-authorization-revoked"
+	// defaultMaxEvents are returned by a polling request without maxEvents
+	defaultMaxEvents = 10
+)
+
+type callbackURL struct {
+	id       string
+	clientId string
+	url      string
+	version  string
+}
+
+// notification is a signed event notification waiting to be polled by its client
+type notification struct {
+	id       string
+	clientId string
+	set      string
+}
+
+func (s *Server) events(w http.ResponseWriter, r *http.Request, resource string) {
+	g, ok := s.clientCredentials(w, r)
+	if !ok {
+		return
+	}
+
+	switch {
+	case resource == "/events" && r.Method == http.MethodPost:
+		s.pollEvents(w, r, g)
+	case resource == "/callback-urls":
+		s.callbackURLs(w, r, g)
+	case strings.HasPrefix(resource, "/callback-urls/") && r.Method == http.MethodDelete:
+		id := strings.TrimPrefix(resource, "/callback-urls/")
+		s.mutex.Lock()
+		callback, found := s.callbacks[id]
+		if found && callback.clientId == g.clientId {
+			delete(s.callbacks, id)
+		}
+		s.mutex.Unlock()
+		if !found || callback.clientId != g.clientId {
+			writeOBError(w, http.StatusNotFound, "UK.OBIE.Resource.NotFound", "callback url not found")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// callbackURLs lists and registers callback urls, a client can only have one
+func (s *Server) callbackURLs(w http.ResponseWriter, r *http.Request, g grant) {
+	switch r.Method {
+	case http.MethodGet:
+		s.mutex.Lock()
+		data := []map[string]string{}
+		for _, callback := range s.callbacks {
+			if callback.clientId == g.clientId {
+				data = append(data, callbackDocument(callback))
+			}
+		}
+		s.mutex.Unlock()
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"Data":  map[string]interface{}{"CallbackUrl": data},
+			"Links": map[string]string{"Self": baseURL(r) + EventsPath + "/callback-urls"},
+			"Meta":  map[string]interface{}{},
+		})
+
+	case http.MethodPost:
+		var request struct {
+			Data struct {
+				Url     string `json:"Url"`
+				Version string `json:"Version"`
+			} `json:"Data"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Data.Url == "" || request.Data.Version == "" {
+			writeOBError(w, http.StatusBadRequest, "UK.OBIE.Field.Missing", "Data.Url and Data.Version required")
+			return
+		}
+		if parsed, err := url.Parse(request.Data.Url); err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") {
+			writeOBError(w, http.StatusBadRequest, "UK.OBIE.Field.Invalid", "Data.Url must be an absolute url")
+			return
+		}
+
+		callback := &callbackURL{
+			id:       "cbu-" + uuid.New().String(),
+			clientId: g.clientId,
+			url:      strings.TrimSuffix(request.Data.Url, "/"),
+			version:  request.Data.Version,
+		}
+		s.mutex.Lock()
+		registered := false
+		for _, existing := range s.callbacks {
+			registered = registered || existing.clientId == g.clientId
+		}
+		if !registered {
+			s.callbacks[callback.id] = callback
+		}
+		s.mutex.Unlock()
+		if registered {
+			writeOBError(w, http.StatusBadRequest, "UK.OBIE.Resource.InvalidFormat", "a callback url is already registered, delete it first")
+			return
+		}
+		writeJSON(w, http.StatusCreated, map[string]interface{}{
+			"Data":  callbackDocument(callback),
+			"Links": map[string]string{"Self": baseURL(r) + EventsPath + "/callback-urls/" + callback.id},
+			"Meta":  map[string]interface{}{},
+		})
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func callbackDocument(callback *callbackURL) map[string]string {
+	return map[string]string{
+		"CallbackUrlId": callback.id,
+		"Url":           callback.url,
+		"Version":       callback.version,
+	}
+}
+
+// pollEvents removes acknowledged and rejected events then returns the oldest waiting ones
+func (s *Server) pollEvents(w http.ResponseWriter, r *http.Request, g grant) {
+	var request struct {
+		MaxEvents *int                   `json:"maxEvents"`
+		Ack       []string               `json:"ack"`
+		SetErrs   map[string]interface{} `json:"setErrs"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeOBError(w, http.StatusBadRequest, "UK.OBIE.Field.Invalid", err.Error())
+		return
+	}
+	maxEvents := defaultMaxEvents
+	if request.MaxEvents != nil {
+		maxEvents = *request.MaxEvents
+	}
+
+	done := map[string]bool{}
+	for _, id := range request.Ack {
+		done[id] = true
+	}
+	for id := range request.SetErrs {
+		done[id] = true
+	}
+
+	sets := map[string]string{}
+	more := false
+	s.mutex.Lock()
+	var waiting []notification
+	for _, pending := range s.notifications {
+		if pending.clientId == g.clientId && done[pending.id] {
+			continue
+		}
+		waiting = append(waiting, pending)
+		if pending.clientId != g.clientId {
+			continue
+		}
+		if len(sets) < maxEvents {
+			sets[pending.id] = pending.set
+		} else {
+			more = true
+		}
+	}
+	s.notifications = waiting
+	s.mutex.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"sets":          sets,
+		"moreAvailable": more,
+	})
+}
+
+// notify signs an event about the resource at path and pushes it to the client callback
+// url, it waits to be polled when there is none or the push is not accepted
+func (s *Server) notify(r *http.Request, clientId, eventType, path, resourceId string, payload map[string]interface{}) {
+	link := baseURL(r) + path + "/" + resourceId
+	now := time.Now()
+	subject := map[string]interface{}{
+		"subject_type":                  "http://openbanking.org.uk/rid_http://openbanking.org.uk/rty",
+		"http://openbanking.org.uk/rid": resourceId,
+		"http://openbanking.org.uk/rty": resourceType(path),
+		"http://openbanking.org.uk/rlk": []map[string]string{{"version": "v3.1", "link": link}},
+	}
+	if payload == nil {
+		payload = map[string]interface{}{}
+	}
+	payload["subject"] = subject
+
+	id := uuid.New().String()
+	token := jwt.NewWithClaims(jwt.SigningMethodPS256, jwt.MapClaims{
+		"iss":    baseURL(r),
+		"iat":    now.Unix(),
+		"jti":    id,
+		"sub":    link,
+		"aud":    clientId,
+		"txn":    r.Header.Get("x-fapi-interaction-id"),
+		"toe":    now.Unix(),
+		"events": map[string]interface{}{eventType: payload},
+	})
+	token.Header["kid"] = bankKeyId
+	set, err := token.SignedString(s.Certificates.BankKey)
+	if err != nil {
+		return
+	}
+
+	s.mutex.Lock()
+	s.notifications = append(s.notifications, notification{id: id, clientId: clientId, set: set})
+	var target string
+	for _, callback := range s.callbacks {
+		if callback.clientId == clientId {
+			target = callback.url + "/event-notifications"
+		}
+	}
+	s.mutex.Unlock()
+
+	if target != "" {
+		go s.push(target, id, set)
+	}
+}
+
+// push posts the event to the callback url, it is no longer polled once accepted
+func (s *Server) push(target, id, set string) {
+	client := http.Client{Timeout: 10 * time.Second}
+	response, err := client.Post(target, "application/jwt", strings.NewReader(set))
+	if err != nil {
+		return
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusAccepted {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for i, pending := range s.notifications {
+		if pending.id == id {
+			s.notifications = append(s.notifications[:i], s.notifications[i+1:]...)
+			return
+		}
+	}
+}
+
+// resourceType is the OB resource type of the resources at path, e.g. domestic-payment
+func resourceType(path string) string {
+	segments := strings.Split(path, "/")
+	return strings.TrimSuffix(segments[len(segments)-1], "s")
+}
@@ -0,0 +1,208 @@
+package aspsptest
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/jmatosp/obclient/aspsp"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const fileTypePaymentInitiation = "UK.OBIE.PaymentInitiation.3.1"
+
+var filePaymentKind = paymentKind{"/file-payment-consents", "/file-payments", "FilePaymentId", "InitiationCompleted", false}
+
+type fileInitiation struct {
+	FileType             string      `json:"FileType"`
+	FileHash             string      `json:"FileHash"`
+	NumberOfTransactions string      `json:"NumberOfTransactions"`
+	ControlSum           json.Number `json:"ControlSum"`
+}
+
+// paymentFile is the UK.OBIE.PaymentInitiation.3.1 file format
+type paymentFile struct {
+	Data struct {
+		DomesticPayments []map[string]interface{} `json:"DomesticPayments"`
+	} `json:"Data"`
+}
+
+func (s *Server) filePayments(w http.ResponseWriter, r *http.Request, resource string) {
+	switch {
+	case strings.HasPrefix(resource, filePaymentKind.consents):
+		s.filePaymentConsents(w, r, strings.TrimPrefix(resource, filePaymentKind.consents))
+	case strings.HasPrefix(resource, filePaymentKind.payments+"/") && strings.HasSuffix(resource, "/report-file"):
+		s.filePaymentReport(w, r, strings.TrimSuffix(strings.TrimPrefix(resource, filePaymentKind.payments+"/"), "/report-file"))
+	default:
+		s.paymentOrders(w, r, filePaymentKind, strings.TrimPrefix(resource, filePaymentKind.payments))
+	}
+}
+
+func (s *Server) filePaymentConsents(w http.ResponseWriter, r *http.Request, resource string) {
+	g, ok := s.clientCredentials(w, r)
+	if !ok {
+		return
+	}
+
+	if strings.HasSuffix(resource, "/file") {
+		s.uploadFile(w, r, g, strings.TrimSuffix(strings.TrimPrefix(resource, "/"), "/file"))
+		return
+	}
+	if resource != "" {
+		if r.Method == http.MethodDelete {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s.consentResource(w, r, g, PISPPath+filePaymentKind.consents, strings.TrimPrefix(resource, "/"))
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.replayConsent(w, r, g) {
+		return
+	}
+
+	var request struct {
+		Data struct {
+			Initiation json.RawMessage `json:"Initiation"`
+		} `json:"Data"`
+	}
+	var initiation fileInitiation
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || json.Unmarshal(request.Data.Initiation, &initiation) != nil {
+		writeOBError(w, http.StatusBadRequest, "UK.OBIE.Field.Invalid", "Data.Initiation required")
+		return
+	}
+	if initiation.FileType != fileTypePaymentInitiation {
+		writeOBError(w, http.StatusBadRequest, "UK.OBIE.Unsupported.FileType", "only "+fileTypePaymentInitiation+" files are supported")
+		return
+	}
+	if initiation.FileHash == "" {
+		writeOBError(w, http.StatusBadRequest, "UK.OBIE.Field.Missing", "Data.Initiation.FileHash required")
+		return
+	}
+
+	intent := s.createConsent(g, "fpc-", "payments", PISPPath+filePaymentKind.consents, map[string]interface{}{
+		"Initiation": request.Data.Initiation,
+	})
+	s.keepIdempotent(r, g, intent.id)
+	s.mutex.Lock()
+	intent.status = "AwaitingUpload"
+	document := consentDocument(r, intent)
+	s.mutex.Unlock()
+	writeJSON(w, http.StatusCreated, document)
+}
+
+// uploadFile checks the file against the hash, number of transactions and control sum of
+// the consent, the consent then awaits authorisation
+func (s *Server) uploadFile(w http.ResponseWriter, r *http.Request, g grant, id string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.mutex.Lock()
+	intent, found := s.consents[id]
+	s.mutex.Unlock()
+	if !found || intent.clientId != g.clientId || intent.path != PISPPath+filePaymentKind.consents {
+		writeOBError(w, http.StatusNotFound, "UK.OBIE.Resource.NotFound", "consent not found")
+		return
+	}
+
+	s.mutex.Lock()
+	status := intent.status
+	raw, _ := intent.data["Initiation"].(json.RawMessage)
+	s.mutex.Unlock()
+	if status != "AwaitingUpload" {
+		writeOBError(w, http.StatusBadRequest, "UK.OBIE.Resource.InvalidConsentStatus", "consent is not awaiting upload")
+		return
+	}
+
+	content, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeOBError(w, http.StatusBadRequest, "UK.OBIE.Field.Invalid", err.Error())
+		return
+	}
+	var initiation fileInitiation
+	json.Unmarshal(raw, &initiation)
+	if message := checkFile(initiation, content); message != "" {
+		writeOBError(w, http.StatusBadRequest, "UK.OBIE.Unsupported.File", message)
+		return
+	}
+
+	s.mutex.Lock()
+	intent.status = "AwaitingAuthorisation"
+	s.files[id] = content
+	s.mutex.Unlock()
+	w.WriteHeader(http.StatusOK)
+}
+
+// checkFile returns why the file does not match the consent initiation, empty if it does
+func checkFile(initiation fileInitiation, content []byte) string {
+	hash := sha256.Sum256(content)
+	if base64.StdEncoding.EncodeToString(hash[:]) != initiation.FileHash {
+		return "file hash does not match the consent FileHash"
+	}
+
+	var file paymentFile
+	if err := json.Unmarshal(content, &file); err != nil {
+		return "invalid payment file: " + err.Error()
+	}
+	if strconv.Itoa(len(file.Data.DomesticPayments)) != initiation.NumberOfTransactions {
+		return "number of payments does not match the consent NumberOfTransactions"
+	}
+
+	var sum int64
+	currency := "GBP"
+	for _, payment := range file.Data.DomesticPayments {
+		instructed, _ := payment["InstructedAmount"].(map[string]interface{})
+		value, _ := instructed["Amount"].(string)
+		currency, _ = instructed["Currency"].(string)
+		money, err := aspsp.ParseMoney(value, currency)
+		if err != nil {
+			return "invalid payment amount: " + err.Error()
+		}
+		sum += money.MinorUnits()
+	}
+	controlSum, err := aspsp.ParseMoney(initiation.ControlSum.String(), currency)
+	if err != nil || controlSum.MinorUnits() != sum {
+		return "sum of payment amounts does not match the consent ControlSum"
+	}
+	return ""
+}
+
+// filePaymentReport reports every payment of the file as completed
+func (s *Server) filePaymentReport(w http.ResponseWriter, r *http.Request, id string) {
+	g, ok := s.bearer(w, r)
+	if !ok {
+		return
+	}
+
+	s.mutex.Lock()
+	found, ok := s.payments[id]
+	var content []byte
+	if ok {
+		consentId, _ := found.data["ConsentId"].(string)
+		content = s.files[consentId]
+	}
+	s.mutex.Unlock()
+	if !ok || found.clientId != g.clientId || found.kind != filePaymentKind {
+		writeOBError(w, http.StatusNotFound, "UK.OBIE.Resource.NotFound", "payment not found")
+		return
+	}
+
+	var file paymentFile
+	json.Unmarshal(content, &file)
+	for _, payment := range file.Data.DomesticPayments {
+		payment["Status"] = "AcceptedSettlementCompleted"
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"Data": map[string]interface{}{
+			"FilePaymentId":    id,
+			"DomesticPayments": file.Data.DomesticPayments,
+		},
+	})
+}
@@ -0,0 +1,38 @@
+package aspsptest
+
+import (
+	_ "embed"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"io/ioutil"
+)
+
+//go:embed fixtures.json
+var defaultFixtures []byte
+
+// Fixtures are AIS response documents keyed by resource path relative
+// to the AIS base, e.g. /accounts/22289/balances
+type Fixtures map[string]json.RawMessage
+
+// DefaultFixtures returns two sample accounts with every AIS resource populated
+func DefaultFixtures() Fixtures {
+	var fixtures Fixtures
+	if err := json.Unmarshal(defaultFixtures, &fixtures); err != nil {
+		panic(err)
+	}
+	return fixtures
+}
+
+func LoadFixtures(filename string) (Fixtures, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, errors.Wrap(err, "error loading fixtures")
+	}
+
+	var fixtures Fixtures
+	if err = json.Unmarshal(content, &fixtures); err != nil {
+		return nil, errors.Wrap(err, "error loading fixtures")
+	}
+
+	return fixtures, nil
+}
@@ -0,0 +1,68 @@
+package aspsptest
+
+import (
+	"github.com/dgrijalva/jwt-go"
+	"github.com/google/uuid"
+	"net/http"
+	"time"
+)
+
+const requestURIExpiration = time.Minute
+
+// pushedRequest is a request object pushed by a client, referred to by its request_uri once
+type pushedRequest struct {
+	clientId      string
+	requestObject string
+	expires       time.Time
+}
+
+// par implements RFC 9126, the request object is only checked to be a JWT of the authenticated client
+func (s *Server) par(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	clientId, ok := s.authenticateClient(w, r)
+	if !ok {
+		return
+	}
+
+	requestObject := r.PostForm.Get("request")
+	claims := jwt.MapClaims{}
+	if _, _, err := new(jwt.Parser).ParseUnverified(requestObject, claims); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request_object", err.Error())
+		return
+	}
+	if claims["client_id"] != clientId {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request_object", "client_id does not match the authenticated client")
+		return
+	}
+
+	requestURI := "urn:ietf:params:oauth:request_uri:" + uuid.New().String()
+	s.mutex.Lock()
+	s.pushedRequests[requestURI] = pushedRequest{
+		clientId:      clientId,
+		requestObject: requestObject,
+		expires:       time.Now().Add(requestURIExpiration),
+	}
+	s.mutex.Unlock()
+
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"request_uri": requestURI,
+		"expires_in":  int64(requestURIExpiration.Seconds()),
+	})
+}
+
+// pushedRequestObject returns and forgets the request object pushed by clientId as requestURI
+func (s *Server) pushedRequestObject(requestURI, clientId string) (string, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	pushed, found := s.pushedRequests[requestURI]
+	delete(s.pushedRequests, requestURI)
+	if !found || pushed.clientId != clientId || time.Now().After(pushed.expires) {
+		return "", false
+	}
+	return pushed.requestObject, true
+}
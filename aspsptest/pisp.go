@@ -0,0 +1,352 @@
+package aspsptest
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+type paymentKind struct {
+	consents string
+	payments string
+	idMember string
+	status   string
+	// future dated kinds need the Create permission
+	future bool
+}
+
+var paymentKinds = []paymentKind{
+	{"/domestic-payment-consents", "/domestic-payments", "DomesticPaymentId", "AcceptedSettlementInProcess", false},
+	{"/domestic-scheduled-payment-consents", "/domestic-scheduled-payments", "DomesticScheduledPaymentId", "InitiationCompleted", true},
+	{"/domestic-standing-order-consents", "/domestic-standing-orders", "DomesticStandingOrderId", "InitiationCompleted", true},
+	{"/international-payment-consents", "/international-payments", "InternationalPaymentId", "AcceptedSettlementInProcess", false},
+}
+
+// mockRates are the mock exchange rates quoted for Actual and Indicative rate types, other pairs are quoted at 1
+var mockRates = map[string]string{
+	"GBP/EUR": "1.1500",
+	"GBP/USD": "1.2700",
+	"EUR/GBP": "0.8700",
+	"USD/GBP": "0.7900",
+}
+
+type payment struct {
+	clientId string
+	kind     paymentKind
+	data     map[string]interface{}
+}
+
+type initiation struct {
+	CurrencyOfTransfer         string          `json:"CurrencyOfTransfer"`
+	RequestedExecutionDateTime string          `json:"RequestedExecutionDateTime"`
+	FirstPaymentDateTime       string          `json:"FirstPaymentDateTime"`
+	InstructedAmount           *amount         `json:"InstructedAmount"`
+	CreditorAccount            *cashAccount    `json:"CreditorAccount"`
+	ExchangeRateInformation    *exchangeRate   `json:"ExchangeRateInformation"`
+	Raw                        json.RawMessage `json:"-"`
+}
+
+type exchangeRate struct {
+	UnitCurrency           string      `json:"UnitCurrency"`
+	ExchangeRate           json.Number `json:"ExchangeRate,omitempty"`
+	RateType               string      `json:"RateType"`
+	ContractIdentification string      `json:"ContractIdentification,omitempty"`
+	ExpirationDateTime     string      `json:"ExpirationDateTime,omitempty"`
+}
+
+func (s *Server) pisp(w http.ResponseWriter, r *http.Request, resource string) {
+	if strings.HasPrefix(resource, filePaymentKind.consents) || resource == filePaymentKind.payments || strings.HasPrefix(resource, filePaymentKind.payments+"/") {
+		s.filePayments(w, r, resource)
+		return
+	}
+	if strings.HasPrefix(resource, vrpKind.consents) {
+		s.vrpConsents(w, r, strings.TrimPrefix(resource, vrpKind.consents))
+		return
+	}
+	if resource == vrpKind.payments || strings.HasPrefix(resource, vrpKind.payments+"/") {
+		s.vrps(w, r, strings.TrimPrefix(resource, vrpKind.payments))
+		return
+	}
+	for _, kind := range paymentKinds {
+		switch {
+		case strings.HasPrefix(resource, kind.consents):
+			s.paymentConsents(w, r, kind, strings.TrimPrefix(resource, kind.consents))
+			return
+		case resource == kind.payments || strings.HasPrefix(resource, kind.payments+"/"):
+			s.paymentOrders(w, r, kind, strings.TrimPrefix(resource, kind.payments))
+			return
+		}
+	}
+	writeOBError(w, http.StatusNotFound, "UK.OBIE.Resource.NotFound", "resource not found")
+}
+
+func (s *Server) paymentConsents(w http.ResponseWriter, r *http.Request, kind paymentKind, resource string) {
+	g, ok := s.clientCredentials(w, r)
+	if !ok {
+		return
+	}
+
+	if resource != "" {
+		if r.Method == http.MethodDelete {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s.consentResource(w, r, g, PISPPath+kind.consents, strings.TrimPrefix(resource, "/"))
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.replayConsent(w, r, g) {
+		return
+	}
+
+	var request struct {
+		Data struct {
+			Permission string          `json:"Permission"`
+			Initiation json.RawMessage `json:"Initiation"`
+		} `json:"Data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeOBError(w, http.StatusBadRequest, "UK.OBIE.Field.Invalid", err.Error())
+		return
+	}
+	initiation, message := parseInitiation(kind, request.Data.Initiation)
+	if message != "" {
+		writeOBError(w, http.StatusBadRequest, "UK.OBIE.Field.Invalid", message)
+		return
+	}
+	if kind.future && request.Data.Permission != "Create" {
+		writeOBError(w, http.StatusBadRequest, "UK.OBIE.Field.Invalid", "Data.Permission must be Create")
+		return
+	}
+
+	data := map[string]interface{}{"Initiation": initiation.Raw}
+	if request.Data.Permission != "" {
+		data["Permission"] = request.Data.Permission
+	}
+	if initiation.RequestedExecutionDateTime != "" {
+		data["ExpectedExecutionDateTime"] = initiation.RequestedExecutionDateTime
+	}
+	if initiation.FirstPaymentDateTime != "" {
+		data["ExpectedExecutionDateTime"] = initiation.FirstPaymentDateTime
+	}
+	if rate := initiation.ExchangeRateInformation; rate != nil {
+		data["ExchangeRateInformation"] = quote(*rate, initiation.CurrencyOfTransfer)
+	}
+
+	intent := s.createConsent(g, "pc-", "payments", PISPPath+kind.consents, data)
+	s.keepIdempotent(r, g, intent.id)
+	writeJSON(w, http.StatusCreated, consentDocument(r, intent))
+}
+
+// replayConsent answers with the consent created with the request x-idempotency-key, if any
+func (s *Server) replayConsent(w http.ResponseWriter, r *http.Request, g grant) bool {
+	key := r.Header.Get("x-idempotency-key")
+	if key == "" {
+		return false
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	intent, replayed := s.consents[s.idempotent[g.clientId+"/"+key]]
+	if !replayed {
+		return false
+	}
+	writeJSON(w, http.StatusCreated, consentDocument(r, intent))
+	return true
+}
+
+// keepIdempotent remembers the consent or payment created with the request x-idempotency-key, if any
+func (s *Server) keepIdempotent(r *http.Request, g grant, id string) {
+	if key := r.Header.Get("x-idempotency-key"); key != "" {
+		s.mutex.Lock()
+		s.idempotent[g.clientId+"/"+key] = id
+		s.mutex.Unlock()
+	}
+}
+
+// parseInitiation validates the members the mock relies on, returning an error message otherwise
+func parseInitiation(kind paymentKind, raw json.RawMessage) (initiation, string) {
+	var parsed initiation
+	if len(raw) == 0 || json.Unmarshal(raw, &parsed) != nil {
+		return parsed, "Data.Initiation required"
+	}
+	parsed.Raw = raw
+
+	if parsed.CreditorAccount == nil || parsed.CreditorAccount.Identification == "" {
+		return parsed, "Data.Initiation.CreditorAccount required"
+	}
+	switch kind.payments {
+	case "/domestic-scheduled-payments":
+		if parsed.RequestedExecutionDateTime == "" {
+			return parsed, "Data.Initiation.RequestedExecutionDateTime required"
+		}
+	case "/domestic-standing-orders":
+		if parsed.FirstPaymentDateTime == "" {
+			return parsed, "Data.Initiation.FirstPaymentDateTime required"
+		}
+	case "/international-payments":
+		if parsed.CurrencyOfTransfer == "" {
+			return parsed, "Data.Initiation.CurrencyOfTransfer required"
+		}
+		if rate := parsed.ExchangeRateInformation; rate != nil && rate.RateType == "Agreed" && (rate.ExchangeRate == "" || rate.ContractIdentification == "") {
+			return parsed, "agreed exchange rates need ExchangeRate and ContractIdentification"
+		}
+	}
+	return parsed, ""
+}
+
+// quote returns the exchange rate the mock applies, agreed rates are kept as requested
+func quote(requested exchangeRate, currencyOfTransfer string) exchangeRate {
+	if requested.RateType == "Agreed" {
+		return requested
+	}
+
+	rate, ok := mockRates[requested.UnitCurrency+"/"+currencyOfTransfer]
+	if !ok {
+		rate = "1"
+	}
+	quoted := exchangeRate{
+		UnitCurrency: requested.UnitCurrency,
+		ExchangeRate: json.Number(rate),
+		RateType:     requested.RateType,
+	}
+	if requested.RateType == "Actual" {
+		quoted.ExpirationDateTime = time.Now().UTC().Add(time.Minute * 5).Format(time.RFC3339)
+	}
+	return quoted
+}
+
+func (s *Server) paymentOrders(w http.ResponseWriter, r *http.Request, kind paymentKind, resource string) {
+	g, ok := s.bearer(w, r)
+	if !ok {
+		return
+	}
+
+	if resource != "" {
+		s.paymentResource(w, r, g, kind, strings.TrimPrefix(resource, "/"))
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idempotencyKey := g.clientId + "/" + r.Header.Get("x-idempotency-key")
+	s.mutex.Lock()
+	paymentId, replayed := s.idempotent[idempotencyKey]
+	s.mutex.Unlock()
+	if replayed && r.Header.Get("x-idempotency-key") != "" {
+		s.paymentDocument(w, r, http.StatusCreated, kind, paymentId)
+		return
+	}
+
+	if !s.authorised(w, g, "payments") {
+		return
+	}
+
+	var request struct {
+		Data struct {
+			ConsentId  string          `json:"ConsentId"`
+			Initiation json.RawMessage `json:"Initiation"`
+		} `json:"Data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeOBError(w, http.StatusBadRequest, "UK.OBIE.Field.Invalid", err.Error())
+		return
+	}
+	if request.Data.ConsentId != g.consentId {
+		writeOBError(w, http.StatusBadRequest, "UK.OBIE.Field.Invalid", "Data.ConsentId does not match the access token consent")
+		return
+	}
+
+	s.mutex.Lock()
+	intent := s.consents[g.consentId]
+	consented, _ := intent.data["Initiation"].(json.RawMessage)
+	path := intent.path
+	s.mutex.Unlock()
+	if path != PISPPath+kind.consents {
+		writeOBError(w, http.StatusBadRequest, "UK.OBIE.Resource.ConsentMismatch", "consent is not a "+strings.TrimPrefix(kind.consents, "/"))
+		return
+	}
+	if !sameJSON(consented, request.Data.Initiation) {
+		writeOBError(w, http.StatusBadRequest, "UK.OBIE.Resource.ConsentMismatch", "Data.Initiation does not match the consent")
+		return
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	created := &payment{
+		clientId: g.clientId,
+		kind:     kind,
+		data: map[string]interface{}{
+			kind.idMember:          "pay-" + uuid.New().String(),
+			"ConsentId":            g.consentId,
+			"Status":               kind.status,
+			"CreationDateTime":     now,
+			"StatusUpdateDateTime": now,
+			"Initiation":           request.Data.Initiation,
+		},
+	}
+	s.mutex.Lock()
+	for _, member := range []string{"ExchangeRateInformation", "ExpectedExecutionDateTime"} {
+		if value, ok := intent.data[member]; ok {
+			created.data[member] = value
+		}
+	}
+	intent.status = "Consumed"
+	paymentId = created.data[kind.idMember].(string)
+	s.payments[paymentId] = created
+	if r.Header.Get("x-idempotency-key") != "" {
+		s.idempotent[idempotencyKey] = paymentId
+	}
+	s.mutex.Unlock()
+
+	s.notify(r, g.clientId, eventResourceUpdate, PISPPath+kind.payments, paymentId, nil)
+	s.paymentDocument(w, r, http.StatusCreated, kind, paymentId)
+}
+
+func (s *Server) paymentResource(w http.ResponseWriter, r *http.Request, g grant, kind paymentKind, id string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.mutex.Lock()
+	found, ok := s.payments[id]
+	s.mutex.Unlock()
+	if !ok || found.clientId != g.clientId || found.kind != kind {
+		writeOBError(w, http.StatusNotFound, "UK.OBIE.Resource.NotFound", "payment not found")
+		return
+	}
+
+	s.paymentDocument(w, r, http.StatusOK, kind, id)
+}
+
+func (s *Server) paymentDocument(w http.ResponseWriter, r *http.Request, status int, kind paymentKind, id string) {
+	s.mutex.Lock()
+	data := map[string]interface{}{}
+	for name, value := range s.payments[id].data {
+		data[name] = value
+	}
+	s.mutex.Unlock()
+
+	writeJSON(w, status, map[string]interface{}{
+		"Data":  data,
+		"Links": map[string]string{"Self": baseURL(r) + PISPPath + kind.payments + "/" + id},
+		"Meta":  map[string]interface{}{},
+	})
+}
+
+// sameJSON compares JSON documents ignoring member order and whitespace
+func sameJSON(a, b json.RawMessage) bool {
+	var left, right interface{}
+	if json.Unmarshal(a, &left) != nil || json.Unmarshal(b, &right) != nil {
+		return false
+	}
+	return reflect.DeepEqual(left, right)
+}
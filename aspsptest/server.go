@@ -0,0 +1,905 @@
+package aspsptest
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DiscoveryPath     = "/.well-known/openid-configuration"
+	RegistrationPath  = "/register"
+	TokenPath         = "/token"
+	AuthorizationPath = "/authorize"
+	JWKSPath          = "/jwks"
+	RevocationPath    = "/revoke"
+	IntrospectionPath = "/introspect"
+	PARPath           = "/par"
+	AISPath           = "/open-banking/v3.1/aisp"
+	CBPIIPath         = "/open-banking/v3.1/cbpii"
+	PISPPath          = "/open-banking/v3.1/pisp"
+	EventsPath        = "/open-banking/v3.1"
+)
+
+const (
+	bankKeyId       = "mockbank"
+	tokenExpiration = time.Hour
+)
+
+var statementFilePath = regexp.MustCompile(`^/accounts/[^/]+/statements/[^/]+/file$`)
+
+// Server is a mock ASPSP over mutual TLS, client certificates are required
+// everywhere except discovery, jwks and the authorization endpoint. Consents
+// are authorised straight away by the authorization endpoint without user interaction.
+type Server struct {
+	*httptest.Server
+	Certificates *Certificates
+	Fixtures     Fixtures
+	// PageSize splits transaction lists in pages linked by Links.Next, zero serves them whole
+	PageSize int
+	// NoPAR stops advertising the pushed authorization request endpoint, so clients send request objects in the front channel
+	NoPAR bool
+	// RequirePAR advertises require_pushed_authorization_requests and rejects request objects sent in the front channel
+	RequirePAR bool
+	// JARM advertises the jwt response modes, authorization responses requested with them are JWTs signed
+	// with the bank key in the response parameter
+	JARM bool
+
+	mutex    sync.Mutex
+	clients  map[string]registeredClient
+	consents map[string]*consent
+	codes    map[string]authorizationCode
+	grants   map[string]grant
+	payments map[string]*payment
+	// idempotent maps client id and x-idempotency-key to the consent or payment they created
+	idempotent map[string]string
+	// files are the payment files uploaded by file payment consent id
+	files map[string][]byte
+	// refreshTokens are issued for VRP consents, which are used without the PSU, and account access consents
+	refreshTokens map[string]grant
+	callbacks     map[string]*callbackURL
+	// notifications are the events waiting to be polled, oldest first
+	notifications []notification
+	// pushedRequests are the request objects pushed to PARPath by request_uri
+	pushedRequests map[string]pushedRequest
+}
+
+type registeredClient struct {
+	secret       string
+	redirectUris []string
+}
+
+// consent is an intent of any API, scope tells which resources its tokens can access
+// and data holds the API specific Data members
+type consent struct {
+	id               string
+	clientId         string
+	scope            string
+	path             string
+	status           string
+	creationDateTime time.Time
+	data             map[string]interface{}
+}
+
+type authorizationCode struct {
+	clientId    string
+	consentId   string
+	redirectUri string
+	nonce       string
+	// codeChallenge is the S256 PKCE challenge the token request verifier must match, if sent
+	codeChallenge string
+}
+
+type grant struct {
+	clientId  string
+	consentId string
+	scope     string
+	expires   time.Time
+}
+
+// NewServer starts a mock ASPSP on a random local port
+func NewServer(certificates *Certificates, fixtures Fixtures) *Server {
+	server := NewUnstartedServer(certificates, fixtures)
+	server.StartTLS()
+	return server
+}
+
+// NewUnstartedServer returns a mock ASPSP to be started with StartTLS, the
+// listener can be replaced to serve on a fixed address
+func NewUnstartedServer(certificates *Certificates, fixtures Fixtures) *Server {
+	server := &Server{
+		Certificates:   certificates,
+		Fixtures:       fixtures,
+		clients:        map[string]registeredClient{},
+		consents:       map[string]*consent{},
+		codes:          map[string]authorizationCode{},
+		grants:         map[string]grant{},
+		payments:       map[string]*payment{},
+		idempotent:     map[string]string{},
+		files:          map[string][]byte{},
+		refreshTokens:  map[string]grant{},
+		callbacks:      map[string]*callbackURL{},
+		pushedRequests: map[string]pushedRequest{},
+	}
+	server.Server = httptest.NewUnstartedServer(server)
+	server.Server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{certificates.Server},
+		ClientAuth:   tls.VerifyClientCertIfGiven,
+		ClientCAs:    certificates.caPool(),
+	}
+	return server
+}
+
+func (s *Server) DiscoveryURL() string {
+	return s.URL + DiscoveryPath
+}
+
+func (s *Server) AISURL() string {
+	return s.URL + AISPath
+}
+
+func (s *Server) CBPIIURL() string {
+	return s.URL + CBPIIPath
+}
+
+func (s *Server) PISPURL() string {
+	return s.URL + PISPPath
+}
+
+func (s *Server) EventsURL() string {
+	return s.URL + EventsPath
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if interactionId := r.Header.Get("x-fapi-interaction-id"); interactionId != "" {
+		w.Header().Set("x-fapi-interaction-id", interactionId)
+	}
+
+	switch r.URL.Path {
+	case DiscoveryPath:
+		s.discovery(w, r)
+		return
+	case JWKSPath:
+		s.jwks(w, r)
+		return
+	case AuthorizationPath:
+		s.authorize(w, r)
+		return
+	}
+
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		writeOAuthError(w, http.StatusUnauthorized, "invalid_client", "client certificate required")
+		return
+	}
+
+	signed := strings.HasPrefix(r.URL.Path, CBPIIPath+"/") || strings.HasPrefix(r.URL.Path, PISPPath+"/")
+	if signed && r.Method == http.MethodPost && !s.verifySignature(w, r) {
+		return
+	}
+
+	switch {
+	case r.URL.Path == RegistrationPath:
+		s.register(w, r)
+	case r.URL.Path == TokenPath:
+		s.token(w, r)
+	case r.URL.Path == RevocationPath:
+		s.revoke(w, r)
+	case r.URL.Path == IntrospectionPath:
+		s.introspect(w, r)
+	case r.URL.Path == PARPath:
+		s.par(w, r)
+	case strings.HasPrefix(r.URL.Path, AISPath+"/account-access-consents"):
+		s.accountAccessConsents(w, r, strings.TrimPrefix(r.URL.Path, AISPath+"/account-access-consents"))
+	case strings.HasPrefix(r.URL.Path, AISPath+"/"):
+		s.accountResource(w, r, strings.TrimPrefix(r.URL.Path, AISPath))
+	case strings.HasPrefix(r.URL.Path, CBPIIPath+"/"):
+		s.cbpii(w, r, strings.TrimPrefix(r.URL.Path, CBPIIPath))
+	case strings.HasPrefix(r.URL.Path, PISPPath+"/"):
+		s.pisp(w, r, strings.TrimPrefix(r.URL.Path, PISPPath))
+	case r.URL.Path == EventsPath+"/events" || strings.HasPrefix(r.URL.Path, EventsPath+"/callback-urls"):
+		s.events(w, r, strings.TrimPrefix(r.URL.Path, EventsPath))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) discovery(w http.ResponseWriter, r *http.Request) {
+	base := baseURL(r)
+	configuration := map[string]interface{}{
+		"issuer":                 base,
+		"authorization_endpoint": base + AuthorizationPath,
+		"token_endpoint":         base + TokenPath,
+		"registration_endpoint":  base + RegistrationPath,
+		"jwks_uri":               base + JWKSPath,
+		"revocation_endpoint":    base + RevocationPath,
+		"introspection_endpoint": base + IntrospectionPath,
+		"request_object_signing_alg_values_supported": []string{"PS256", "RS256"},
+		"id_token_signing_alg_values_supported":       []string{"PS256"},
+		"response_types_supported":                    []string{"code", "code id_token"},
+		"grant_types_supported":                       []string{"authorization_code", "client_credentials"},
+		"scopes_supported":                            []string{"openid", "accounts"},
+		"token_endpoint_auth_methods_supported":       []string{"client_secret_basic"},
+		"tls_client_certificate_bound_access_tokens":  true,
+		"code_challenge_methods_supported":            []string{"S256"},
+	}
+	if !s.NoPAR {
+		configuration["pushed_authorization_request_endpoint"] = base + PARPath
+	}
+	if s.RequirePAR {
+		configuration["require_pushed_authorization_requests"] = true
+	}
+	if s.JARM {
+		configuration["response_modes_supported"] = []string{"query", "fragment", "jwt", "query.jwt", "fragment.jwt"}
+	}
+	writeJSON(w, http.StatusOK, configuration)
+}
+
+func (s *Server) jwks(w http.ResponseWriter, r *http.Request) {
+	key := s.Certificates.BankKey.PublicKey
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "PS256",
+			"kid": bankKeyId,
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	})
+}
+
+func (s *Server) register(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_client_metadata", err.Error())
+		return
+	}
+
+	claims := jwt.MapClaims{}
+	if _, _, err = new(jwt.Parser).ParseUnverified(string(body), claims); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_client_metadata", err.Error())
+		return
+	}
+
+	var redirectUris []string
+	if uris, ok := claims["redirect_uris"].([]interface{}); ok {
+		for _, uri := range uris {
+			if value, ok := uri.(string); ok {
+				redirectUris = append(redirectUris, value)
+			}
+		}
+	}
+	if len(redirectUris) == 0 {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_redirect_uri", "redirect_uris required")
+		return
+	}
+
+	clientId := uuid.New().String()
+	secret := uuid.New().String()
+	s.mutex.Lock()
+	s.clients[clientId] = registeredClient{secret: secret, redirectUris: redirectUris}
+	s.mutex.Unlock()
+
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"client_id":                  clientId,
+		"client_secret":              secret,
+		"client_id_issued_at":        time.Now().Unix(),
+		"redirect_uris":              redirectUris,
+		"token_endpoint_auth_method": "client_secret_basic",
+		"grant_types":                claims["grant_types"],
+		"response_types":             claims["response_types"],
+	})
+}
+
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	clientId, ok := s.authenticateClient(w, r)
+	if !ok {
+		return
+	}
+
+	switch r.PostForm.Get("grant_type") {
+	case "client_credentials":
+		accessToken := s.issue(grant{clientId: clientId, scope: r.PostForm.Get("scope")})
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"access_token": accessToken,
+			"token_type":   "Bearer",
+			"expires_in":   int64(tokenExpiration.Seconds()),
+			"scope":        r.PostForm.Get("scope"),
+		})
+
+	case "authorization_code":
+		s.mutex.Lock()
+		code, found := s.codes[r.PostForm.Get("code")]
+		delete(s.codes, r.PostForm.Get("code"))
+		s.mutex.Unlock()
+		if !found || code.clientId != clientId || code.redirectUri != r.PostForm.Get("redirect_uri") {
+			writeOAuthError(w, http.StatusBadRequest, "invalid_grant", "invalid authorization code")
+			return
+		}
+		if code.codeChallenge != "" && code.codeChallenge != codeChallenge(r.PostForm.Get("code_verifier")) {
+			writeOAuthError(w, http.StatusBadRequest, "invalid_grant", "code_verifier does not match the code_challenge")
+			return
+		}
+		s.mutex.Lock()
+		consentScope := s.consents[code.consentId].scope
+		s.mutex.Unlock()
+		for _, requested := range strings.Fields(r.PostForm.Get("scope")) {
+			if requested != "openid" && requested != consentScope {
+				writeOAuthError(w, http.StatusBadRequest, "invalid_scope", "scope "+requested+" is not the consent scope")
+				return
+			}
+		}
+
+		idToken, err := s.idToken(baseURL(r), code)
+		if err != nil {
+			writeOAuthError(w, http.StatusInternalServerError, "server_error", err.Error())
+			return
+		}
+		s.mutex.Lock()
+		scope := "openid " + s.consents[code.consentId].scope
+		refreshable := s.consents[code.consentId].path == PISPPath+vrpKind.consents || s.consents[code.consentId].scope == "accounts"
+		s.mutex.Unlock()
+		issued := grant{clientId: clientId, consentId: code.consentId, scope: scope}
+		response := map[string]interface{}{
+			"access_token": s.issue(issued),
+			"token_type":   "Bearer",
+			"expires_in":   int64(tokenExpiration.Seconds()),
+			"scope":        scope,
+			"id_token":     idToken,
+		}
+		if refreshable {
+			refreshToken := uuid.New().String()
+			s.mutex.Lock()
+			s.refreshTokens[refreshToken] = issued
+			s.mutex.Unlock()
+			response["refresh_token"] = refreshToken
+		}
+		writeJSON(w, http.StatusOK, response)
+
+	case "refresh_token":
+		s.mutex.Lock()
+		issued, found := s.refreshTokens[r.PostForm.Get("refresh_token")]
+		intent := s.consents[issued.consentId]
+		revoked := intent == nil || intent.status != "Authorised"
+		s.mutex.Unlock()
+		if !found || issued.clientId != clientId || revoked {
+			writeOAuthError(w, http.StatusBadRequest, "invalid_grant", "invalid refresh token")
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"access_token": s.issue(issued),
+			"token_type":   "Bearer",
+			"expires_in":   int64(tokenExpiration.Seconds()),
+			"scope":        issued.scope,
+		})
+
+	default:
+		writeOAuthError(w, http.StatusBadRequest, "unsupported_grant_type", "unsupported grant type")
+	}
+}
+
+// authenticateClient returns the client id of a form post authenticated with client_secret_basic,
+// writing the error response otherwise
+func (s *Server) authenticateClient(w http.ResponseWriter, r *http.Request) (string, bool) {
+	clientId, secret, ok := r.BasicAuth()
+	s.mutex.Lock()
+	client, registered := s.clients[clientId]
+	s.mutex.Unlock()
+	if !ok || !registered || client.secret != secret {
+		writeOAuthError(w, http.StatusUnauthorized, "invalid_client", "client authentication failed")
+		return "", false
+	}
+
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return "", false
+	}
+
+	return clientId, true
+}
+
+func (s *Server) issue(g grant) string {
+	accessToken := uuid.New().String()
+	g.expires = time.Now().Add(tokenExpiration)
+
+	s.mutex.Lock()
+	s.grants[accessToken] = g
+	s.mutex.Unlock()
+
+	return accessToken
+}
+
+func (s *Server) idToken(issuer string, code authorizationCode) (string, error) {
+	iat := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodPS256, jwt.MapClaims{
+		"iss":                   issuer,
+		"sub":                   code.consentId,
+		"aud":                   code.clientId,
+		"nonce":                 code.nonce,
+		"openbanking_intent_id": code.consentId,
+		"iat":                   iat.Unix(),
+		"exp":                   iat.Add(tokenExpiration).Unix(),
+	})
+	token.Header["kid"] = bankKeyId
+	return token.SignedString(s.Certificates.BankKey)
+}
+
+// authorize approves the consent referenced by the request object and redirects
+// back to the client, in the fragment for hybrid flow response types
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	requestObject := query.Get("request")
+	if s.RequirePAR && query.Get("request_uri") == "" {
+		http.Error(w, "pushed authorization request required", http.StatusBadRequest)
+		return
+	}
+	if requestURI := query.Get("request_uri"); requestURI != "" {
+		var pushed bool
+		if requestObject, pushed = s.pushedRequestObject(requestURI, query.Get("client_id")); !pushed {
+			http.Error(w, "invalid or expired request_uri", http.StatusBadRequest)
+			return
+		}
+	}
+	claims := jwt.MapClaims{}
+	if requestObject != "" {
+		if _, _, err := new(jwt.Parser).ParseUnverified(requestObject, claims); err != nil {
+			http.Error(w, "invalid request object: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	param := func(name string) string {
+		if value, ok := claims[name].(string); ok {
+			return value
+		}
+		return query.Get(name)
+	}
+
+	clientId := param("client_id")
+	redirectUri := param("redirect_uri")
+	s.mutex.Lock()
+	client, registered := s.clients[clientId]
+	s.mutex.Unlock()
+	if !registered || !contains(client.redirectUris, redirectUri) {
+		http.Error(w, "unknown client or redirect uri", http.StatusBadRequest)
+		return
+	}
+
+	responseType := param("response_type")
+	redirect := url.Values{}
+	redirect.Set("state", param("state"))
+
+	consentId := intentId(claims)
+	s.mutex.Lock()
+	intent, found := s.consents[consentId]
+	if method := param("code_challenge_method"); param("code_challenge") != "" && method != "S256" {
+		redirect.Set("error", "invalid_request")
+		redirect.Set("error_description", "code_challenge_method must be S256")
+	} else if found && intent.clientId == clientId && intent.status == "AwaitingAuthorisation" {
+		intent.status = "Authorised"
+		code := uuid.New().String()
+		s.codes[code] = authorizationCode{
+			clientId:      clientId,
+			consentId:     consentId,
+			redirectUri:   redirectUri,
+			nonce:         param("nonce"),
+			codeChallenge: param("code_challenge"),
+		}
+		redirect.Set("code", code)
+	} else {
+		redirect.Set("error", "invalid_request")
+		redirect.Set("error_description", "unknown or already authorised openbanking_intent_id")
+	}
+	s.mutex.Unlock()
+
+	if redirect.Get("code") != "" && strings.Contains(responseType, "id_token") {
+		idToken, err := s.idToken(baseURL(r), authorizationCode{clientId: clientId, consentId: consentId, nonce: param("nonce")})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		redirect.Set("id_token", idToken)
+	}
+
+	if s.JARM && strings.HasSuffix(param("response_mode"), "jwt") {
+		response, err := s.jarmResponse(baseURL(r), clientId, redirect)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		redirect = url.Values{"response": {response}}
+	}
+
+	separator := "?"
+	if strings.Contains(redirectUri, "?") {
+		separator = "&"
+	}
+	if responseType != "code" {
+		separator = "#"
+	}
+	http.Redirect(w, r, redirectUri+separator+redirect.Encode(), http.StatusFound)
+}
+
+// jarmResponse signs the authorization response parameters as JARM claims
+func (s *Server) jarmResponse(issuer, clientId string, params url.Values) (string, error) {
+	claims := jwt.MapClaims{
+		"iss": issuer,
+		"aud": clientId,
+		"exp": time.Now().Add(10 * time.Minute).Unix(),
+	}
+	for name := range params {
+		claims[name] = params.Get(name)
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodPS256, claims)
+	token.Header["kid"] = bankKeyId
+	response, err := token.SignedString(s.Certificates.BankKey)
+	if err != nil {
+		return "", errors.Wrap(err, "error signing authorization response")
+	}
+	return response, nil
+}
+
+// codeChallenge is the S256 PKCE challenge of verifier
+func codeChallenge(verifier string) string {
+	hash := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+func intentId(claims jwt.MapClaims) string {
+	for _, member := range []string{"id_token", "userinfo"} {
+		requested, _ := claims["claims"].(map[string]interface{})
+		member, _ := requested[member].(map[string]interface{})
+		intent, _ := member["openbanking_intent_id"].(map[string]interface{})
+		if value, ok := intent["value"].(string); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+func (s *Server) accountAccessConsents(w http.ResponseWriter, r *http.Request, resource string) {
+	g, ok := s.clientCredentials(w, r)
+	if !ok {
+		return
+	}
+
+	if resource == "" && r.Method == http.MethodPost {
+		var request struct {
+			Data struct {
+				Permissions             []string `json:"Permissions"`
+				ExpirationDateTime      string   `json:"ExpirationDateTime"`
+				TransactionFromDateTime string   `json:"TransactionFromDateTime"`
+				TransactionToDateTime   string   `json:"TransactionToDateTime"`
+			} `json:"Data"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil || len(request.Data.Permissions) == 0 {
+			writeOBError(w, http.StatusBadRequest, "UK.OBIE.Field.Missing", "Data.Permissions required")
+			return
+		}
+
+		intent := s.createConsent(g, "aac-", "accounts", AISPath+"/account-access-consents", map[string]interface{}{
+			"Permissions": request.Data.Permissions,
+		})
+		writeJSON(w, http.StatusCreated, consentDocument(r, intent))
+		return
+	}
+
+	s.consentResource(w, r, g, AISPath+"/account-access-consents", strings.TrimPrefix(resource, "/"))
+}
+
+// createConsent stores a new consent awaiting authorisation
+func (s *Server) createConsent(g grant, prefix, scope, path string, data map[string]interface{}) *consent {
+	intent := &consent{
+		id:               prefix + uuid.New().String(),
+		clientId:         g.clientId,
+		scope:            scope,
+		path:             path,
+		status:           "AwaitingAuthorisation",
+		creationDateTime: time.Now().UTC(),
+		data:             data,
+	}
+	s.mutex.Lock()
+	s.consents[intent.id] = intent
+	s.mutex.Unlock()
+	return intent
+}
+
+// consentResource serves GET and DELETE of a consent created at path owned by the grant client
+func (s *Server) consentResource(w http.ResponseWriter, r *http.Request, g grant, path, id string) {
+	s.mutex.Lock()
+	intent, found := s.consents[id]
+	s.mutex.Unlock()
+	if !found || intent.clientId != g.clientId || intent.path != path {
+		writeOBError(w, http.StatusNotFound, "UK.OBIE.Resource.NotFound", "consent not found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		s.mutex.Lock()
+		document := consentDocument(r, intent)
+		s.mutex.Unlock()
+		writeJSON(w, http.StatusOK, document)
+	case http.MethodDelete:
+		s.mutex.Lock()
+		intent.status = "Revoked"
+		s.mutex.Unlock()
+		s.notify(r, intent.clientId, eventConsentAuthorizationRevoked, path, intent.id, map[string]interface{}{"reason": "Consent revoked by the TPP"})
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func consentDocument(r *http.Request, intent *consent) map[string]interface{} {
+	data := map[string]interface{}{
+		"ConsentId":            intent.id,
+		"Status":               intent.status,
+		"StatusUpdateDateTime": intent.creationDateTime.Format(time.RFC3339),
+		"CreationDateTime":     intent.creationDateTime.Format(time.RFC3339),
+	}
+	for name, value := range intent.data {
+		data[name] = value
+	}
+	return map[string]interface{}{
+		"Data":  data,
+		"Risk":  map[string]interface{}{},
+		"Links": map[string]string{"Self": baseURL(r) + intent.path + "/" + intent.id},
+		"Meta":  map[string]interface{}{},
+	}
+}
+
+func (s *Server) accountResource(w http.ResponseWriter, r *http.Request, resource string) {
+	g, ok := s.bearer(w, r)
+	if !ok {
+		return
+	}
+
+	if !s.authorised(w, g, "accounts") {
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if statementFilePath.MatchString(resource) {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write([]byte(statementPDF))
+		return
+	}
+
+	fixture, found := s.Fixtures[resource]
+	if !found {
+		writeOBError(w, http.StatusNotFound, "UK.OBIE.Resource.NotFound", "resource not found")
+		return
+	}
+	if strings.HasSuffix(resource, "/transactions") {
+		page, err := s.transactionsPage(r, resource, fixture)
+		if err != nil {
+			writeOBError(w, http.StatusBadRequest, "UK.OBIE.Field.Invalid", err.Error())
+			return
+		}
+		fixture = page
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(fixture)
+}
+
+// transactionsPage filters a transactions fixture by the fromBookingDateTime and toBookingDateTime
+// query parameters and slices it by the page one when there is a PageSize
+func (s *Server) transactionsPage(r *http.Request, resource string, fixture json.RawMessage) (json.RawMessage, error) {
+	var document struct {
+		Data struct {
+			Transaction []json.RawMessage `json:"Transaction"`
+		} `json:"Data"`
+		Links map[string]string      `json:"Links"`
+		Meta  map[string]interface{} `json:"Meta"`
+	}
+	if err := json.Unmarshal(fixture, &document); err != nil {
+		return nil, err
+	}
+
+	query := r.URL.Query()
+	transactions, err := bookedBetween(document.Data.Transaction, query.Get("fromBookingDateTime"), query.Get("toBookingDateTime"))
+	if err != nil {
+		return nil, err
+	}
+	if s.PageSize == 0 {
+		document.Data.Transaction = transactions
+		return json.Marshal(document)
+	}
+
+	page := 1
+	if query.Get("page") != "" {
+		var err error
+		if page, err = strconv.Atoi(query.Get("page")); err != nil || page < 1 {
+			return nil, errors.New("invalid page")
+		}
+	}
+
+	totalPages := (len(transactions) + s.PageSize - 1) / s.PageSize
+	if totalPages == 0 {
+		totalPages = 1
+	}
+	start := (page - 1) * s.PageSize
+	if start > len(transactions) {
+		start = len(transactions)
+	}
+	end := start + s.PageSize
+	if end > len(transactions) {
+		end = len(transactions)
+	}
+	document.Data.Transaction = transactions[start:end]
+
+	link := func(page int) string {
+		query.Set("page", strconv.Itoa(page))
+		return baseURL(r) + AISPath + resource + "?" + query.Encode()
+	}
+	document.Links = map[string]string{"Self": link(page), "First": link(1), "Last": link(totalPages)}
+	if page < totalPages {
+		document.Links["Next"] = link(page + 1)
+	}
+	if page > 1 {
+		document.Links["Prev"] = link(page - 1)
+	}
+	if document.Meta == nil {
+		document.Meta = map[string]interface{}{}
+	}
+	document.Meta["TotalPages"] = totalPages
+
+	return json.Marshal(document)
+}
+
+// bookedBetween keeps the transactions booked within from and to, both inclusive and optional
+func bookedBetween(transactions []json.RawMessage, from, to string) ([]json.RawMessage, error) {
+	if from == "" && to == "" {
+		return transactions, nil
+	}
+
+	parse := func(name, value string) (time.Time, error) {
+		for _, layout := range []string{"2006-01-02T15:04:05", time.RFC3339} {
+			if parsed, err := time.Parse(layout, value); err == nil {
+				return parsed, nil
+			}
+		}
+		return time.Time{}, errors.New("invalid " + name)
+	}
+	var start, end time.Time
+	var err error
+	if from != "" {
+		if start, err = parse("fromBookingDateTime", from); err != nil {
+			return nil, err
+		}
+	}
+	if to != "" {
+		if end, err = parse("toBookingDateTime", to); err != nil {
+			return nil, err
+		}
+	}
+
+	booked := []json.RawMessage{}
+	for _, transaction := range transactions {
+		var member struct {
+			BookingDateTime time.Time `json:"BookingDateTime"`
+		}
+		if err = json.Unmarshal(transaction, &member); err != nil {
+			return nil, err
+		}
+		if (from == "" || !member.BookingDateTime.Before(start)) && (to == "" || !member.BookingDateTime.After(end)) {
+			booked = append(booked, transaction)
+		}
+	}
+	return booked, nil
+}
+
+// authorised checks the grant comes from an authorised consent of scope, writing the error response otherwise
+func (s *Server) authorised(w http.ResponseWriter, g grant, scope string) bool {
+	s.mutex.Lock()
+	intent, found := s.consents[g.consentId]
+	authorised := found && intent.status == "Authorised" && intent.scope == scope
+	s.mutex.Unlock()
+	if !authorised {
+		writeOBError(w, http.StatusForbidden, "UK.OBIE.Resource.InvalidConsentStatus", "consent is not authorised")
+		return false
+	}
+	return true
+}
+
+// clientCredentials returns the grant of a client credentials token, as consent endpoints require
+func (s *Server) clientCredentials(w http.ResponseWriter, r *http.Request) (grant, bool) {
+	g, ok := s.bearer(w, r)
+	if ok && g.consentId != "" {
+		writeOBError(w, http.StatusForbidden, "UK.OBIE.Resource.InvalidConsentStatus", "client credentials token required")
+		return grant{}, false
+	}
+	return g, ok
+}
+
+// bearer returns the grant of a valid access token, writing the error response otherwise
+func (s *Server) bearer(w http.ResponseWriter, r *http.Request) (grant, bool) {
+	accessToken := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	s.mutex.Lock()
+	g, found := s.grants[accessToken]
+	s.mutex.Unlock()
+	if !found || time.Now().After(g.expires) {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		writeOBError(w, http.StatusUnauthorized, "UK.OBIE.Unauthorized", "invalid or expired access token")
+		return grant{}, false
+	}
+
+	return g, true
+}
+
+func baseURL(r *http.Request) string {
+	return "https://" + r.Host
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeOAuthError(w http.ResponseWriter, status int, code, description string) {
+	writeJSON(w, status, map[string]string{
+		"error":             code,
+		"error_description": description,
+	})
+}
+
+func writeOBError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]interface{}{
+		"Code":    http.StatusText(status),
+		"Message": message,
+		"Errors": []map[string]string{{
+			"ErrorCode": code,
+			"Message":   message,
+		}},
+	})
+}
+
+const statementPDF = `%PDF-1.4
+1 0 obj << /Type /Catalog /Pages 2 0 R >> endobj
+2 0 obj << /Type /Pages /Kids [3 0 R] /Count 1 >> endobj
+3 0 obj << /Type /Page /Parent 2 0 R /MediaBox [0 0 595 842] >> endobj
+trailer << /Root 1 0 R >>
+%%EOF
+`
@@ -0,0 +1,241 @@
+package aspsptest_test
+
+import (
+	"github.com/jmatosp/obclient/aspsp"
+	"github.com/jmatosp/obclient/aspsptest"
+	"github.com/jmatosp/obclient/authorization"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// authorizationRequests records whether requests to the authorization endpoint referred to a pushed request object
+type authorizationRequests struct {
+	mutex  sync.Mutex
+	pushed []bool
+}
+
+func (a *authorizationRequests) handler(server *aspsptest.Server, failPAR bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case aspsptest.PARPath:
+			if failPAR {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"invalid_request"}`))
+				return
+			}
+		case aspsptest.AuthorizationPath:
+			a.mutex.Lock()
+			a.pushed = append(a.pushed, r.URL.Query().Get("request_uri") != "")
+			a.mutex.Unlock()
+		}
+		server.ServeHTTP(w, r)
+	})
+}
+
+// startServer starts the mock bank configured by configure, its certificates are written to a temporary folder
+func startServer(t *testing.T, configure func(*aspsptest.Server), failPAR bool) (*aspsptest.Server, aspsptest.Files, *authorizationRequests) {
+	t.Helper()
+
+	certificates, err := aspsptest.NewCertificates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := aspsptest.NewUnstartedServer(certificates, aspsptest.DefaultFixtures())
+	if configure != nil {
+		configure(server)
+	}
+	requests := &authorizationRequests{}
+	server.Config.Handler = requests.handler(server, failPAR)
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	files, err := certificates.WriteFiles(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return server, files, requests
+}
+
+// authenticate registers a client and gets an accounts access token from the mock bank
+func authenticate(server *aspsptest.Server, files aspsptest.Files) (authorization.Token, error) {
+	register, err := authorization.NewClientRegisterBuilder().
+		WithWellKnown(server.DiscoveryURL()).
+		WithSigPublicKeyFile(files.SigPublicKeyFile).
+		WithSigPrivateKeyFile(files.SigPrivateKeyFile).
+		WithCertFile(files.CertFile).
+		WithKeyFile(files.KeyFile).
+		WithRootCAs([]string{files.RootCA}).
+		WithRedirectUrl("http://localhost:8081/callback").
+		WithSoftwareStatementID("mockbank-tpp").
+		WithSoftwareStatementName("obcli").
+		Build()
+	if err != nil {
+		return authorization.NoToken, err
+	}
+
+	client, err := register.Register()
+	if err != nil {
+		return authorization.NoToken, err
+	}
+
+	authenticator, err := authorization.NewAuthenticatorBuilder().
+		WithWellKnown(server.DiscoveryURL()).
+		WithClient(client).
+		WithFapiFinancialId("0015800001041RHAAY").
+		WithAccessConsentEndpoint(server.AISURL()).
+		WithCertFile(files.CertFile).
+		WithKeyFile(files.KeyFile).
+		WithRootCAs([]string{files.RootCA}).
+		WithRedirectUrl("http://localhost:8081/callback").
+		WithSigPublicKeyFile(files.SigPublicKeyFile).
+		WithSigPrivateKeyFile(files.SigPrivateKeyFile).
+		WithAuthoriser(server.Authoriser()).
+		Build()
+	if err != nil {
+		return authorization.NoToken, err
+	}
+
+	return authenticator.Authenticate()
+}
+
+func resourceClient(t *testing.T, server *aspsptest.Server, files aspsptest.Files, token authorization.Token) aspsp.ResourceClient {
+	t.Helper()
+
+	client, err := aspsp.NewResourceClient(
+		authorization.NewSecureTransport(files.CertFile, files.KeyFile, []string{files.RootCA}),
+		server.AISURL(),
+		aspsp.NewStaticTokenSource(token),
+		authorization.FapiHeaders{FinancialId: "0015800001041RHAAY"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestAuthenticate(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func(*aspsptest.Server)
+		failPAR   bool
+		// wantPushed tells whether the authorization endpoint is expected to get a request_uri
+		wantPushed bool
+		wantErr    bool
+	}{
+		{name: "pushed request object", wantPushed: true},
+		{name: "front channel request object", configure: func(s *aspsptest.Server) { s.NoPAR = true }},
+		{name: "failed push falls back to the front channel", failPAR: true},
+		{name: "required push", configure: func(s *aspsptest.Server) { s.RequirePAR = true }, wantPushed: true},
+		{name: "failed required push", configure: func(s *aspsptest.Server) { s.RequirePAR = true }, failPAR: true, wantErr: true},
+		{name: "jarm", configure: func(s *aspsptest.Server) { s.JARM = true }, wantPushed: true},
+		{name: "jarm front channel", configure: func(s *aspsptest.Server) {
+			s.JARM = true
+			s.NoPAR = true
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, files, requests := startServer(t, test.configure, test.failPAR)
+
+			token, err := authenticate(server, files)
+			if test.wantErr {
+				if err == nil {
+					t.Fatal("authenticated, want error")
+				}
+				if len(requests.pushed) != 0 {
+					t.Errorf("authorization endpoint called %d times, want none", len(requests.pushed))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if token.AccessToken == "" {
+				t.Fatal("no access token")
+			}
+			if len(requests.pushed) != 1 || requests.pushed[0] != test.wantPushed {
+				t.Errorf("authorization requests pushed %v, want [%t]", requests.pushed, test.wantPushed)
+			}
+
+			accounts, err := aspsp.NewAccountLister(resourceClient(t, server, files, token)).List()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []aspsp.AccountId
+			for _, account := range accounts {
+				ids = append(ids, account.Id())
+			}
+			if len(ids) != 2 || ids[0] != "22289" || ids[1] != "31820" {
+				t.Errorf("got accounts %v, want [22289 31820]", ids)
+			}
+		})
+	}
+}
+
+func TestTransactionPages(t *testing.T) {
+	may := func(day int) time.Time {
+		return time.Date(2019, 5, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name      string
+		pageSize  int
+		from, to  time.Time
+		wantIds   []string
+		wantPages int
+	}{
+		{name: "single page", wantIds: []string{"123", "124", "125", "126"}, wantPages: 1},
+		{name: "page per transaction", pageSize: 1, wantIds: []string{"123", "124", "125", "126"}, wantPages: 4},
+		{name: "last page partial", pageSize: 3, wantIds: []string{"123", "124", "125", "126"}, wantPages: 2},
+		{name: "booked from", pageSize: 2, from: may(3), wantIds: []string{"125", "126"}, wantPages: 1},
+		{name: "booked between", pageSize: 1, from: may(2), to: may(4), wantIds: []string{"124", "125"}, wantPages: 2},
+		{name: "none booked", pageSize: 2, from: may(20), wantIds: nil, wantPages: 1},
+	}
+
+	server, files, _ := startServer(t, nil, false)
+	token, err := authenticate(server, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, err := aspsp.NewAccountLister(resourceClient(t, server, files, token)).Get("22289")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server.PageSize = test.pageSize
+
+			var ids []string
+			var credits []bool
+			iterator := account.TransactionIterator(test.from, test.to)
+			for iterator.Next() {
+				ids = append(ids, iterator.Transaction().Id)
+				credits = append(credits, iterator.Transaction().Credit())
+			}
+			if err := iterator.Err(); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(ids) != len(test.wantIds) {
+				t.Fatalf("got transactions %v, want %v", ids, test.wantIds)
+			}
+			for i := range ids {
+				if ids[i] != test.wantIds[i] {
+					t.Fatalf("got transactions %v, want %v", ids, test.wantIds)
+				}
+				// only the first fixture transaction is a credit
+				if credits[i] != (ids[i] == "123") {
+					t.Errorf("transaction %s credit %t", ids[i], credits[i])
+				}
+			}
+			if page := iterator.Page(); page.Number != test.wantPages {
+				t.Errorf("fetched %d pages, want %d", page.Number, test.wantPages)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package aspsptest
+
+import (
+	"bytes"
+	"github.com/jmatosp/obclient/authorization"
+	"io/ioutil"
+	"net/http"
+)
+
+// ClientSigningKeyId is the kid of Certificates.SigningKey the TPP signs x-jws-signature with
+const ClientSigningKeyId = "mocktpp"
+
+// verifySignature checks the x-jws-signature of the request body with the client signing key, leaving
+// the body to be read again, PISP and CBPII POSTs must be signed
+func (s *Server) verifySignature(w http.ResponseWriter, r *http.Request) bool {
+	signature := r.Header.Get("x-jws-signature")
+	if signature == "" {
+		writeOBError(w, http.StatusBadRequest, "UK.OBIE.Signature.Missing", "x-jws-signature required")
+		return false
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeOBError(w, http.StatusBadRequest, "UK.OBIE.Field.Invalid", err.Error())
+		return false
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	header, err := authorization.VerifyDetached(signature, body, &s.Certificates.SigningKey.PublicKey)
+	if err != nil {
+		writeOBError(w, http.StatusBadRequest, "UK.OBIE.Signature.Invalid", err.Error())
+		return false
+	}
+	if header["kid"] != ClientSigningKeyId || header[authorization.HeaderJWSTrustAnchor] != authorization.TrustAnchorOpenBanking {
+		writeOBError(w, http.StatusBadRequest, "UK.OBIE.Signature.InvalidClaim", "unknown kid or trust anchor")
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,92 @@
+package aspsptest
+
+import (
+	"net/http"
+	"time"
+)
+
+// revoke implements RFC 7009, revoking a refresh token also revokes the access tokens of its consent.
+// Unknown tokens and tokens of other clients are ignored as the RFC requires
+func (s *Server) revoke(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	clientId, ok := s.authenticateClient(w, r)
+	if !ok {
+		return
+	}
+
+	token := r.PostForm.Get("token")
+	s.mutex.Lock()
+	if g, found := s.grants[token]; found && g.clientId == clientId {
+		delete(s.grants, token)
+	}
+	if g, found := s.refreshTokens[token]; found && g.clientId == clientId {
+		delete(s.refreshTokens, token)
+		for accessToken, issued := range s.grants {
+			if issued.clientId == clientId && issued.consentId == g.consentId {
+				delete(s.grants, accessToken)
+			}
+		}
+	}
+	s.mutex.Unlock()
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// introspect implements RFC 7662, tokens are inactive once expired, revoked or their consent is
+// no longer authorised, and to clients other than the one they were issued to
+func (s *Server) introspect(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	clientId, ok := s.authenticateClient(w, r)
+	if !ok {
+		return
+	}
+
+	token := r.PostForm.Get("token")
+	s.mutex.Lock()
+	g, found := s.grants[token]
+	tokenType := "Bearer"
+	if !found {
+		g, found = s.refreshTokens[token]
+		tokenType = "refresh_token"
+	}
+	active := found && g.clientId == clientId
+	if active && tokenType == "Bearer" {
+		active = time.Now().Before(g.expires)
+	}
+	if active && g.consentId != "" {
+		intent := s.consents[g.consentId]
+		active = intent != nil && intent.status == "Authorised"
+	}
+	s.mutex.Unlock()
+
+	if !active {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"active": false})
+		return
+	}
+
+	introspection := map[string]interface{}{
+		"active":     true,
+		"scope":      g.scope,
+		"client_id":  g.clientId,
+		"token_type": tokenType,
+		"iss":        baseURL(r),
+		"sub":        g.clientId,
+	}
+	if g.consentId != "" {
+		introspection["sub"] = g.consentId
+		introspection["openbanking_intent_id"] = g.consentId
+	}
+	if tokenType == "Bearer" {
+		introspection["exp"] = g.expires.Unix()
+		introspection["iat"] = g.expires.Add(-tokenExpiration).Unix()
+	}
+	writeJSON(w, http.StatusOK, introspection)
+}
@@ -0,0 +1,374 @@
+package aspsptest
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/jmatosp/obclient/aspsp"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var vrpKind = paymentKind{"/domestic-vrp-consents", "/domestic-vrps", "DomesticVRPId", "AcceptedSettlementInProcess", false}
+
+type vrpControlParameters struct {
+	PSUAuthenticationMethods []string        `json:"PSUAuthenticationMethods"`
+	VRPType                  []string        `json:"VRPType"`
+	ValidFromDateTime        string          `json:"ValidFromDateTime,omitempty"`
+	ValidToDateTime          string          `json:"ValidToDateTime,omitempty"`
+	MaximumIndividualAmount  amount          `json:"MaximumIndividualAmount"`
+	PeriodicLimits           []periodicLimit `json:"PeriodicLimits"`
+}
+
+type periodicLimit struct {
+	Amount          string `json:"Amount"`
+	Currency        string `json:"Currency"`
+	PeriodAlignment string `json:"PeriodAlignment"`
+	PeriodType      string `json:"PeriodType"`
+}
+
+type vrpInitiation struct {
+	DebtorAccount   *cashAccount `json:"DebtorAccount"`
+	CreditorAccount *cashAccount `json:"CreditorAccount"`
+}
+
+type vrpInstruction struct {
+	InstructionIdentification string          `json:"InstructionIdentification"`
+	EndToEndIdentification    string          `json:"EndToEndIdentification"`
+	RemittanceInformation     json.RawMessage `json:"RemittanceInformation,omitempty"`
+	InstructedAmount          amount          `json:"InstructedAmount"`
+	CreditorAccount           *cashAccount    `json:"CreditorAccount"`
+}
+
+// periodLengths are years, months and days of VRP periodic limit period types
+var periodLengths = map[string][3]int{
+	"Day":       {0, 0, 1},
+	"Week":      {0, 0, 7},
+	"Fortnight": {0, 0, 14},
+	"Month":     {0, 1, 0},
+	"Half-year": {0, 6, 0},
+	"Year":      {1, 0, 0},
+}
+
+func (s *Server) vrpConsents(w http.ResponseWriter, r *http.Request, resource string) {
+	if strings.HasSuffix(resource, "/funds-confirmation") {
+		s.vrpFundsConfirmation(w, r, strings.TrimSuffix(strings.TrimPrefix(resource, "/"), "/funds-confirmation"))
+		return
+	}
+
+	g, ok := s.clientCredentials(w, r)
+	if !ok {
+		return
+	}
+	if resource != "" {
+		s.consentResource(w, r, g, PISPPath+vrpKind.consents, strings.TrimPrefix(resource, "/"))
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.replayConsent(w, r, g) {
+		return
+	}
+
+	var request struct {
+		Data struct {
+			ReadRefundAccount string               `json:"ReadRefundAccount"`
+			ControlParameters vrpControlParameters `json:"ControlParameters"`
+			Initiation        json.RawMessage      `json:"Initiation"`
+		} `json:"Data"`
+	}
+	var initiation vrpInitiation
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || json.Unmarshal(request.Data.Initiation, &initiation) != nil {
+		writeOBError(w, http.StatusBadRequest, "UK.OBIE.Field.Invalid", "Data.Initiation required")
+		return
+	}
+	if initiation.CreditorAccount == nil || initiation.CreditorAccount.Identification == "" {
+		writeOBError(w, http.StatusBadRequest, "UK.OBIE.Field.Missing", "Data.Initiation.CreditorAccount required")
+		return
+	}
+	if message := checkControlParameters(request.Data.ControlParameters); message != "" {
+		writeOBError(w, http.StatusBadRequest, "UK.OBIE.Field.Invalid", message)
+		return
+	}
+
+	intent := s.createConsent(g, "vrp-", "payments", PISPPath+vrpKind.consents, map[string]interface{}{
+		"ReadRefundAccount": request.Data.ReadRefundAccount,
+		"ControlParameters": request.Data.ControlParameters,
+		"Initiation":        request.Data.Initiation,
+	})
+	s.keepIdempotent(r, g, intent.id)
+	writeJSON(w, http.StatusCreated, consentDocument(r, intent))
+}
+
+// checkControlParameters returns why the control parameters are invalid, empty if they are valid
+func checkControlParameters(parameters vrpControlParameters) string {
+	if _, err := aspsp.ParseMoney(parameters.MaximumIndividualAmount.Amount, parameters.MaximumIndividualAmount.Currency); err != nil {
+		return "Data.ControlParameters.MaximumIndividualAmount invalid"
+	}
+	for _, limit := range parameters.PeriodicLimits {
+		if _, ok := periodLengths[limit.PeriodType]; !ok {
+			return "Data.ControlParameters.PeriodicLimits unknown PeriodType " + limit.PeriodType
+		}
+		if limit.PeriodAlignment != "Consent" && limit.PeriodAlignment != "Calendar" {
+			return "Data.ControlParameters.PeriodicLimits unknown PeriodAlignment " + limit.PeriodAlignment
+		}
+		if _, err := aspsp.ParseMoney(limit.Amount, limit.Currency); err != nil {
+			return "Data.ControlParameters.PeriodicLimits invalid amount"
+		}
+	}
+	return ""
+}
+
+// vrpConsent returns the authorised VRP consent of the PSU token, writing the error response otherwise
+func (s *Server) vrpConsent(w http.ResponseWriter, g grant, consentId string) (*consent, bool) {
+	if !s.authorised(w, g, "payments") {
+		return nil, false
+	}
+
+	s.mutex.Lock()
+	intent := s.consents[g.consentId]
+	s.mutex.Unlock()
+	if consentId != g.consentId || intent.path != PISPPath+vrpKind.consents {
+		writeOBError(w, http.StatusBadRequest, "UK.OBIE.Resource.ConsentMismatch", "ConsentId does not match the access token VRP consent")
+		return nil, false
+	}
+	return intent, true
+}
+
+// vrpFundsConfirmation answers from the available balance of the consent debtor account, funds
+// are reported available when the PSU chose the debtor account
+func (s *Server) vrpFundsConfirmation(w http.ResponseWriter, r *http.Request, id string) {
+	g, ok := s.bearer(w, r)
+	if !ok {
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	intent, ok := s.vrpConsent(w, g, id)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		Data struct {
+			ConsentId        string `json:"ConsentId"`
+			Reference        string `json:"Reference"`
+			InstructedAmount amount `json:"InstructedAmount"`
+		} `json:"Data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeOBError(w, http.StatusBadRequest, "UK.OBIE.Field.Invalid", err.Error())
+		return
+	}
+	instructed, err := aspsp.ParseMoney(request.Data.InstructedAmount.Amount, request.Data.InstructedAmount.Currency)
+	if err != nil {
+		writeOBError(w, http.StatusBadRequest, "UK.OBIE.Field.Invalid", err.Error())
+		return
+	}
+
+	s.mutex.Lock()
+	raw, _ := intent.data["Initiation"].(json.RawMessage)
+	s.mutex.Unlock()
+	var initiation vrpInitiation
+	json.Unmarshal(raw, &initiation)
+
+	available := "Available"
+	if initiation.DebtorAccount != nil {
+		balance, found := s.availableBalance(initiation.DebtorAccount.Identification)
+		cmp, err := instructed.Cmp(balance)
+		if !found || err != nil || cmp > 0 {
+			available = "NotAvailable"
+		}
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"Data": map[string]interface{}{
+			"FundsConfirmationId": "fc-" + uuid.New().String(),
+			"ConsentId":           id,
+			"CreationDateTime":    now,
+			"Reference":           request.Data.Reference,
+			"InstructedAmount":    request.Data.InstructedAmount,
+			"FundsAvailableResult": map[string]string{
+				"FundsAvailableDateTime": now,
+				"FundsAvailable":         available,
+			},
+		},
+		"Links": map[string]string{"Self": baseURL(r) + PISPPath + vrpKind.consents + "/" + id + "/funds-confirmation"},
+		"Meta":  map[string]interface{}{},
+	})
+}
+
+func (s *Server) vrps(w http.ResponseWriter, r *http.Request, resource string) {
+	g, ok := s.bearer(w, r)
+	if !ok {
+		return
+	}
+
+	if resource != "" {
+		s.paymentResource(w, r, g, vrpKind, strings.TrimPrefix(resource, "/"))
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idempotencyKey := g.clientId + "/" + r.Header.Get("x-idempotency-key")
+	s.mutex.Lock()
+	paymentId, replayed := s.idempotent[idempotencyKey]
+	s.mutex.Unlock()
+	if replayed && r.Header.Get("x-idempotency-key") != "" {
+		s.paymentDocument(w, r, http.StatusCreated, vrpKind, paymentId)
+		return
+	}
+
+	var request struct {
+		Data struct {
+			ConsentId               string          `json:"ConsentId"`
+			PSUAuthenticationMethod string          `json:"PSUAuthenticationMethod"`
+			Initiation              json.RawMessage `json:"Initiation"`
+			Instruction             vrpInstruction  `json:"Instruction"`
+		} `json:"Data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeOBError(w, http.StatusBadRequest, "UK.OBIE.Field.Invalid", err.Error())
+		return
+	}
+	intent, ok := s.vrpConsent(w, g, request.Data.ConsentId)
+	if !ok {
+		return
+	}
+
+	s.mutex.Lock()
+	consented, _ := intent.data["Initiation"].(json.RawMessage)
+	parameters, _ := intent.data["ControlParameters"].(vrpControlParameters)
+	s.mutex.Unlock()
+	if !sameJSON(consented, request.Data.Initiation) {
+		writeOBError(w, http.StatusBadRequest, "UK.OBIE.Resource.ConsentMismatch", "Data.Initiation does not match the consent")
+		return
+	}
+	var initiation vrpInitiation
+	json.Unmarshal(consented, &initiation)
+	instruction := request.Data.Instruction
+	if instruction.CreditorAccount == nil || instruction.CreditorAccount.Identification != initiation.CreditorAccount.Identification {
+		writeOBError(w, http.StatusBadRequest, "UK.OBIE.Rules.FailsControlParameters", "Data.Instruction.CreditorAccount does not match the consent")
+		return
+	}
+	instructed, err := aspsp.ParseMoney(instruction.InstructedAmount.Amount, instruction.InstructedAmount.Currency)
+	if err != nil {
+		writeOBError(w, http.StatusBadRequest, "UK.OBIE.Field.Invalid", err.Error())
+		return
+	}
+
+	now := time.Now().UTC()
+	if message := s.failsControlParameters(intent, parameters, instructed, now); message != "" {
+		writeOBError(w, http.StatusBadRequest, "UK.OBIE.Rules.FailsControlParameters", message)
+		return
+	}
+
+	created := &payment{
+		clientId: g.clientId,
+		kind:     vrpKind,
+		data: map[string]interface{}{
+			vrpKind.idMember:       "vrp-pay-" + uuid.New().String(),
+			"ConsentId":            intent.id,
+			"Status":               vrpKind.status,
+			"CreationDateTime":     now.Format(time.RFC3339),
+			"StatusUpdateDateTime": now.Format(time.RFC3339),
+			"Initiation":           request.Data.Initiation,
+			"Instruction":          instruction,
+		},
+	}
+	s.mutex.Lock()
+	paymentId = created.data[vrpKind.idMember].(string)
+	s.payments[paymentId] = created
+	if r.Header.Get("x-idempotency-key") != "" {
+		s.idempotent[idempotencyKey] = paymentId
+	}
+	s.mutex.Unlock()
+
+	s.notify(r, g.clientId, eventResourceUpdate, PISPPath+vrpKind.payments, paymentId, nil)
+	s.paymentDocument(w, r, http.StatusCreated, vrpKind, paymentId)
+}
+
+// failsControlParameters returns why instructed breaks the consent control parameters, empty if it does not
+func (s *Server) failsControlParameters(intent *consent, parameters vrpControlParameters, instructed aspsp.Money, now time.Time) string {
+	validFrom, _ := time.Parse(time.RFC3339, parameters.ValidFromDateTime)
+	validTo, _ := time.Parse(time.RFC3339, parameters.ValidToDateTime)
+	if now.Before(validFrom) || (!validTo.IsZero() && now.After(validTo)) {
+		return "consent is not valid at this time"
+	}
+
+	maximum, _ := aspsp.ParseMoney(parameters.MaximumIndividualAmount.Amount, parameters.MaximumIndividualAmount.Currency)
+	if cmp, err := instructed.Cmp(maximum); err != nil || cmp > 0 {
+		return "amount exceeds MaximumIndividualAmount"
+	}
+
+	anchor := validFrom
+	if anchor.IsZero() {
+		anchor = intent.creationDateTime
+	}
+	for _, limit := range parameters.PeriodicLimits {
+		start := periodStart(now, anchor, limit.PeriodType, limit.PeriodAlignment)
+		total := instructed
+		s.mutex.Lock()
+		for _, paid := range s.payments {
+			if paid.kind != vrpKind || paid.data["ConsentId"] != intent.id {
+				continue
+			}
+			created, _ := time.Parse(time.RFC3339, paid.data["CreationDateTime"].(string))
+			if created.Before(start) {
+				continue
+			}
+			instruction := paid.data["Instruction"].(vrpInstruction)
+			amount, _ := aspsp.ParseMoney(instruction.InstructedAmount.Amount, instruction.InstructedAmount.Currency)
+			total, _ = total.Add(amount)
+		}
+		s.mutex.Unlock()
+
+		limitAmount, _ := aspsp.ParseMoney(limit.Amount, limit.Currency)
+		if cmp, err := total.Cmp(limitAmount); err != nil || cmp > 0 {
+			return "amount exceeds the " + limit.PeriodType + " periodic limit"
+		}
+	}
+	return ""
+}
+
+// periodStart returns the start of the period now is in, Consent aligned periods repeat from anchor
+func periodStart(now, anchor time.Time, periodType, alignment string) time.Time {
+	length := periodLengths[periodType]
+	if alignment == "Consent" {
+		start := anchor
+		for !start.AddDate(length[0], length[1], length[2]).After(now) {
+			start = start.AddDate(length[0], length[1], length[2])
+		}
+		return start
+	}
+
+	year, month, day := now.Date()
+	switch periodType {
+	case "Week", "Fortnight":
+		start := time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+		start = start.AddDate(0, 0, -((int(start.Weekday()) + 6) % 7))
+		if _, week := start.ISOWeek(); periodType == "Fortnight" && week%2 == 0 {
+			start = start.AddDate(0, 0, -7)
+		}
+		return start
+	case "Month":
+		return time.Date(year, month, 1, 0, 0, 0, 0, now.Location())
+	case "Half-year":
+		if month > time.June {
+			return time.Date(year, time.July, 1, 0, 0, 0, 0, now.Location())
+		}
+		return time.Date(year, time.January, 1, 0, 0, 0, 0, now.Location())
+	case "Year":
+		return time.Date(year, time.January, 1, 0, 0, 0, 0, now.Location())
+	default:
+		return time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+	}
+}
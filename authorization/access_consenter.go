@@ -2,8 +2,8 @@ package authorization
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	"net/http"
 	"time"
@@ -11,6 +11,7 @@ import (
 
 type AccessConsenter interface {
 	Request(GrantToken) (AccessConsent, error)
+	RequestContext(context.Context, GrantToken) (AccessConsent, error)
 }
 
 type accessConsenter struct {
@@ -28,6 +29,10 @@ func NewAccessConsenter(transport Transport, endpoint, fapiFinancialId string) A
 }
 
 func (a accessConsenter) Request(token GrantToken) (AccessConsent, error) {
+	return a.RequestContext(context.Background(), token)
+}
+
+func (a accessConsenter) RequestContext(ctx context.Context, token GrantToken) (AccessConsent, error) {
 	client, err := a.transport.Client()
 	if err != nil {
 		return NoAccessConsent, errors.Wrap(err, "error getting access consent")
@@ -38,19 +43,23 @@ func (a accessConsenter) Request(token GrantToken) (AccessConsent, error) {
 		return NoAccessConsent, errors.Wrap(err, "error getting access consent")
 	}
 
-	request, err := http.NewRequest(http.MethodPost, a.endpoint+"/account-access-consents", bytes.NewBuffer(data))
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, a.endpoint+"/account-access-consents", bytes.NewBuffer(data))
 	if err != nil {
 		return NoAccessConsent, errors.Wrap(err, "error getting access consent")
 	}
 	request.Header.Set("Authorization", "Bearer "+token.AccessToken)
 	request.Header.Set("Content-Type", "application/json")
-	request.Header.Set("x-fapi-financial-id", a.fapiFinancialId)
-	request.Header.Set("x-fapi-interaction-id", uuid.New().String())
+	interactionId := FapiHeaders{FinancialId: a.fapiFinancialId}.Apply(request)
 
 	response, err := client.Do(request)
 	if err != nil {
 		return NoAccessConsent, errors.Wrap(err, "error getting access consent")
 	}
+	defer response.Body.Close()
+
+	if err = CheckInteractionId(response, interactionId); err != nil {
+		return NoAccessConsent, errors.Wrap(err, "error getting access consent")
+	}
 
 	if response.StatusCode != http.StatusCreated {
 		return NoAccessConsent, errors.Errorf("error getting access consent: unexpected response status code %d", response.StatusCode)
@@ -61,13 +70,22 @@ func (a accessConsenter) Request(token GrantToken) (AccessConsent, error) {
 		return NoAccessConsent, errors.Wrap(err, "error getting access consent")
 	}
 
-	return AccessConsent{accessConsentResponse.Data.ConsentId}, nil
+	return AccessConsent{ConsentId: accessConsentResponse.Data.ConsentId, Scope: ScopeAccounts}, nil
 }
 
 var NoAccessConsent = AccessConsent{}
 
 type AccessConsent struct {
 	ConsentId string
+	// Scope the consent is authorised for, ScopeAccounts when empty
+	Scope string
+}
+
+func (c AccessConsent) scope() string {
+	if c.Scope == "" {
+		return "openid " + ScopeAccounts
+	}
+	return "openid " + c.Scope
 }
 
 type AccessConsentResponse struct {
@@ -103,8 +121,12 @@ var AccountsReadConsent = AccessConsentRequest{
 			"ReadBalances",
 			"ReadBeneficiariesDetail",
 			"ReadDirectDebits",
+			"ReadOffers",
+			"ReadParty",
 			"ReadProducts",
+			"ReadScheduledPaymentsDetail",
 			"ReadStandingOrdersDetail",
+			"ReadStatementsDetail",
 			"ReadTransactionsCredits",
 			"ReadTransactionsDebits",
 			"ReadTransactionsDetail",
@@ -1,11 +1,16 @@
 package authorization
 
 import (
+	"context"
 	"github.com/pkg/errors"
 )
 
 type Authenticator interface {
 	Authenticate() (Token, error)
+	AuthenticateContext(context.Context) (Token, error)
+	// AuthenticateConsent also returns the account access consent the token is bound to
+	AuthenticateConsent() (Token, AccessConsent, error)
+	AuthenticateConsentContext(context.Context) (Token, AccessConsent, error)
 }
 
 type authenticator struct {
@@ -30,25 +35,38 @@ func NewAuthenticator(
 }
 
 func (a authenticator) Authenticate() (Token, error) {
-	grantsToken, err := a.credentialsGranter.Request()
+	return a.AuthenticateContext(context.Background())
+}
+
+func (a authenticator) AuthenticateContext(ctx context.Context) (Token, error) {
+	token, _, err := a.AuthenticateConsentContext(ctx)
+	return token, err
+}
+
+func (a authenticator) AuthenticateConsent() (Token, AccessConsent, error) {
+	return a.AuthenticateConsentContext(context.Background())
+}
+
+func (a authenticator) AuthenticateConsentContext(ctx context.Context) (Token, AccessConsent, error) {
+	grantsToken, err := a.credentialsGranter.RequestContext(ctx)
 	if err != nil {
-		return NoToken, errors.Wrap(err, "error authenticating")
+		return NoToken, NoAccessConsent, errors.Wrap(err, "error authenticating")
 	}
 
-	accessConsent, err := a.accessConsenter.Request(grantsToken)
+	accessConsent, err := a.accessConsenter.RequestContext(ctx, grantsToken)
 	if err != nil {
-		return NoToken, errors.Wrap(err, "error authenticating")
+		return NoToken, NoAccessConsent, errors.Wrap(err, "error authenticating")
 	}
 
-	code, err := a.psuAccessConsenter.Request(accessConsent)
+	code, err := a.psuAccessConsenter.RequestContext(ctx, accessConsent)
 	if err != nil {
-		return NoToken, errors.Wrap(err, "error authenticating")
+		return NoToken, NoAccessConsent, errors.Wrap(err, "error authenticating")
 	}
 
-	token, err := a.tokenGenerator.Request(code)
+	token, err := a.tokenGenerator.RequestContext(ctx, code)
 	if err != nil {
-		return NoToken, errors.Wrap(err, "error authenticating")
+		return NoToken, NoAccessConsent, errors.Wrap(err, "error authenticating")
 	}
 
-	return token, nil
+	return token, accessConsent, nil
 }
@@ -1,6 +1,7 @@
 package authorization
 
 import (
+	"context"
 	"errors"
 )
 
@@ -10,19 +11,35 @@ type AuthenticatorBuilder struct {
 	accessConsentEndpoint string
 	wellKnownEndpoint     string
 	redirectUrl           string
+	sigPublicKeyFile      string
+	sigPrivateKeyFile     string
 	certFile              string
 	keyFile               string
 	rootCAs               []string
+	authoriser            Authoriser
 }
 
 func NewAuthenticatorBuilder() *AuthenticatorBuilder {
-	return &AuthenticatorBuilder{}
+	return &AuthenticatorBuilder{
+		authoriser: NewBrowserAuthoriser(),
+	}
 }
 
 func (c *AuthenticatorBuilder) Build() (Authenticator, error) {
-	err := c.mustValidate()
+	if err := c.mustValidate(); err != nil {
+		return nil, err
+	}
+
+	if c.accessConsentEndpoint == "" {
+		return nil, errors.New("error accessConsentEndpoint not provided")
+	}
 
-	config, err := GetConfiguration(c.wellKnownEndpoint)
+	config, err := c.fetchConfiguration()
+	if err != nil {
+		return nil, err
+	}
+
+	psuAccessConsenter, err := c.makePSUAccessConsenter(config)
 	if err != nil {
 		return nil, err
 	}
@@ -30,11 +47,94 @@ func (c *AuthenticatorBuilder) Build() (Authenticator, error) {
 	return NewAuthenticator(
 		c.makeCredentialsGranter(config),
 		c.makeAccessConsenter(),
-		c.makePSUAccessConsenter(),
-		c.makeTokenGenerator(config),
+		psuAccessConsenter,
+		c.makeTokenGenerator(config, ScopeAccounts),
+	), nil
+}
+
+// BuildIntentAuthenticator builds an IntentAuthenticator granting client credentials for scope,
+// the access consent endpoint is not needed as the caller creates the consents
+func (c *AuthenticatorBuilder) BuildIntentAuthenticator(scope string) (IntentAuthenticator, error) {
+	if err := c.mustValidate(); err != nil {
+		return nil, err
+	}
+
+	config, err := c.fetchConfiguration()
+	if err != nil {
+		return nil, err
+	}
+
+	psuAccessConsenter, err := c.makePSUAccessConsenter(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewIntentAuthenticator(
+		NewScopedCredentialsGranter(c.makeSecuredTransport(), config.TokenEndpoint, c.client, scope),
+		psuAccessConsenter,
+		c.makeTokenGenerator(config, scope),
 	), nil
 }
 
+// BuildTokenGenerator builds a TokenGenerator on the token endpoint of the openid configuration, e.g. to refresh tokens
+func (c *AuthenticatorBuilder) BuildTokenGenerator() (TokenGenerator, error) {
+	if err := c.mustValidate(); err != nil {
+		return nil, err
+	}
+
+	config, err := c.fetchConfiguration()
+	if err != nil {
+		return nil, err
+	}
+
+	return c.makeTokenGenerator(config, ScopeAccounts), nil
+}
+
+// BuildTokenRevoker builds a TokenRevoker on the revocation endpoint of the openid configuration
+func (c *AuthenticatorBuilder) BuildTokenRevoker() (TokenRevoker, error) {
+	if err := c.mustValidate(); err != nil {
+		return nil, err
+	}
+
+	config, err := c.fetchConfiguration()
+	if err != nil {
+		return nil, err
+	}
+
+	if config.RevocationEndpoint == "" {
+		return nil, errors.New("error revocation_endpoint not in openid configuration")
+	}
+
+	return NewTokenRevoker(c.makeSecuredTransport(), config.RevocationEndpoint, c.client), nil
+}
+
+// BuildTokenIntrospector builds a TokenIntrospector on the introspection endpoint of the openid configuration
+func (c *AuthenticatorBuilder) BuildTokenIntrospector() (TokenIntrospector, error) {
+	if err := c.mustValidate(); err != nil {
+		return nil, err
+	}
+
+	config, err := c.fetchConfiguration()
+	if err != nil {
+		return nil, err
+	}
+
+	if config.IntrospectionEndpoint == "" {
+		return nil, errors.New("error introspection_endpoint not in openid configuration")
+	}
+
+	return NewTokenIntrospector(c.makeSecuredTransport(), config.IntrospectionEndpoint, c.client), nil
+}
+
+func (c *AuthenticatorBuilder) fetchConfiguration() (Configuration, error) {
+	discovery, err := NewDiscoveryTransport(c.rootCAs).Client()
+	if err != nil {
+		return NoConfiguration, err
+	}
+
+	return FetchConfiguration(context.Background(), discovery, c.wellKnownEndpoint)
+}
+
 func (c *AuthenticatorBuilder) mustValidate() error {
 	if c.client.Id == "" || c.client.Secret == "" {
 		return errors.New("error client not provided")
@@ -44,10 +144,6 @@ func (c *AuthenticatorBuilder) mustValidate() error {
 		return errors.New("error fapiFinancialId not provided")
 	}
 
-	if c.accessConsentEndpoint == "" {
-		return errors.New("error accessConsentEndpoint not provided")
-	}
-
 	if c.wellKnownEndpoint == "" {
 		return errors.New("error wellKnownEndpoint not provided")
 	}
@@ -56,6 +152,14 @@ func (c *AuthenticatorBuilder) mustValidate() error {
 		return errors.New("error redirectUrl not provided")
 	}
 
+	if c.sigPublicKeyFile == "" {
+		return errors.New("error sigPublicKeyFile not provided")
+	}
+
+	if c.sigPrivateKeyFile == "" {
+		return errors.New("error sigPrivateKeyFile not provided")
+	}
+
 	if c.certFile == "" {
 		return errors.New("error certFile not provided")
 	}
@@ -96,6 +200,22 @@ func (c *AuthenticatorBuilder) WithRedirectUrl(url string) *AuthenticatorBuilder
 	return c
 }
 
+func (c *AuthenticatorBuilder) WithSigPublicKeyFile(filename string) *AuthenticatorBuilder {
+	c.sigPublicKeyFile = filename
+	return c
+}
+
+func (c *AuthenticatorBuilder) WithSigPrivateKeyFile(filename string) *AuthenticatorBuilder {
+	c.sigPrivateKeyFile = filename
+	return c
+}
+
+// WithAuthoriser sets how the user gives consent, defaults to NewBrowserAuthoriser
+func (c *AuthenticatorBuilder) WithAuthoriser(authoriser Authoriser) *AuthenticatorBuilder {
+	c.authoriser = authoriser
+	return c
+}
+
 func (c *AuthenticatorBuilder) WithCertFile(filename string) *AuthenticatorBuilder {
 	c.certFile = filename
 	return c
@@ -135,20 +255,62 @@ func (c *AuthenticatorBuilder) makeAccessConsenter() AccessConsenter {
 	)
 }
 
-func (c *AuthenticatorBuilder) makePSUAccessConsenter() PSUAccessConsenter {
+func (c *AuthenticatorBuilder) makePSUAccessConsenter(config Configuration) (PSUAccessConsenter, error) {
+	certificate := NewSafeCertificates(
+		c.sigPublicKeyFile,
+		c.sigPrivateKeyFile,
+	)
+
+	signer, err := NewSigner(certificate, config.ObjectSignAlgSupported)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys KeySet
+	if config.SupportsJARM() {
+		discovery, err := NewDiscoveryTransport(c.rootCAs).Client()
+		if err != nil {
+			return nil, err
+		}
+		keys = NewKeySet(discovery, config.JWKSURI)
+	}
+
+	if config.RequirePAR && config.PAREndpoint == "" {
+		return nil, errors.New("error pushed authorization requests are required without a pushed_authorization_request_endpoint")
+	}
+
+	if config.PAREndpoint != "" {
+		return NewPushedPSUAccessConsenter(
+			c.makeSecuredTransport(),
+			config.PAREndpoint,
+			config.AuthorizationEndpoint,
+			config.Issuer,
+			c.redirectUrl,
+			c.client,
+			signer,
+			c.authoriser,
+			keys,
+			config.RequirePAR,
+		), nil
+	}
+
 	return NewPSUAccessConsenter(
-		c.makeSecuredTransport(),
-		c.accessConsentEndpoint,
+		config.AuthorizationEndpoint,
+		config.Issuer,
 		c.redirectUrl,
 		c.client,
-	)
+		signer,
+		c.authoriser,
+		keys,
+	), nil
 }
 
-func (c *AuthenticatorBuilder) makeTokenGenerator(config Configuration) TokenGenerator {
-	return NewTokenGenerator(
+func (c *AuthenticatorBuilder) makeTokenGenerator(config Configuration, scope string) TokenGenerator {
+	return NewScopedTokenGenerator(
 		c.makeSecuredTransport(),
 		config.TokenEndpoint,
 		c.redirectUrl,
 		c.client,
+		scope,
 	)
 }
@@ -0,0 +1,240 @@
+package authorization
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/skratchdot/open-golang/open"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+)
+
+// Authoriser takes the PSU through the authorization url and returns the code
+// delivered to the redirect url
+type Authoriser interface {
+	Authorise(AuthorisationRequest) (Code, error)
+	AuthoriseContext(context.Context, AuthorisationRequest) (Code, error)
+}
+
+type AuthorisationRequest struct {
+	Url         string
+	RedirectUrl string
+	State       string
+	// verifyResponse decodes JARM responses with the ASPSP keys, set by PSU flows that request them
+	verifyResponse func(string) (Code, error)
+}
+
+// CodeFromRedirect extracts the code of the redirect url the request was answered with, see CodeFromRedirect,
+// JARM responses are verified and checked against the request state
+func (r AuthorisationRequest) CodeFromRedirect(redirect string) (Code, error) {
+	params, err := redirectParams(redirect)
+	if err != nil {
+		return NoCode, err
+	}
+
+	return codeFromParams(params, r)
+}
+
+type browserAuthoriser struct{}
+
+// NewBrowserAuthoriser opens the authorization url in the local browser and
+// listens for the callback on the redirect url host and port
+func NewBrowserAuthoriser() Authoriser {
+	return browserAuthoriser{}
+}
+
+func (b browserAuthoriser) Authorise(request AuthorisationRequest) (Code, error) {
+	return b.AuthoriseContext(context.Background(), request)
+}
+
+func (b browserAuthoriser) AuthoriseContext(ctx context.Context, request AuthorisationRequest) (Code, error) {
+	addr, err := callbackAddr(request.RedirectUrl)
+	if err != nil {
+		return NoCode, errors.Wrap(err, "error starting user access consent flow")
+	}
+
+	return waitCallback(ctx, addr, request, func() error {
+		if err := open.Run(request.Url); err != nil {
+			return errors.Wrap(err, "error initiating browser for user consent flow")
+		}
+		return nil
+	})
+}
+
+type webhookAuthoriser struct {
+	listenAddr string
+	out        io.Writer
+}
+
+// NewWebhookAuthoriser prints the authorization url to be opened on any device and
+// waits for the callback on listenAddr, the redirect url must be routed to it
+func NewWebhookAuthoriser(listenAddr string, out io.Writer) Authoriser {
+	return webhookAuthoriser{
+		listenAddr: listenAddr,
+		out:        out,
+	}
+}
+
+func (w webhookAuthoriser) Authorise(request AuthorisationRequest) (Code, error) {
+	return w.AuthoriseContext(context.Background(), request)
+}
+
+func (w webhookAuthoriser) AuthoriseContext(ctx context.Context, request AuthorisationRequest) (Code, error) {
+	return waitCallback(ctx, w.listenAddr, request, func() error {
+		fmt.Fprintf(w.out, "Open this url to give consent:\n\n%s\n\nWaiting for callback on %s\n", request.Url, w.listenAddr)
+		return nil
+	})
+}
+
+// CodeFromRedirect extracts the code of a redirect url, both query and fragment response modes,
+// JARM responses are rejected as they can only be verified by AuthorisationRequest.CodeFromRedirect
+func CodeFromRedirect(redirect, state string) (Code, error) {
+	return AuthorisationRequest{State: state}.CodeFromRedirect(redirect)
+}
+
+func redirectParams(redirect string) (url.Values, error) {
+	redirectUrl, err := url.Parse(redirect)
+	if err != nil {
+		return nil, errors.Wrap(err, "error parsing redirect url")
+	}
+
+	params := redirectUrl.Query()
+	if redirectUrl.Fragment != "" {
+		fragment, err := url.ParseQuery(redirectUrl.Fragment)
+		if err != nil {
+			return nil, errors.Wrap(err, "error parsing redirect url")
+		}
+		for name, values := range fragment {
+			params[name] = values
+		}
+	}
+
+	return params, nil
+}
+
+// codeFromParams checks the state of the authorization response of request, JARM responses carry it signed
+func codeFromParams(params url.Values, request AuthorisationRequest) (Code, error) {
+	if response := params.Get("response"); response != "" {
+		if request.verifyResponse == nil {
+			return NoCode, errors.New("error user access consent: unexpected JARM authorization response")
+		}
+		return request.verifyResponse(response)
+	}
+
+	if params.Get("state") != request.State {
+		return NoCode, errors.New("error user access consent: invalid state")
+	}
+
+	if params.Get("error") != "" {
+		return NoCode, errors.Errorf("error user access consent denied: %s %s", params.Get("error"), params.Get("error_description"))
+	}
+
+	if params.Get("code") == "" {
+		return NoCode, errors.New("error user access consent: no code in redirect")
+	}
+
+	return Code{Value: params.Get("code")}, nil
+}
+
+func callbackAddr(redirectUrl string) (string, error) {
+	callback, err := url.Parse(redirectUrl)
+	if err != nil {
+		return "", err
+	}
+
+	// listening on the scheme default port would need privileges, or TLS for https
+	if callback.Port() == "" {
+		return "", errors.Errorf("redirect url %s has no port to listen on", redirectUrl)
+	}
+
+	return net.JoinHostPort(callback.Hostname(), callback.Port()), nil
+}
+
+type callbackResult struct {
+	code Code
+	err  error
+}
+
+// waitCallback serves the redirect url on addr, calls start once listening and
+// waits for the authorization response
+func waitCallback(ctx context.Context, addr string, request AuthorisationRequest, start func() error) (Code, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return NoCode, errors.Wrap(err, "error starting user access consent flow")
+	}
+
+	resultChan := make(chan callbackResult, 1)
+	mux := http.NewServeMux()
+	srv := &http.Server{Handler: mux}
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("code") == "" && query.Get("error") == "" && query.Get("response") == "" {
+			// hybrid flow responses come in the url fragment, that browsers do not send
+			w.Write([]byte(fragmentToQueryPage))
+			return
+		}
+
+		if query.Get("response") == "" && query.Get("state") != request.State {
+			http.Error(w, "invalid state", http.StatusBadRequest)
+			return
+		}
+
+		code, err := codeFromParams(query, request)
+		select {
+		case resultChan <- callbackResult{code, err}:
+		default:
+		}
+		w.Write([]byte(authenticatedPage))
+	})
+
+	go func() {
+		srv.Serve(listener)
+	}()
+	defer srv.Shutdown(context.Background())
+
+	if err = start(); err != nil {
+		return NoCode, err
+	}
+
+	select {
+	case result := <-resultChan:
+		return result.code, result.err
+	case <-ctx.Done():
+		return NoCode, errors.Wrap(ctx.Err(), "error waiting user access consent")
+	}
+}
+
+const fragmentToQueryPage = `<!DOCTYPE HTML>
+<HTML><HEAD><TITLE>Open Banking Access Consent</TITLE></HEAD>
+<BODY>
+<script>
+if (window.location.hash) {
+  window.location.replace(window.location.pathname + "?" + window.location.hash.substring(1));
+}
+</script>
+</BODY>
+</HTML>
+`
+
+const authenticatedPage = `
+<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 4.0 
+Transitional//EN"> <HTML> <HEAD> 
+<TITLE>Open Banking Access Consent</TITLE> </HEAD>
+<BODY>
+<table border="0" width="100%">
+ <tr>
+  <td align="center"><font color=#330066 size="4"><strong>
+   Authenticated!</strong></font>
+  </td>
+ </tr>
+ <tr>
+  <td align="center"><font color=#330066>
+   (Please close this window)</font>
+  </td>
+ </tr>
+</table>
+</BODY>
+</HTML> 
+`
@@ -0,0 +1,78 @@
+package authorization
+
+import (
+	"github.com/pkg/errors"
+	"testing"
+)
+
+func TestCodeFromRedirect(t *testing.T) {
+	jarm := AuthorisationRequest{
+		State: testState,
+		verifyResponse: func(response string) (Code, error) {
+			if response != "signed" {
+				return NoCode, errors.New("invalid response")
+			}
+			return Code{Value: "from-jwt"}, nil
+		},
+	}
+	plain := AuthorisationRequest{State: testState}
+
+	tests := []struct {
+		name     string
+		request  AuthorisationRequest
+		redirect string
+		want     string
+		wantErr  bool
+	}{
+		{name: "query", request: plain, redirect: "http://localhost:8081/callback?code=a1b2c3&state=" + testState, want: "a1b2c3"},
+		{name: "fragment", request: plain, redirect: "http://localhost:8081/callback#code=a1b2c3&state=" + testState, want: "a1b2c3"},
+		{name: "invalid state", request: plain, redirect: "http://localhost:8081/callback?code=a1b2c3&state=other", wantErr: true},
+		{name: "no code", request: plain, redirect: "http://localhost:8081/callback?state=" + testState, wantErr: true},
+		{name: "denied", request: plain, redirect: "http://localhost:8081/callback?error=access_denied&state=" + testState, wantErr: true},
+		{name: "jarm", request: jarm, redirect: "http://localhost:8081/callback?response=signed", want: "from-jwt"},
+		{name: "jarm invalid", request: jarm, redirect: "http://localhost:8081/callback?response=forged&code=a1b2c3&state=" + testState, wantErr: true},
+		{name: "jarm not requested", request: plain, redirect: "http://localhost:8081/callback?response=signed&state=" + testState, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			code, err := test.request.CodeFromRedirect(test.redirect)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("got code %s, want error", code.Value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if code.Value != test.want {
+				t.Errorf("got code %s, want %s", code.Value, test.want)
+			}
+		})
+	}
+}
+
+func TestCallbackAddr(t *testing.T) {
+	tests := []struct {
+		redirectUrl string
+		want        string
+		wantErr     bool
+	}{
+		{redirectUrl: "http://localhost:8081/callback", want: "localhost:8081"},
+		{redirectUrl: "http://[::1]:8081", want: "[::1]:8081"},
+		{redirectUrl: "http://localhost/callback", wantErr: true},
+		{redirectUrl: "https://tpp.example.com/callback", wantErr: true},
+	}
+	for _, test := range tests {
+		addr, err := callbackAddr(test.redirectUrl)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("callbackAddr(%s) = %s, want error", test.redirectUrl, addr)
+			}
+			continue
+		}
+		if err != nil || addr != test.want {
+			t.Errorf("callbackAddr(%s) = %s, %v, want %s", test.redirectUrl, addr, err, test.want)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package authorization
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"github.com/dgrijalva/jwt-go"
 	"github.com/google/uuid"
@@ -13,6 +14,7 @@ import (
 
 type ClientRegister interface {
 	Register() (Client, error)
+	RegisterContext(context.Context) (Client, error)
 }
 
 func NewClientRegisterer(registrationEndpoint, issuer string, softwareStatement SoftwareStatement, transport Transport) ClientRegister {
@@ -32,6 +34,10 @@ type clientRegister struct {
 }
 
 func (o *clientRegister) Register() (Client, error) {
+	return o.RegisterContext(context.Background())
+}
+
+func (o *clientRegister) RegisterContext(ctx context.Context) (Client, error) {
 	client, err := o.transport.Client()
 	if err != nil {
 		return NoClient, err
@@ -42,7 +48,7 @@ func (o *clientRegister) Register() (Client, error) {
 		return NoClient, errors.Wrap(err, "error registering client")
 	}
 
-	request, err := http.NewRequest(http.MethodPost, o.registrationEndpoint, bytes.NewBufferString(payload))
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, o.registrationEndpoint, bytes.NewBufferString(payload))
 	if err != nil {
 		return NoClient, errors.Wrap(err, "error registering client")
 	}
@@ -52,6 +58,7 @@ func (o *clientRegister) Register() (Client, error) {
 	if err != nil {
 		return NoClient, errors.Wrap(err, "error registering client")
 	}
+	defer response.Body.Close()
 
 	if response.StatusCode != http.StatusCreated && response.StatusCode != http.StatusOK {
 		message, err := ioutil.ReadAll(response.Body)
@@ -1,6 +1,7 @@
 package authorization
 
 import (
+	"context"
 	"github.com/pkg/errors"
 )
 
@@ -21,9 +22,16 @@ func NewClientRegisterBuilder() *ClientRegisterBuilder {
 }
 
 func (c *ClientRegisterBuilder) Build() (ClientRegister, error) {
-	err := c.mustValidate()
+	if err := c.mustValidate(); err != nil {
+		return nil, err
+	}
+
+	discovery, err := NewDiscoveryTransport(c.rootCAs).Client()
+	if err != nil {
+		return nil, err
+	}
 
-	config, err := GetConfiguration(c.wellKnownEndpoint)
+	config, err := FetchConfiguration(context.Background(), discovery, c.wellKnownEndpoint)
 	if err != nil {
 		return nil, err
 	}
@@ -1,6 +1,7 @@
 package authorization
 
 import (
+	"context"
 	"encoding/json"
 	"github.com/pkg/errors"
 	"net/http"
@@ -11,17 +12,56 @@ type Configuration struct {
 	RegistrationEndpoint   string   `json:"registration_endpoint"`
 	TokenEndpoint          string   `json:"token_endpoint"`
 	Issuer                 string   `json:"issuer"`
+	JWKSURI                string   `json:"jwks_uri"`
+	RevocationEndpoint     string   `json:"revocation_endpoint"`
+	IntrospectionEndpoint  string   `json:"introspection_endpoint"`
+	PAREndpoint            string   `json:"pushed_authorization_request_endpoint"`
 	ObjectSignAlgSupported []string `json:"request_object_signing_alg_values_supported"`
+	ResponseModesSupported []string `json:"response_modes_supported"`
+	// RequirePAR rejects request objects sent in the front channel, they must be pushed to PAREndpoint
+	RequirePAR bool `json:"require_pushed_authorization_requests"`
+}
+
+// SupportsJARM tells whether the ASPSP signs authorization responses, JARM, and publishes the keys to verify them
+func (c Configuration) SupportsJARM() bool {
+	if c.JWKSURI == "" {
+		return false
+	}
+	for _, mode := range c.ResponseModesSupported {
+		if mode == ResponseModeJWT {
+			return true
+		}
+	}
+	return false
 }
 
 var NoConfiguration = Configuration{}
 
 func GetConfiguration(endpoint string) (Configuration, error) {
-	response, err := http.Get(endpoint)
+	return GetConfigurationContext(context.Background(), endpoint)
+}
+
+func GetConfigurationContext(ctx context.Context, endpoint string) (Configuration, error) {
+	return FetchConfiguration(ctx, http.DefaultClient, endpoint)
+}
+
+// FetchConfiguration gets the openid configuration using client, see NewDiscoveryTransport
+func FetchConfiguration(ctx context.Context, client *http.Client, endpoint string) (Configuration, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
 	if err != nil {
 		return NoConfiguration, errors.Wrap(err, "error getting openid configuration")
 	}
 
+	response, err := client.Do(request)
+	if err != nil {
+		return NoConfiguration, errors.Wrap(err, "error getting openid configuration")
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return NoConfiguration, errors.Errorf("error getting openid configuration: unexpected response status code %d", response.StatusCode)
+	}
+
 	var configuration Configuration
 	if err = json.NewDecoder(response.Body).Decode(&configuration); err != nil {
 		return NoConfiguration, errors.Wrap(err, "error getting openid configuration")
@@ -1,6 +1,7 @@
 package authorization
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/pkg/errors"
@@ -13,29 +14,41 @@ import (
 
 type CredentialsGranter interface {
 	Request() (GrantToken, error)
+	RequestContext(context.Context) (GrantToken, error)
 }
 
 type credentialsGranter struct {
 	transport Transport
 	endpoint  string
 	client    Client
+	scope     string
 }
 
 func NewCredentialGrander(transport Transport, tokenEndpoint string, client Client) CredentialsGranter {
+	return NewScopedCredentialsGranter(transport, tokenEndpoint, client, ScopeAccounts)
+}
+
+// NewScopedCredentialsGranter requests client credentials grants for an OB scope, see ScopeAccounts
+func NewScopedCredentialsGranter(transport Transport, tokenEndpoint string, client Client, scope string) CredentialsGranter {
 	return credentialsGranter{
 		transport: transport,
 		endpoint:  tokenEndpoint,
 		client:    client,
+		scope:     scope,
 	}
 }
 
 func (c credentialsGranter) Request() (GrantToken, error) {
+	return c.RequestContext(context.Background())
+}
+
+func (c credentialsGranter) RequestContext(ctx context.Context) (GrantToken, error) {
 	client, err := c.transport.Client()
 	if err != nil {
 		return NoGrantToken, errors.Wrap(err, "error getting credentials grant")
 	}
 
-	request, err := http.NewRequest(http.MethodPost, c.endpoint, credentialsGrantRequestReader())
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, c.credentialsGrantRequestReader())
 	if err != nil {
 		return NoGrantToken, errors.Wrap(err, "error getting credentials grant")
 	}
@@ -46,6 +59,7 @@ func (c credentialsGranter) Request() (GrantToken, error) {
 	if err != nil {
 		return NoGrantToken, errors.Wrap(err, "error getting credentials grant")
 	}
+	defer response.Body.Close()
 
 	if response.StatusCode != http.StatusOK {
 		body, _ := ioutil.ReadAll(response.Body)
@@ -72,9 +86,9 @@ type CredentialsGrantResponse struct {
 	ExpiresIn   int64  `json:"expires_in"`
 }
 
-func credentialsGrantRequestReader() io.Reader {
+func (c credentialsGranter) credentialsGrantRequestReader() io.Reader {
 	data := url.Values{}
 	data.Set("grant_type", "client_credentials")
-	data.Set("scope", "accounts openid")
+	data.Set("scope", c.scope+" openid")
 	return strings.NewReader(data.Encode())
 }
@@ -0,0 +1,63 @@
+package authorization
+
+import (
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"net/http"
+	"time"
+)
+
+const (
+	headerFapiFinancialId            = "x-fapi-financial-id"
+	headerFapiInteractionId          = "x-fapi-interaction-id"
+	headerFapiCustomerLastLoggedTime = "x-fapi-customer-last-logged-time"
+	headerFapiCustomerIPAddress      = "x-fapi-customer-ip-address"
+	headerFapiAuthDate               = "x-fapi-auth-date"
+	headerCustomerUserAgent          = "x-customer-user-agent"
+)
+
+var ErrInteractionIdMismatch = errors.New("error response interaction id does not match request")
+
+// FapiHeaders are the FAPI headers sent to ASPSP resource endpoints,
+// zero values are not sent
+type FapiHeaders struct {
+	FinancialId            string
+	CustomerLastLoggedTime time.Time
+	CustomerIPAddress      string
+	AuthDate               time.Time
+	CustomerUserAgent      string
+}
+
+// Apply sets FAPI headers on request and returns the interaction id used
+func (h FapiHeaders) Apply(request *http.Request) string {
+	interactionId := uuid.New().String()
+
+	request.Header.Set(headerFapiInteractionId, interactionId)
+	if h.FinancialId != "" {
+		request.Header.Set(headerFapiFinancialId, h.FinancialId)
+	}
+	if !h.CustomerLastLoggedTime.IsZero() {
+		request.Header.Set(headerFapiCustomerLastLoggedTime, h.CustomerLastLoggedTime.UTC().Format(http.TimeFormat))
+	}
+	if h.CustomerIPAddress != "" {
+		request.Header.Set(headerFapiCustomerIPAddress, h.CustomerIPAddress)
+	}
+	if !h.AuthDate.IsZero() {
+		request.Header.Set(headerFapiAuthDate, h.AuthDate.UTC().Format(http.TimeFormat))
+	}
+	if h.CustomerUserAgent != "" {
+		request.Header.Set(headerCustomerUserAgent, h.CustomerUserAgent)
+	}
+
+	return interactionId
+}
+
+// CheckInteractionId validates the interaction id echoed by the ASPSP,
+// a response without one is accepted
+func CheckInteractionId(response *http.Response, interactionId string) error {
+	echoed := response.Header.Get(headerFapiInteractionId)
+	if echoed != "" && echoed != interactionId {
+		return ErrInteractionIdMismatch
+	}
+	return nil
+}
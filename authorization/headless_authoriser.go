@@ -0,0 +1,46 @@
+package authorization
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"net/http"
+)
+
+type headlessAuthoriser struct {
+	client *http.Client
+}
+
+// NewHeadlessAuthoriser requests the authorization url without a browser and takes the code from
+// the redirect, for sandboxes and mock banks approving consents automatically
+func NewHeadlessAuthoriser(client *http.Client) Authoriser {
+	noRedirects := *client
+	noRedirects.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return headlessAuthoriser{
+		client: &noRedirects,
+	}
+}
+
+func (a headlessAuthoriser) Authorise(request AuthorisationRequest) (Code, error) {
+	return a.AuthoriseContext(context.Background(), request)
+}
+
+func (a headlessAuthoriser) AuthoriseContext(ctx context.Context, request AuthorisationRequest) (Code, error) {
+	authorizationRequest, err := http.NewRequestWithContext(ctx, http.MethodGet, request.Url, nil)
+	if err != nil {
+		return NoCode, errors.Wrap(err, "error authorising consent")
+	}
+
+	response, err := a.client.Do(authorizationRequest)
+	if err != nil {
+		return NoCode, errors.Wrap(err, "error authorising consent")
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusFound {
+		return NoCode, errors.Errorf("error authorising consent: unexpected response status code %d", response.StatusCode)
+	}
+
+	return request.CodeFromRedirect(response.Header.Get("Location"))
+}
@@ -0,0 +1,70 @@
+package authorization
+
+import (
+	"context"
+	"github.com/pkg/errors"
+)
+
+// IntentAuthenticator authorises consents the caller creates with a client credentials
+// grant, such as funds confirmation or payment consents, through the PSU redirect flow.
+// Tokens issued with a refresh token, e.g. of VRP consents, are refreshed without the PSU
+type IntentAuthenticator interface {
+	Grant() (GrantToken, error)
+	GrantContext(context.Context) (GrantToken, error)
+	Authorise(AccessConsent) (Token, error)
+	AuthoriseContext(context.Context, AccessConsent) (Token, error)
+	Refresh(Token) (Token, error)
+	RefreshContext(context.Context, Token) (Token, error)
+}
+
+type intentAuthenticator struct {
+	credentialsGranter CredentialsGranter
+	psuAccessConsenter PSUAccessConsenter
+	tokenGenerator     TokenGenerator
+}
+
+func NewIntentAuthenticator(
+	credentialsGranter CredentialsGranter,
+	psuAccessConsenter PSUAccessConsenter,
+	generator TokenGenerator,
+) IntentAuthenticator {
+	return intentAuthenticator{
+		credentialsGranter: credentialsGranter,
+		psuAccessConsenter: psuAccessConsenter,
+		tokenGenerator:     generator,
+	}
+}
+
+func (a intentAuthenticator) Grant() (GrantToken, error) {
+	return a.GrantContext(context.Background())
+}
+
+func (a intentAuthenticator) GrantContext(ctx context.Context) (GrantToken, error) {
+	return a.credentialsGranter.RequestContext(ctx)
+}
+
+func (a intentAuthenticator) Authorise(consent AccessConsent) (Token, error) {
+	return a.AuthoriseContext(context.Background(), consent)
+}
+
+func (a intentAuthenticator) AuthoriseContext(ctx context.Context, consent AccessConsent) (Token, error) {
+	code, err := a.psuAccessConsenter.RequestContext(ctx, consent)
+	if err != nil {
+		return NoToken, errors.Wrap(err, "error authorising consent")
+	}
+
+	token, err := a.tokenGenerator.RequestContext(ctx, code)
+	if err != nil {
+		return NoToken, errors.Wrap(err, "error authorising consent")
+	}
+
+	return token, nil
+}
+
+func (a intentAuthenticator) Refresh(token Token) (Token, error) {
+	return a.RefreshContext(context.Background(), token)
+}
+
+func (a intentAuthenticator) RefreshContext(ctx context.Context, token Token) (Token, error) {
+	return a.tokenGenerator.RefreshContext(ctx, token)
+}
@@ -0,0 +1,44 @@
+package authorization
+
+import (
+	"context"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/pkg/errors"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ResponseModeJWT requests JARM authorization responses in the default response mode of the response type
+const ResponseModeJWT = "jwt"
+
+// codeFromResponse verifies a JARM authorization response, a JWT the ASPSP signs and returns in the
+// response parameter instead of code and state, and extracts them as codeFromParams does
+func codeFromResponse(ctx context.Context, keys KeySet, issuer, clientId, response, state string) (Code, error) {
+	if strings.Count(response, ".") == 4 {
+		return NoCode, errors.New("error user access consent: encrypted authorization response, the client registers no authorization_encrypted_response_alg")
+	}
+
+	claims := jwt.MapClaims{}
+	if _, err := new(jwt.Parser).ParseWithClaims(response, claims, KeyFunc(ctx, keys)); err != nil {
+		return NoCode, errors.Wrap(err, "error user access consent: invalid authorization response")
+	}
+	if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return NoCode, errors.New("error user access consent: authorization response without exp or expired")
+	}
+	if claims["iss"] != issuer {
+		return NoCode, errors.Errorf("error user access consent: authorization response from unexpected issuer %v", claims["iss"])
+	}
+	if !AudienceIncludes(claims["aud"], clientId) {
+		return NoCode, errors.New("error user access consent: authorization response not addressed to client " + clientId)
+	}
+
+	params := url.Values{}
+	for _, name := range []string{"code", "state", "error", "error_description"} {
+		if value, ok := claims[name].(string); ok {
+			params.Set(name, value)
+		}
+	}
+
+	return codeFromParams(params, AuthorisationRequest{State: state})
+}
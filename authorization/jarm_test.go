@@ -0,0 +1,122 @@
+package authorization
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/pkg/errors"
+	"testing"
+	"time"
+)
+
+const (
+	testIssuer   = "https://bank.example.com"
+	testClientId = "client-1"
+	testKeyId    = "bank-key"
+	testState    = "4f1c8a9e"
+)
+
+// staticKeySet serves keys by id without fetching them
+type staticKeySet map[string]*rsa.PublicKey
+
+func (k staticKeySet) Key(kid string) (*rsa.PublicKey, error) {
+	return k.KeyContext(context.Background(), kid)
+}
+
+func (k staticKeySet) KeyContext(_ context.Context, kid string) (*rsa.PublicKey, error) {
+	if key, ok := k[kid]; ok {
+		return key, nil
+	}
+	return nil, errors.Errorf("unknown key id %s", kid)
+}
+
+func TestCodeFromResponse(t *testing.T) {
+	bankKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := staticKeySet{testKeyId: &bankKey.PublicKey}
+
+	claims := func(change func(jwt.MapClaims)) jwt.MapClaims {
+		claims := jwt.MapClaims{
+			"iss":   testIssuer,
+			"aud":   testClientId,
+			"exp":   time.Now().Add(time.Minute).Unix(),
+			"code":  "a1b2c3",
+			"state": testState,
+		}
+		if change != nil {
+			change(claims)
+		}
+		return claims
+	}
+	sign := func(key *rsa.PrivateKey, claims jwt.MapClaims) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodPS256, claims)
+		token.Header["kid"] = testKeyId
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	tests := []struct {
+		name     string
+		response string
+		want     string
+		wantErr  bool
+	}{
+		{name: "code", response: sign(bankKey, claims(nil)), want: "a1b2c3"},
+		{name: "audience array", response: sign(bankKey, claims(func(c jwt.MapClaims) {
+			c["aud"] = []string{testClientId, "other"}
+		})), want: "a1b2c3"},
+		{name: "other key", response: sign(otherKey, claims(nil)), wantErr: true},
+		{name: "unsigned", response: func() string {
+			token := jwt.NewWithClaims(jwt.SigningMethodNone, claims(nil))
+			unsigned, _ := token.SignedString(jwt.UnsafeAllowNoneSignatureType)
+			return unsigned
+		}(), wantErr: true},
+		{name: "encrypted", response: "header.key.iv.ciphertext.tag", wantErr: true},
+		{name: "no exp", response: sign(bankKey, claims(func(c jwt.MapClaims) {
+			delete(c, "exp")
+		})), wantErr: true},
+		{name: "expired", response: sign(bankKey, claims(func(c jwt.MapClaims) {
+			c["exp"] = time.Now().Add(-time.Minute).Unix()
+		})), wantErr: true},
+		{name: "other issuer", response: sign(bankKey, claims(func(c jwt.MapClaims) {
+			c["iss"] = "https://other.example.com"
+		})), wantErr: true},
+		{name: "other audience", response: sign(bankKey, claims(func(c jwt.MapClaims) {
+			c["aud"] = "other"
+		})), wantErr: true},
+		{name: "other state", response: sign(bankKey, claims(func(c jwt.MapClaims) {
+			c["state"] = "replayed"
+		})), wantErr: true},
+		{name: "denied", response: sign(bankKey, claims(func(c jwt.MapClaims) {
+			delete(c, "code")
+			c["error"] = "access_denied"
+		})), wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			code, err := codeFromResponse(context.Background(), keys, testIssuer, testClientId, test.response, testState)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("got code %s, want error", code.Value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if code.Value != test.want {
+				t.Errorf("got code %s, want %s", code.Value, test.want)
+			}
+		})
+	}
+}
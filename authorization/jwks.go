@@ -0,0 +1,163 @@
+package authorization
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/pkg/errors"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// keyRefetchInterval is the minimum time between fetches of the keys for unknown key ids,
+// so JWTs with made up key ids can not make the client hammer the jwks_uri
+const keyRefetchInterval = time.Minute
+
+// KeySet gets the ASPSP signing keys published at its jwks_uri, keys are cached
+// and fetched again when a key id is unknown as ASPSPs rotate them
+type KeySet interface {
+	Key(string) (*rsa.PublicKey, error)
+	KeyContext(context.Context, string) (*rsa.PublicKey, error)
+}
+
+// KeyFetchError is returned when the keys can not be fetched from the jwks_uri, unlike
+// a JWT signed with an unknown key it says nothing of the JWT
+type KeyFetchError struct {
+	Err error
+}
+
+func (e KeyFetchError) Error() string {
+	return e.Err.Error()
+}
+
+type keySet struct {
+	client  *http.Client
+	jwksURI string
+	mutex   sync.Mutex
+	keys    map[string]*rsa.PublicKey
+	fetched time.Time
+}
+
+// NewKeySet uses client, see NewDiscoveryTransport, to get the keys at jwksURI, see Configuration.JWKSURI
+func NewKeySet(client *http.Client, jwksURI string) KeySet {
+	return &keySet{
+		client:  client,
+		jwksURI: jwksURI,
+	}
+}
+
+type jsonWebKey struct {
+	KeyType string `json:"kty"`
+	KeyId   string `json:"kid"`
+	Use     string `json:"use"`
+	N       string `json:"n"`
+	E       string `json:"e"`
+}
+
+func (k *keySet) Key(kid string) (*rsa.PublicKey, error) {
+	return k.KeyContext(context.Background(), kid)
+}
+
+func (k *keySet) KeyContext(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	if key, found := k.keys[kid]; found {
+		return key, nil
+	}
+
+	if k.fetched.IsZero() || time.Since(k.fetched) >= keyRefetchInterval {
+		keys, err := k.fetch(ctx)
+		if err != nil {
+			return nil, KeyFetchError{err}
+		}
+		k.keys = keys
+		k.fetched = time.Now()
+	}
+
+	key, found := k.keys[kid]
+	if !found {
+		return nil, errors.Errorf("error getting signing key: unknown key id %s", kid)
+	}
+
+	return key, nil
+}
+
+func (k *keySet) fetch(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, k.jwksURI, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting signing keys")
+	}
+
+	response, err := k.client.Do(request)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting signing keys")
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("error getting signing keys: unexpected response status code %d", response.StatusCode)
+	}
+
+	var document struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err = json.NewDecoder(response.Body).Decode(&document); err != nil {
+		return nil, errors.Wrap(err, "error getting signing keys")
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+	for _, key := range document.Keys {
+		// encryption keys can not verify signatures
+		if key.KeyType != "RSA" || key.Use == "enc" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(key.N)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error getting signing keys: invalid key %s", key.KeyId)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(key.E)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error getting signing keys: invalid key %s", key.KeyId)
+		}
+		keys[key.KeyId] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	return keys, nil
+}
+
+// KeyFunc verifies JWTs signed with RSA or RSA-PSS by the key of keys named by the kid header
+func KeyFunc(ctx context.Context, keys KeySet) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		switch token.Method.(type) {
+		case *jwt.SigningMethodRSAPSS, *jwt.SigningMethodRSA:
+		default:
+			return nil, errors.Errorf("unexpected signing method %s", token.Header["alg"])
+		}
+
+		kid, _ := token.Header["kid"].(string)
+		return keys.KeyContext(ctx, kid)
+	}
+}
+
+// AudienceIncludes checks the aud claim of a JWT, a string or an array of strings, includes clientId
+func AudienceIncludes(aud interface{}, clientId string) bool {
+	switch value := aud.(type) {
+	case string:
+		return value == clientId
+	case []interface{}:
+		for _, member := range value {
+			if member == clientId {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package authorization
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// jwksServer publishes keys by id at its url, counting the fetches
+type jwksServer struct {
+	mutex   sync.Mutex
+	keys    map[string]*rsa.PublicKey
+	fetches int
+}
+
+func (s *jwksServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.fetches++
+	var document struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	for kid, key := range s.keys {
+		document.Keys = append(document.Keys, jsonWebKey{
+			KeyType: "RSA",
+			KeyId:   kid,
+			Use:     "sig",
+			N:       base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:       base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		})
+	}
+	json.NewEncoder(w).Encode(document)
+}
+
+func (s *jwksServer) publish(kid string, key *rsa.PublicKey) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.keys[kid] = key
+}
+
+func TestKeySet(t *testing.T) {
+	bankKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotatedKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	published := &jwksServer{keys: map[string]*rsa.PublicKey{testKeyId: &bankKey.PublicKey}}
+	server := httptest.NewServer(published)
+	defer server.Close()
+	keys := NewKeySet(http.DefaultClient, server.URL).(*keySet)
+
+	key, err := keys.Key(testKeyId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.N.Cmp(bankKey.N) != 0 || key.E != bankKey.E {
+		t.Error("got another key than the published one")
+	}
+	if _, err = keys.Key(testKeyId); err != nil || published.fetches != 1 {
+		t.Errorf("known key id fetched the keys again, %d fetches: %v", published.fetches, err)
+	}
+
+	if _, err = keys.Key("made-up"); err == nil {
+		t.Error("got a key for an unknown key id")
+	}
+	if _, ok := err.(KeyFetchError); ok {
+		t.Errorf("unknown key id is a fetch error: %v", err)
+	}
+	if published.fetches != 1 {
+		t.Errorf("unknown key id fetched the keys within %s, %d fetches", keyRefetchInterval, published.fetches)
+	}
+
+	published.publish("rotated", &rotatedKey.PublicKey)
+	keys.fetched = time.Now().Add(-keyRefetchInterval)
+	if key, err = keys.Key("rotated"); err != nil {
+		t.Fatalf("rotated key not fetched after %s: %v", keyRefetchInterval, err)
+	}
+	if key.N.Cmp(rotatedKey.N) != 0 || published.fetches != 2 {
+		t.Errorf("got the rotated key in %d fetches, want 2", published.fetches)
+	}
+}
+
+func TestKeySetFetchError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	_, err := NewKeySet(http.DefaultClient, server.URL).Key(testKeyId)
+	if _, ok := err.(KeyFetchError); !ok {
+		t.Errorf("got %v, want a KeyFetchError", err)
+	}
+}
@@ -0,0 +1,140 @@
+package authorization
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/pkg/errors"
+	"strings"
+	"time"
+)
+
+// OB protected header members of the detached JWS of request and response bodies
+const (
+	HeaderJWSIssuedAt    = "http://openbanking.org.uk/iat"
+	HeaderJWSIssuer      = "http://openbanking.org.uk/iss"
+	HeaderJWSTrustAnchor = "http://openbanking.org.uk/tan"
+
+	TrustAnchorOpenBanking = "openbanking.org.uk"
+)
+
+// DetachedSigner signs request bodies into the detached JWS of the x-jws-signature header
+type DetachedSigner interface {
+	SignDetached(payload []byte) (string, error)
+}
+
+type detachedSigner struct {
+	certs      Certificate
+	signMethod jwt.SigningMethod
+	keyId      string
+	issuer     string
+	unencoded  bool
+}
+
+// NewDetachedSigner signs as the software statement issuer, {org id}/{software statement id}, with the key keyId,
+// unencoded signs the payload as is with b64 false in the critical headers as OB versions before v3.1.4 require
+func NewDetachedSigner(certificate Certificate, method jwt.SigningMethod, keyId, issuer string, unencoded bool) DetachedSigner {
+	return &detachedSigner{
+		certs:      certificate,
+		signMethod: method,
+		keyId:      keyId,
+		issuer:     issuer,
+		unencoded:  unencoded,
+	}
+}
+
+func (s *detachedSigner) SignDetached(payload []byte) (string, error) {
+	privateKey, err := s.certs.PrivateKey()
+	if err != nil {
+		return "", errors.Wrap(err, "error signing payload")
+	}
+
+	critical := []string{HeaderJWSIssuedAt, HeaderJWSIssuer, HeaderJWSTrustAnchor}
+	header := map[string]interface{}{
+		"alg":                s.signMethod.Alg(),
+		"kid":                s.keyId,
+		"typ":                "JOSE",
+		"cty":                "application/json",
+		HeaderJWSIssuedAt:    time.Now().Unix(),
+		HeaderJWSIssuer:      s.issuer,
+		HeaderJWSTrustAnchor: TrustAnchorOpenBanking,
+	}
+	if s.unencoded {
+		header["b64"] = false
+		critical = append([]string{"b64"}, critical...)
+	}
+	header["crit"] = critical
+
+	protected, err := json.Marshal(header)
+	if err != nil {
+		return "", errors.Wrap(err, "error signing payload")
+	}
+
+	encodedHeader := base64.RawURLEncoding.EncodeToString(protected)
+	signature, err := s.signMethod.Sign(signingInput(encodedHeader, payload, s.unencoded), privateKey)
+	if err != nil {
+		return "", errors.Wrap(err, "error signing payload")
+	}
+
+	return encodedHeader + ".." + signature, nil
+}
+
+// VerifyDetached checks the detached JWS of payload was signed with key and carries the OB critical headers,
+// returning the protected header
+func VerifyDetached(signature string, payload []byte, key interface{}) (map[string]interface{}, error) {
+	parts := strings.Split(signature, ".")
+	if len(parts) != 3 || parts[1] != "" {
+		return nil, errors.New("error verifying signature: not a detached JWS")
+	}
+
+	protected, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, errors.Wrap(err, "error verifying signature")
+	}
+
+	header := map[string]interface{}{}
+	if err = json.Unmarshal(protected, &header); err != nil {
+		return nil, errors.Wrap(err, "error verifying signature")
+	}
+
+	critical := map[string]bool{}
+	if members, ok := header["crit"].([]interface{}); ok {
+		for _, member := range members {
+			if name, ok := member.(string); ok {
+				critical[name] = true
+			}
+		}
+	}
+	for _, name := range []string{HeaderJWSIssuedAt, HeaderJWSIssuer, HeaderJWSTrustAnchor} {
+		if !critical[name] || header[name] == nil {
+			return nil, errors.Errorf("error verifying signature: missing critical header %s", name)
+		}
+	}
+
+	unencoded := false
+	if b64, ok := header["b64"]; ok {
+		if b64 != false || !critical["b64"] {
+			return nil, errors.New("error verifying signature: b64 must be false and critical")
+		}
+		unencoded = true
+	}
+
+	alg, _ := header["alg"].(string)
+	method := jwt.GetSigningMethod(alg)
+	if method == nil || method.Alg() == jwt.SigningMethodNone.Alg() {
+		return nil, errors.Errorf("error verifying signature: unsupported alg %s", alg)
+	}
+
+	if err = method.Verify(signingInput(parts[0], payload, unencoded), parts[2], key); err != nil {
+		return nil, errors.Wrap(err, "error verifying signature")
+	}
+
+	return header, nil
+}
+
+func signingInput(encodedHeader string, payload []byte, unencoded bool) string {
+	if unencoded {
+		return encodedHeader + "." + string(payload)
+	}
+	return encodedHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+}
@@ -0,0 +1,52 @@
+package authorization
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"strings"
+)
+
+type pasteAuthoriser struct {
+	in  io.Reader
+	out io.Writer
+}
+
+// NewPasteAuthoriser prints the authorization url and reads the redirect url,
+// as shown in the browser address bar after consent, from in
+func NewPasteAuthoriser(in io.Reader, out io.Writer) Authoriser {
+	return pasteAuthoriser{
+		in:  in,
+		out: out,
+	}
+}
+
+func (p pasteAuthoriser) Authorise(request AuthorisationRequest) (Code, error) {
+	return p.AuthoriseContext(context.Background(), request)
+}
+
+func (p pasteAuthoriser) AuthoriseContext(ctx context.Context, request AuthorisationRequest) (Code, error) {
+	fmt.Fprintf(p.out, "Open this url to give consent:\n\n%s\n\nThen paste the url you were redirected to: ", request.Url)
+
+	lines := make(chan string, 1)
+	errs := make(chan error, 1)
+	go func() {
+		line, err := bufio.NewReader(p.in).ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			errs <- err
+			return
+		}
+		lines <- line
+	}()
+
+	select {
+	case line := <-lines:
+		return request.CodeFromRedirect(strings.TrimSpace(line))
+	case err := <-errs:
+		return NoCode, errors.Wrap(err, "error reading redirect url")
+	case <-ctx.Done():
+		return NoCode, errors.Wrap(ctx.Err(), "error waiting user access consent")
+	}
+}
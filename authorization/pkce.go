@@ -0,0 +1,26 @@
+package authorization
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"github.com/pkg/errors"
+)
+
+// CodeChallengeMethodS256 is the only PKCE method used, RFC 7636, plain is not allowed by FAPI
+const CodeChallengeMethodS256 = "S256"
+
+// newCodeVerifier returns a 43 characters code_verifier from 32 random bytes
+func newCodeVerifier() (string, error) {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return "", errors.Wrap(err, "error generating PKCE code verifier")
+	}
+	return base64.RawURLEncoding.EncodeToString(random), nil
+}
+
+// codeChallenge is the S256 code_challenge of verifier
+func codeChallenge(verifier string) string {
+	hash := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
@@ -0,0 +1,126 @@
+package authorization
+
+import (
+	"context"
+	"github.com/dgrijalva/jwt-go"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// plainTransport sends requests with the default client, without mutual TLS
+type plainTransport struct{}
+
+func (plainTransport) Client() (*http.Client, error) {
+	return http.DefaultClient, nil
+}
+
+// claimsSigner records the claims of the request object it signs as signed
+type claimsSigner struct {
+	claims jwt.MapClaims
+}
+
+func (s *claimsSigner) Sign(claims jwt.Claims) (string, error) {
+	s.claims = claims.(jwt.MapClaims)
+	return "signed", nil
+}
+
+// urlAuthoriser records the authorization request and consents without a user
+type urlAuthoriser struct {
+	request AuthorisationRequest
+}
+
+func (a *urlAuthoriser) Authorise(request AuthorisationRequest) (Code, error) {
+	return a.AuthoriseContext(context.Background(), request)
+}
+
+func (a *urlAuthoriser) AuthoriseContext(_ context.Context, request AuthorisationRequest) (Code, error) {
+	a.request = request
+	return Code{Value: "a1b2c3"}, nil
+}
+
+func TestCodeChallenge(t *testing.T) {
+	// RFC 7636 appendix B
+	if got := codeChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"); got != "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM" {
+		t.Errorf("got code challenge %s", got)
+	}
+}
+
+func TestNewCodeVerifier(t *testing.T) {
+	verifier, err := newCodeVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(verifier) != 43 {
+		t.Errorf("got %d characters verifier %s, want 43", len(verifier), verifier)
+	}
+	other, err := newCodeVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other == verifier {
+		t.Errorf("got verifier %s twice", verifier)
+	}
+}
+
+func TestPSUAccessConsenterPKCE(t *testing.T) {
+	signer := &claimsSigner{}
+	authoriser := &urlAuthoriser{}
+	consenter := NewPSUAccessConsenter("https://bank.example.com/authorize", testIssuer, "http://localhost:8081/callback",
+		NewClient(testClientId, "secret"), signer, authoriser, nil)
+
+	code, err := consenter.Request(AccessConsent{ConsentId: "aac-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code.Value != "a1b2c3" || len(code.Verifier) != 43 {
+		t.Fatalf("got code %s with verifier %q", code.Value, code.Verifier)
+	}
+
+	authorizationUrl, err := url.Parse(authoriser.request.Url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := authorizationUrl.Query()
+	challenge := codeChallenge(code.Verifier)
+	if query.Get("code_challenge") != challenge || query.Get("code_challenge_method") != CodeChallengeMethodS256 {
+		t.Errorf("authorization url challenge %s %s, want %s %s", query.Get("code_challenge"), query.Get("code_challenge_method"), challenge, CodeChallengeMethodS256)
+	}
+	if signer.claims["code_challenge"] != challenge || signer.claims["code_challenge_method"] != CodeChallengeMethodS256 {
+		t.Errorf("request object challenge %v %v, want %s %s", signer.claims["code_challenge"], signer.claims["code_challenge_method"], challenge, CodeChallengeMethodS256)
+	}
+	if _, found := query["code_verifier"]; found {
+		t.Error("authorization url sends the code verifier")
+	}
+}
+
+func TestTokenGeneratorCodeVerifier(t *testing.T) {
+	tests := []struct {
+		name string
+		code Code
+		want []string
+	}{
+		{name: "verifier", code: Code{Value: "a1b2c3", Verifier: "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"}, want: []string{"dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"}},
+		{name: "no verifier", code: Code{Value: "a1b2c3"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var form url.Values
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				r.ParseForm()
+				form = r.PostForm
+				w.Write([]byte(`{"access_token":"token","token_type":"Bearer","expires_in":3600}`))
+			}))
+			defer server.Close()
+
+			generator := NewTokenGenerator(plainTransport{}, server.URL, "http://localhost:8081/callback", NewClient(testClientId, "secret"))
+			if _, err := generator.Request(test.code); err != nil {
+				t.Fatal(err)
+			}
+			if form.Get("code") != "a1b2c3" || len(form["code_verifier"]) != len(test.want) || (test.want != nil && form.Get("code_verifier") != test.want[0]) {
+				t.Errorf("sent code %q with verifier %q, want verifier %q", form.Get("code"), form["code_verifier"], test.want)
+			}
+		})
+	}
+}
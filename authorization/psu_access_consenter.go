@@ -2,116 +2,190 @@ package authorization
 
 import (
 	"context"
-	"fmt"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/google/uuid"
 	"github.com/pkg/errors"
-	"github.com/skratchdot/open-golang/open"
-	"io/ioutil"
-	"net/http"
+	"net/url"
 	"time"
 )
 
+// OB scopes a consent is authorised for
+const (
+	ScopeAccounts           = "accounts"
+	ScopeFundsConfirmations = "fundsconfirmations"
+	ScopePayments           = "payments"
+)
+
 type PSUAccessConsenter interface {
 	Request(AccessConsent) (Code, error)
+	RequestContext(context.Context, AccessConsent) (Code, error)
 }
 
 type psuAccessConsenter struct {
-	transport    Transport
-	endpoint     string
-	authCallback string
-	client       Client
+	authorizationEndpoint string
+	issuer                string
+	authCallback          string
+	client                Client
+	signer                Signer
+	authoriser            Authoriser
+	// keys verify JARM authorization responses, requested with response_mode jwt, nil does not request them
+	keys KeySet
+	// transport and parEndpoint are set to push the request object, see NewPushedPSUAccessConsenter
+	transport   Transport
+	parEndpoint string
+	// requirePushed fails when the push does instead of sending the request object in the front channel
+	requirePushed bool
 }
 
-func NewPSUAccessConsenter(transport Transport, endpoint, authCallback string, client Client) PSUAccessConsenter {
+// NewPSUAccessConsenter sends the signed request object in the front channel authorization url,
+// JARM responses are requested and verified with keys when not nil, see Configuration.SupportsJARM
+func NewPSUAccessConsenter(authorizationEndpoint, issuer, authCallback string, client Client, signer Signer, authoriser Authoriser, keys KeySet) PSUAccessConsenter {
 	return psuAccessConsenter{
-		transport:    transport,
-		endpoint:     endpoint,
-		authCallback: authCallback,
-		client:       client,
+		authorizationEndpoint: authorizationEndpoint,
+		issuer:                issuer,
+		authCallback:          authCallback,
+		client:                client,
+		signer:                signer,
+		authoriser:            authoriser,
+		keys:                  keys,
 	}
 }
 
-func (a psuAccessConsenter) Request(accessConsent AccessConsent) (Code, error) {
-	client, err := a.transport.Client()
-	if err != nil {
-		return NoCode, errors.Wrap(err, "error starting user access consent flow")
+// NewPushedPSUAccessConsenter pushes the signed request object to parEndpoint, RFC 9126, and sends
+// only the request_uri it gets back in the authorization url, as FAPI 2.0 ASPSPs expect. When the push
+// fails the request object goes in the front channel, unless required, see Configuration.RequirePAR
+func NewPushedPSUAccessConsenter(transport Transport, parEndpoint, authorizationEndpoint, issuer, authCallback string, client Client, signer Signer, authoriser Authoriser, keys KeySet, required bool) PSUAccessConsenter {
+	return psuAccessConsenter{
+		authorizationEndpoint: authorizationEndpoint,
+		issuer:                issuer,
+		authCallback:          authCallback,
+		client:                client,
+		signer:                signer,
+		authoriser:            authoriser,
+		keys:                  keys,
+		transport:             transport,
+		parEndpoint:           parEndpoint,
+		requirePushed:         required,
 	}
+}
 
-	endpoint := a.endpoint
-	endpoint = fmt.Sprintf("https://modelobank2018.o3bank.co.uk:4501/ozone/v1.0/auth-code-url/%s?scope=%s", accessConsent.ConsentId, "openid%20accounts")
+func (a psuAccessConsenter) Request(accessConsent AccessConsent) (Code, error) {
+	return a.RequestContext(context.Background(), accessConsent)
+}
 
-	request, err := http.NewRequest(http.MethodGet, endpoint, nil)
+// RequestContext returns the code with the PKCE code verifier the token request must send
+func (a psuAccessConsenter) RequestContext(ctx context.Context, accessConsent AccessConsent) (Code, error) {
+	state := uuid.New().String()
+	verifier, err := newCodeVerifier()
 	if err != nil {
 		return NoCode, errors.Wrap(err, "error starting user access consent flow")
 	}
-	request.Header.Set("Authorization", a.client.AuthHeader())
 
-	response, err := client.Do(request)
+	authorizationUrl, err := a.authorizationUrl(ctx, accessConsent, state, codeChallenge(verifier))
 	if err != nil {
 		return NoCode, errors.Wrap(err, "error starting user access consent flow")
 	}
 
-	if response.StatusCode != http.StatusOK {
-		body, _ := ioutil.ReadAll(response.Body)
-		fmt.Println(string(body))
-		return NoCode, errors.Errorf("error starting user access consent flow: unexpected response status code %d", response.StatusCode)
+	request := AuthorisationRequest{
+		Url:         authorizationUrl,
+		RedirectUrl: a.authCallback,
+		State:       state,
+	}
+	if a.keys != nil {
+		request.verifyResponse = func(response string) (Code, error) {
+			return codeFromResponse(ctx, a.keys, a.issuer, a.client.Id, response, state)
+		}
+	}
+	code, err := a.authoriser.AuthoriseContext(ctx, request)
+	if err != nil {
+		return NoCode, err
 	}
 
-	codeChan := make(chan Code)
-	a.runCallbackListener(codeChan)
+	code.Verifier = verifier
+	return code, nil
+}
 
-	body, err := ioutil.ReadAll(response.Body)
+// authorizationUrl builds the authorization request with a signed request object, pushed when there is a parEndpoint
+// and sent in the front channel otherwise or when an optional push fails
+func (a psuAccessConsenter) authorizationUrl(ctx context.Context, accessConsent AccessConsent, state, challenge string) (string, error) {
+	nonce := uuid.New().String()
+	requestObject, err := a.signer.Sign(a.requestObjectClaims(accessConsent, state, nonce, challenge))
 	if err != nil {
-		return NoCode, errors.Wrap(err, "error starting user access consent flow")
+		return "", err
 	}
 
-	err = open.Run(string(body))
-	if err != nil {
-		return NoCode, errors.Wrap(err, "error initiating browser for user consent flow")
+	if a.parEndpoint != "" {
+		pushed, err := pushAuthorizationRequest(ctx, a.transport, a.parEndpoint, a.client, requestObject)
+		if err == nil {
+			query := url.Values{}
+			query.Set("client_id", a.client.Id)
+			query.Set("request_uri", pushed.RequestURI)
+			return a.authorizationEndpoint + "?" + query.Encode(), nil
+		}
+		if a.requirePushed {
+			return "", errors.Wrap(err, "error pushing authorization request")
+		}
 	}
 
-	code := <-codeChan
+	query := url.Values{}
+	query.Set("client_id", a.client.Id)
+	query.Set("response_type", responseTypeCode)
+	query.Set("scope", accessConsent.scope())
+	query.Set("redirect_uri", a.authCallback)
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", challenge)
+	query.Set("code_challenge_method", CodeChallengeMethodS256)
+	if a.keys != nil {
+		query.Set("response_mode", ResponseModeJWT)
+	}
+	query.Set("request", requestObject)
 
-	return code, nil
+	return a.authorizationEndpoint + "?" + query.Encode(), nil
 }
 
-func (a psuAccessConsenter) runCallbackListener(tokenChan chan Code) {
-	srv := &http.Server{Addr: ":8081"}
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		code := r.URL.Query().Get("code")
-		tokenChan <- Code{code}
-		w.Write([]byte(`
-<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 4.0 
-Transitional//EN"> <HTML> <HEAD> 
-<TITLE>Open Banking Access Consent</TITLE> </HEAD>
-<BODY>
-<table border="0" width="100%">
- <tr>
-  <td align="center"><font color=#330066 size="4"><strong>
-   Authenticated!</strong></font>
-  </td>
- </tr>
- <tr>
-  <td align="center"><font color=#330066>
-   (Please close this window)</font>
-  </td>
- </tr>
-</table>
-</BODY>
-</HTML> 
-`))
-		go func() {
-			time.Sleep(time.Second * 2)
-			srv.Shutdown(context.Background())
-		}()
-	})
-
-	go func() {
-		srv.ListenAndServe()
-	}()
+// responseTypeCode requests only the code, bound to the request by PKCE and signed when JARM is requested,
+// the hybrid flow front channel id_token is not requested as it would have to be verified
+const responseTypeCode = "code"
+
+func (a psuAccessConsenter) requestObjectClaims(accessConsent AccessConsent, state, nonce, challenge string) jwt.Claims {
+	iat := time.Now()
+	intent := map[string]interface{}{
+		"openbanking_intent_id": map[string]interface{}{
+			"value":     accessConsent.ConsentId,
+			"essential": true,
+		},
+	}
+	claims := jwt.MapClaims{
+		"iss":                   a.client.Id,
+		"aud":                   a.issuer,
+		"client_id":             a.client.Id,
+		"response_type":         responseTypeCode,
+		"redirect_uri":          a.authCallback,
+		"scope":                 accessConsent.scope(),
+		"state":                 state,
+		"nonce":                 nonce,
+		"max_age":               86400,
+		"code_challenge":        challenge,
+		"code_challenge_method": CodeChallengeMethodS256,
+		"iat":                   iat.Unix(),
+		"exp":                   iat.Add(time.Hour).Unix(),
+		"jti":                   uuid.New().String(),
+		"claims": map[string]interface{}{
+			"userinfo": intent,
+			"id_token": intent,
+		},
+	}
+	if a.keys != nil {
+		claims["response_mode"] = ResponseModeJWT
+	}
+	return claims
 }
 
 var NoCode = Code{}
 
 type Code struct {
 	Value string
+	// Verifier is the PKCE code_verifier of the authorization request the code was issued for
+	Verifier string
 }
@@ -0,0 +1,60 @@
+package authorization
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// PushedAuthorizationResponse is the request_uri the authorization url refers to, RFC 9126
+type PushedAuthorizationResponse struct {
+	RequestURI string `json:"request_uri"`
+	ExpiresIn  int64  `json:"expires_in"`
+}
+
+// pushAuthorizationRequest posts the signed request object to the pushed authorization request endpoint
+func pushAuthorizationRequest(ctx context.Context, transport Transport, endpoint string, client Client, requestObject string) (PushedAuthorizationResponse, error) {
+	httpClient, err := transport.Client()
+	if err != nil {
+		return PushedAuthorizationResponse{}, err
+	}
+
+	data := url.Values{}
+	data.Set("client_id", client.Id)
+	data.Set("request", requestObject)
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(data.Encode()))
+	if err != nil {
+		return PushedAuthorizationResponse{}, err
+	}
+	request.Header.Set("Content-type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", client.AuthHeader())
+
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return PushedAuthorizationResponse{}, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusCreated && response.StatusCode != http.StatusOK {
+		var oauthError struct {
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}
+		json.NewDecoder(response.Body).Decode(&oauthError)
+		return PushedAuthorizationResponse{}, errors.Errorf("unexpected status code %d %s %s", response.StatusCode, oauthError.Error, oauthError.Description)
+	}
+
+	var pushed PushedAuthorizationResponse
+	if err = json.NewDecoder(response.Body).Decode(&pushed); err != nil {
+		return PushedAuthorizationResponse{}, err
+	}
+	if pushed.RequestURI == "" {
+		return PushedAuthorizationResponse{}, errors.New("no request_uri in response")
+	}
+
+	return pushed, nil
+}
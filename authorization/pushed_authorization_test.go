@@ -0,0 +1,77 @@
+package authorization
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestPushedPSUAccessConsenter(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		required bool
+		// wantPushed is true when the authorization url only refers to the pushed request object
+		wantPushed bool
+		wantErr    bool
+	}{
+		{name: "pushed", status: http.StatusCreated, body: `{"request_uri":"urn:bank:par:1","expires_in":60}`, wantPushed: true},
+		{name: "pushed with 200", status: http.StatusOK, body: `{"request_uri":"urn:bank:par:1","expires_in":60}`, wantPushed: true},
+		{name: "push fails", status: http.StatusBadRequest, body: `{"error":"invalid_request_object"}`},
+		{name: "push without request_uri", status: http.StatusCreated, body: `{"expires_in":60}`},
+		{name: "required push fails", status: http.StatusBadRequest, body: `{"error":"invalid_request_object"}`, required: true, wantErr: true},
+		{name: "required push without request_uri", status: http.StatusCreated, body: `{}`, required: true, wantErr: true},
+		{name: "required push", status: http.StatusCreated, body: `{"request_uri":"urn:bank:par:1","expires_in":60}`, required: true, wantPushed: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var pushed url.Values
+			var authorization string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				r.ParseForm()
+				pushed = r.PostForm
+				authorization = r.Header.Get("Authorization")
+				w.WriteHeader(test.status)
+				w.Write([]byte(test.body))
+			}))
+			defer server.Close()
+
+			client := NewClient(testClientId, "secret")
+			authoriser := &urlAuthoriser{}
+			consenter := NewPushedPSUAccessConsenter(plainTransport{}, server.URL, "https://bank.example.com/authorize", testIssuer,
+				"http://localhost:8081/callback", client, &claimsSigner{}, authoriser, nil, test.required)
+
+			_, err := consenter.Request(AccessConsent{ConsentId: "aac-1"})
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("got authorization url %s, want error", authoriser.request.Url)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if pushed.Get("request") != "signed" || pushed.Get("client_id") != testClientId || authorization != client.AuthHeader() {
+				t.Errorf("pushed %v with authorization %q", pushed, authorization)
+			}
+
+			authorizationUrl, err := url.Parse(authoriser.request.Url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			query := authorizationUrl.Query()
+			if test.wantPushed {
+				if len(query) != 2 || query.Get("request_uri") != "urn:bank:par:1" || query.Get("client_id") != testClientId {
+					t.Errorf("got authorization url %s, want only client_id and request_uri", authoriser.request.Url)
+				}
+				return
+			}
+			if query.Get("request") != "signed" || query.Get("request_uri") != "" || query.Get("code_challenge") == "" {
+				t.Errorf("got authorization url %s, want the request object in the front channel", authoriser.request.Url)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package authorization
+
+import (
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const headerIdempotencyKey = "x-idempotency-key"
+
+// RetryPolicy configures how failed requests are retried, a zero MaxRetries disables retries
+type RetryPolicy struct {
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 3,
+	BaseDelay:  time.Millisecond * 500,
+	MaxDelay:   time.Second * 30,
+}
+
+// backoff returns an exponential delay with full jitter for a zero based attempt
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.MaxDelay
+	if attempt < 32 && p.BaseDelay<<uint(attempt) < p.MaxDelay {
+		delay = p.BaseDelay << uint(attempt)
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(delay)))
+}
+
+type retryRoundTripper struct {
+	next   http.RoundTripper
+	policy RetryPolicy
+}
+
+// NewRetryRoundTripper retries idempotent requests on network errors, 429 and transient 5xx,
+// POST requests are only retried when they carry an x-idempotency-key header
+func NewRetryRoundTripper(next http.RoundTripper, policy RetryPolicy) http.RoundTripper {
+	return &retryRoundTripper{
+		next:   next,
+		policy: policy,
+	}
+}
+
+func (r *retryRoundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	if r.policy.MaxRetries <= 0 || !isRetryable(request) {
+		return r.next.RoundTrip(request)
+	}
+
+	attemptRequest := request
+	for attempt := 0; ; attempt++ {
+		response, err := r.next.RoundTrip(attemptRequest)
+		if attempt >= r.policy.MaxRetries || !shouldRetry(request, response, err) {
+			return response, err
+		}
+
+		delay := r.policy.backoff(attempt)
+		if response != nil {
+			if retryAfter, ok := parseRetryAfter(response.Header.Get("Retry-After")); ok {
+				if retryAfter > r.policy.MaxDelay {
+					return response, err
+				}
+				delay = retryAfter
+			}
+			io.Copy(ioutil.Discard, response.Body)
+			response.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-request.Context().Done():
+			timer.Stop()
+			return nil, request.Context().Err()
+		case <-timer.C:
+		}
+
+		attemptRequest = request.Clone(request.Context())
+		if request.GetBody != nil {
+			attemptRequest.Body, err = request.GetBody()
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+func isRetryable(request *http.Request) bool {
+	if request.Body != nil && request.Body != http.NoBody && request.GetBody == nil {
+		return false
+	}
+
+	switch request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	case http.MethodPost, http.MethodPatch:
+		return request.Header.Get(headerIdempotencyKey) != ""
+	}
+	return false
+}
+
+func shouldRetry(request *http.Request, response *http.Response, err error) bool {
+	if err != nil {
+		return request.Context().Err() == nil
+	}
+
+	switch response.StatusCode {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// parseRetryAfter accepts both delay-seconds and HTTP-date forms
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+
+	delay := time.Until(date)
+	if delay < 0 {
+		delay = 0
+	}
+	return delay, true
+}
+
+type hostLimitRoundTripper struct {
+	next  http.RoundTripper
+	limit int
+	mutex sync.Mutex
+	hosts map[string]chan struct{}
+}
+
+// NewHostLimitRoundTripper limits in flight requests per host, a request holds
+// its slot until the response body is closed
+func NewHostLimitRoundTripper(next http.RoundTripper, limit int) http.RoundTripper {
+	if limit <= 0 {
+		return next
+	}
+	return &hostLimitRoundTripper{
+		next:  next,
+		limit: limit,
+		hosts: map[string]chan struct{}{},
+	}
+}
+
+func (h *hostLimitRoundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	slots := h.slots(request.URL.Host)
+	select {
+	case slots <- struct{}{}:
+	case <-request.Context().Done():
+		return nil, request.Context().Err()
+	}
+
+	release := func() { <-slots }
+	response, err := h.next.RoundTrip(request)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	response.Body = &releasingBody{ReadCloser: response.Body, release: release}
+	return response, nil
+}
+
+func (h *hostLimitRoundTripper) slots(host string) chan struct{} {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	slots, ok := h.hosts[host]
+	if !ok {
+		slots = make(chan struct{}, h.limit)
+		h.hosts[host] = slots
+	}
+	return slots
+}
+
+type releasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
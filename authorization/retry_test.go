@@ -0,0 +1,251 @@
+package authorization
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// attemptCounter answers with statuses in turn, 200 once they run out, recording the bodies it gets
+type attemptCounter struct {
+	mutex      sync.Mutex
+	statuses   []int
+	retryAfter string
+	bodies     []string
+}
+
+func (a *attemptCounter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+
+	a.mutex.Lock()
+	attempt := len(a.bodies)
+	a.bodies = append(a.bodies, string(body))
+	a.mutex.Unlock()
+
+	if attempt < len(a.statuses) {
+		if a.retryAfter != "" {
+			w.Header().Set("Retry-After", a.retryAfter)
+		}
+		w.WriteHeader(a.statuses[attempt])
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *attemptCounter) attempts() int {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return len(a.bodies)
+}
+
+func TestRetryRoundTripper(t *testing.T) {
+	policy := RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: 50 * time.Millisecond}
+
+	tests := []struct {
+		name       string
+		method     string
+		key        string
+		statuses   []int
+		retryAfter string
+		policy     *RetryPolicy
+		// want is the status returned after wantAttempts requests reached the server
+		want         int
+		wantAttempts int
+	}{
+		{name: "get ok", method: http.MethodGet, want: http.StatusOK, wantAttempts: 1},
+		{name: "get 429", method: http.MethodGet, statuses: []int{http.StatusTooManyRequests}, want: http.StatusOK, wantAttempts: 2},
+		{name: "get 500", method: http.MethodGet, statuses: []int{http.StatusInternalServerError}, want: http.StatusOK, wantAttempts: 2},
+		{name: "get 502 then 504", method: http.MethodGet, statuses: []int{http.StatusBadGateway, http.StatusGatewayTimeout}, want: http.StatusOK, wantAttempts: 3},
+		{name: "delete 503", method: http.MethodDelete, statuses: []int{http.StatusServiceUnavailable}, want: http.StatusOK, wantAttempts: 2},
+		{name: "get 501 is not transient", method: http.MethodGet, statuses: []int{http.StatusNotImplemented}, want: http.StatusNotImplemented, wantAttempts: 1},
+		{name: "get 400 is not transient", method: http.MethodGet, statuses: []int{http.StatusBadRequest}, want: http.StatusBadRequest, wantAttempts: 1},
+		{name: "post without idempotency key", method: http.MethodPost, statuses: []int{http.StatusServiceUnavailable}, want: http.StatusServiceUnavailable, wantAttempts: 1},
+		{name: "post with idempotency key", method: http.MethodPost, key: "8c3b1d9e", statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}, want: http.StatusOK, wantAttempts: 3},
+		{name: "patch without idempotency key", method: http.MethodPatch, statuses: []int{http.StatusServiceUnavailable}, want: http.StatusServiceUnavailable, wantAttempts: 1},
+		{name: "retries exhausted", method: http.MethodGet, statuses: []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable}, want: http.StatusServiceUnavailable, wantAttempts: 4},
+		{name: "retries disabled", method: http.MethodGet, statuses: []int{http.StatusServiceUnavailable}, policy: &RetryPolicy{}, want: http.StatusServiceUnavailable, wantAttempts: 1},
+		{name: "retry after seconds", method: http.MethodGet, statuses: []int{http.StatusTooManyRequests}, retryAfter: "0",
+			policy: &RetryPolicy{MaxRetries: 1, BaseDelay: time.Hour, MaxDelay: time.Hour}, want: http.StatusOK, wantAttempts: 2},
+		{name: "retry after beyond the max delay", method: http.MethodGet, statuses: []int{http.StatusTooManyRequests}, retryAfter: "120",
+			want: http.StatusTooManyRequests, wantAttempts: 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			counter := &attemptCounter{statuses: test.statuses, retryAfter: test.retryAfter}
+			server := httptest.NewServer(counter)
+			defer server.Close()
+
+			testPolicy := policy
+			if test.policy != nil {
+				testPolicy = *test.policy
+			}
+			client := &http.Client{Transport: NewRetryRoundTripper(http.DefaultTransport, testPolicy)}
+
+			var body io.Reader
+			if test.method == http.MethodPost || test.method == http.MethodPatch {
+				body = strings.NewReader(`{"Data":{}}`)
+			}
+			request, err := http.NewRequest(test.method, server.URL, body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.key != "" {
+				request.Header.Set(headerIdempotencyKey, test.key)
+			}
+
+			response, err := client.Do(request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			response.Body.Close()
+
+			if response.StatusCode != test.want {
+				t.Errorf("got status %d, want %d", response.StatusCode, test.want)
+			}
+			if counter.attempts() != test.wantAttempts {
+				t.Errorf("got %d attempts, want %d", counter.attempts(), test.wantAttempts)
+			}
+			if body != nil {
+				for i, sent := range counter.bodies {
+					if sent != `{"Data":{}}` {
+						t.Errorf("attempt %d sent body %q", i, sent)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRetryRoundTripperCancelledWait(t *testing.T) {
+	counter := &attemptCounter{statuses: []int{http.StatusServiceUnavailable}, retryAfter: "10"}
+	server := httptest.NewServer(counter)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &http.Client{Transport: NewRetryRoundTripper(http.DefaultTransport, RetryPolicy{MaxRetries: 3, MaxDelay: time.Minute})}
+	started := time.Now()
+	if _, err = client.Do(request); err == nil {
+		t.Fatal("got a response, want the context error")
+	}
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Errorf("waited %s for Retry-After after the context was done", elapsed)
+	}
+	if counter.attempts() != 1 {
+		t.Errorf("got %d attempts, want 1", counter.attempts())
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  string
+		want   time.Duration
+		wantOk bool
+	}{
+		{name: "seconds", value: "3", want: 3 * time.Second, wantOk: true},
+		{name: "zero", value: "0", want: 0, wantOk: true},
+		{name: "past date", value: "Wed, 21 Oct 2015 07:28:00 GMT", want: 0, wantOk: true},
+		{name: "empty", value: ""},
+		{name: "negative", value: "-1"},
+		{name: "not a delay", value: "soon"},
+	}
+	for _, test := range tests {
+		got, ok := parseRetryAfter(test.value)
+		if got != test.want || ok != test.wantOk {
+			t.Errorf("%s: parseRetryAfter(%q) = %s, %t, want %s, %t", test.name, test.value, got, ok, test.want, test.wantOk)
+		}
+	}
+
+	future := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if got, ok := parseRetryAfter(future); !ok || got <= 50*time.Second || got > time.Minute {
+		t.Errorf("parseRetryAfter(%q) = %s, %t, want about a minute", future, got, ok)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	tests := []struct {
+		attempt int
+		// ceiling is the exclusive bound of the jittered delay
+		ceiling time.Duration
+	}{
+		{attempt: 0, ceiling: 100 * time.Millisecond},
+		{attempt: 1, ceiling: 200 * time.Millisecond},
+		{attempt: 3, ceiling: 800 * time.Millisecond},
+		{attempt: 4, ceiling: time.Second},
+		{attempt: 40, ceiling: time.Second},
+	}
+	for _, test := range tests {
+		delays := map[time.Duration]bool{}
+		for i := 0; i < 100; i++ {
+			delay := policy.backoff(test.attempt)
+			if delay < 0 || delay >= test.ceiling {
+				t.Fatalf("backoff(%d) = %s, want in [0, %s)", test.attempt, delay, test.ceiling)
+			}
+			delays[delay] = true
+		}
+		if len(delays) < 2 {
+			t.Errorf("backoff(%d) is not jittered, got %v", test.attempt, delays)
+		}
+	}
+
+	if delay := (RetryPolicy{}).backoff(2); delay != 0 {
+		t.Errorf("backoff without delays = %s, want 0", delay)
+	}
+}
+
+func TestHostLimitRoundTripper(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewHostLimitRoundTripper(http.DefaultTransport, 1)}
+	get := func(timeout time.Duration) (*http.Response, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		t.Cleanup(cancel)
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return client.Do(request)
+	}
+
+	first, err := get(time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = get(50 * time.Millisecond); err == nil {
+		t.Fatal("second request got the slot while the first body is open")
+	}
+
+	body, _ := ioutil.ReadAll(first.Body)
+	if !bytes.Equal(body, []byte("ok")) {
+		t.Errorf("got body %q", body)
+	}
+	first.Body.Close()
+	// closing twice frees the slot once
+	first.Body.Close()
+
+	second, err := get(time.Minute)
+	if err != nil {
+		t.Fatalf("slot not freed when the body closed: %v", err)
+	}
+	if _, err = get(50 * time.Millisecond); err == nil {
+		t.Fatal("third request got the slot while the second body is open")
+	}
+	second.Body.Close()
+}
@@ -1,8 +1,8 @@
 package authorization
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
 	"github.com/pkg/errors"
 	"io"
 	"io/ioutil"
@@ -13,6 +13,9 @@ import (
 
 type TokenGenerator interface {
 	Request(Code) (Token, error)
+	RequestContext(context.Context, Code) (Token, error)
+	Refresh(Token) (Token, error)
+	RefreshContext(context.Context, Token) (Token, error)
 }
 
 type tokenGenerator struct {
@@ -20,45 +23,86 @@ type tokenGenerator struct {
 	endpoint    string
 	redirectUrl string
 	client      Client
+	scope       string
 }
 
 func NewTokenGenerator(transport Transport, endpoint string, redirectUrl string, client Client) TokenGenerator {
+	return NewScopedTokenGenerator(transport, endpoint, redirectUrl, client, ScopeAccounts)
+}
+
+// NewScopedTokenGenerator requests access tokens for codes of a consent of an OB scope, see ScopeAccounts
+func NewScopedTokenGenerator(transport Transport, endpoint string, redirectUrl string, client Client, scope string) TokenGenerator {
 	return tokenGenerator{
 		transport:   transport,
 		endpoint:    endpoint,
 		redirectUrl: redirectUrl,
 		client:      client,
+		scope:       scope,
 	}
 }
 
 func (t tokenGenerator) Request(code Code) (Token, error) {
-	client, err := t.transport.Client()
+	return t.RequestContext(context.Background(), code)
+}
+
+func (t tokenGenerator) RequestContext(ctx context.Context, code Code) (Token, error) {
+	token, err := t.send(ctx, t.authCodeGrantReader(code))
 	if err != nil {
 		return NoToken, errors.Wrap(err, "error getting access token")
 	}
 
-	request, err := http.NewRequest(http.MethodPost, t.endpoint, t.authCodeGrantReader(code))
+	return token, nil
+}
+
+func (t tokenGenerator) Refresh(token Token) (Token, error) {
+	return t.RefreshContext(context.Background(), token)
+}
+
+// RefreshContext gets a new access token with the token refresh token, which is kept
+// when the ASPSP does not issue a new one
+func (t tokenGenerator) RefreshContext(ctx context.Context, token Token) (Token, error) {
+	if token.RefreshToken == "" {
+		return NoToken, errors.New("error refreshing access token: no refresh token")
+	}
+
+	refreshed, err := t.send(ctx, refreshGrantReader(token.RefreshToken))
 	if err != nil {
-		return NoToken, errors.Wrap(err, "error getting access token")
+		return NoToken, errors.Wrap(err, "error refreshing access token")
+	}
+	if refreshed.RefreshToken == "" {
+		refreshed.RefreshToken = token.RefreshToken
+	}
+
+	return refreshed, nil
+}
+
+func (t tokenGenerator) send(ctx context.Context, body io.Reader) (Token, error) {
+	client, err := t.transport.Client()
+	if err != nil {
+		return NoToken, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint, body)
+	if err != nil {
+		return NoToken, err
 	}
 	request.Header.Set("Content-type", "application/x-www-form-urlencoded")
 	request.Header.Set("Authorization", t.client.AuthHeader())
 
 	response, err := client.Do(request)
 	if err != nil {
-		return NoToken, errors.Wrap(err, "error getting access token")
+		return NoToken, err
 	}
+	defer response.Body.Close()
 
 	if response.StatusCode != http.StatusOK {
 		body, _ := ioutil.ReadAll(response.Body)
-		fmt.Println(string(body))
-
-		return NoToken, errors.New("error getting access token unexpected status code")
+		return NoToken, errors.Errorf("unexpected status code %d: %s", response.StatusCode, body)
 	}
 
 	var accessTokenResponse AccessTokenResponse
 	if err = json.NewDecoder(response.Body).Decode(&accessTokenResponse); err != nil {
-		return NoToken, errors.Wrap(err, "error getting access token")
+		return NoToken, err
 	}
 
 	return Token(accessTokenResponse), nil
@@ -74,13 +118,25 @@ type AccessTokenResponse struct {
 	ExpiresIn   int64  `json:"expires_in"`
 	Scope       string `json:"scope"`
 	Id          string `json:"id_token"`
+	// RefreshToken is only issued by some ASPSPs and for some consents, e.g. VRP
+	RefreshToken string `json:"refresh_token,omitempty"`
 }
 
 func (t tokenGenerator) authCodeGrantReader(code Code) io.Reader {
 	data := url.Values{}
 	data.Set("grant_type", "authorization_code")
-	data.Set("scope", "accounts")
+	data.Set("scope", t.scope)
 	data.Set("code", code.Value)
 	data.Set("redirect_uri", t.redirectUrl)
+	if code.Verifier != "" {
+		data.Set("code_verifier", code.Verifier)
+	}
+	return strings.NewReader(data.Encode())
+}
+
+func refreshGrantReader(refreshToken string) io.Reader {
+	data := url.Values{}
+	data.Set("grant_type", "refresh_token")
+	data.Set("refresh_token", refreshToken)
 	return strings.NewReader(data.Encode())
 }
@@ -0,0 +1,98 @@
+package authorization
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// TokenIntrospector tells whether a token is active at the discovery introspection_endpoint, RFC 7662
+type TokenIntrospector interface {
+	// Introspect introspects a token, the type hint may be empty
+	Introspect(string, string) (Introspection, error)
+	IntrospectContext(context.Context, string, string) (Introspection, error)
+}
+
+// Introspection is the token state at the authorization server, only Active is
+// set for inactive tokens and zero times are not reported
+type Introspection struct {
+	Active    bool
+	Scope     string
+	ClientId  string
+	TokenType string
+	Subject   string
+	Issuer    string
+	// ConsentId is the OB intent the token was authorised for, empty for client credentials tokens
+	ConsentId string
+	IssuedAt  time.Time
+	Expires   time.Time
+}
+
+var NoIntrospection = Introspection{}
+
+type IntrospectionResponse struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope"`
+	ClientId  string `json:"client_id"`
+	TokenType string `json:"token_type"`
+	Exp       int64  `json:"exp"`
+	Iat       int64  `json:"iat"`
+	Sub       string `json:"sub"`
+	Iss       string `json:"iss"`
+	IntentId  string `json:"openbanking_intent_id"`
+}
+
+type tokenIntrospector struct {
+	transport Transport
+	endpoint  string
+	client    Client
+}
+
+func NewTokenIntrospector(transport Transport, endpoint string, client Client) TokenIntrospector {
+	return tokenIntrospector{
+		transport: transport,
+		endpoint:  endpoint,
+		client:    client,
+	}
+}
+
+func (t tokenIntrospector) Introspect(token, tokenTypeHint string) (Introspection, error) {
+	return t.IntrospectContext(context.Background(), token, tokenTypeHint)
+}
+
+func (t tokenIntrospector) IntrospectContext(ctx context.Context, token, tokenTypeHint string) (Introspection, error) {
+	response, err := postTokenForm(ctx, t.transport, t.endpoint, t.client, token, tokenTypeHint)
+	if err != nil {
+		return NoIntrospection, errors.Wrap(err, "error introspecting token")
+	}
+	defer response.Body.Close()
+
+	var introspection IntrospectionResponse
+	if err = json.NewDecoder(response.Body).Decode(&introspection); err != nil {
+		return NoIntrospection, errors.Wrap(err, "error introspecting token")
+	}
+
+	if !introspection.Active {
+		return NoIntrospection, nil
+	}
+
+	return Introspection{
+		Active:    true,
+		Scope:     introspection.Scope,
+		ClientId:  introspection.ClientId,
+		TokenType: introspection.TokenType,
+		Subject:   introspection.Sub,
+		Issuer:    introspection.Iss,
+		ConsentId: introspection.IntentId,
+		IssuedAt:  unixTime(introspection.Iat),
+		Expires:   unixTime(introspection.Exp),
+	}, nil
+}
+
+func unixTime(seconds int64) time.Time {
+	if seconds == 0 {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0)
+}
@@ -0,0 +1,95 @@
+package authorization
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Token type hints tell the authorization server which kind of token is revoked or introspected
+const (
+	TokenTypeHintAccessToken  = "access_token"
+	TokenTypeHintRefreshToken = "refresh_token"
+)
+
+// TokenRevoker revokes tokens at the discovery revocation_endpoint, RFC 7009,
+// revoking a token that is unknown or already revoked is not an error
+type TokenRevoker interface {
+	// Revoke revokes a token, the type hint may be empty
+	Revoke(string, string) error
+	RevokeContext(context.Context, string, string) error
+}
+
+type tokenRevoker struct {
+	transport Transport
+	endpoint  string
+	client    Client
+}
+
+func NewTokenRevoker(transport Transport, endpoint string, client Client) TokenRevoker {
+	return tokenRevoker{
+		transport: transport,
+		endpoint:  endpoint,
+		client:    client,
+	}
+}
+
+func (t tokenRevoker) Revoke(token, tokenTypeHint string) error {
+	return t.RevokeContext(context.Background(), token, tokenTypeHint)
+}
+
+func (t tokenRevoker) RevokeContext(ctx context.Context, token, tokenTypeHint string) error {
+	response, err := postTokenForm(ctx, t.transport, t.endpoint, t.client, token, tokenTypeHint)
+	if err != nil {
+		return errors.Wrap(err, "error revoking token")
+	}
+	response.Body.Close()
+
+	return nil
+}
+
+// postTokenForm posts a token and its type hint authenticated as client, as revocation and
+// introspection requests are, responses other than 200 are returned as errors
+func postTokenForm(ctx context.Context, transport Transport, endpoint string, client Client, token, tokenTypeHint string) (*http.Response, error) {
+	if endpoint == "" {
+		return nil, errors.New("endpoint not advertised by the openid configuration")
+	}
+
+	httpClient, err := transport.Client()
+	if err != nil {
+		return nil, err
+	}
+
+	data := url.Values{}
+	data.Set("token", token)
+	if tokenTypeHint != "" {
+		data.Set("token_type_hint", tokenTypeHint)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", client.AuthHeader())
+
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		defer response.Body.Close()
+		var oauthError struct {
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}
+		json.NewDecoder(response.Body).Decode(&oauthError)
+		return nil, errors.Errorf("unexpected status code %d %s %s", response.StatusCode, oauthError.Error, oauthError.Description)
+	}
+
+	return response, nil
+}
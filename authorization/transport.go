@@ -13,18 +13,35 @@ type Transport interface {
 	Client() (*http.Client, error)
 }
 
+// TransportPolicy configures retries and per host concurrency of a secure transport
+type TransportPolicy struct {
+	Retry                RetryPolicy
+	MaxConcurrentPerHost int
+}
+
+var DefaultTransportPolicy = TransportPolicy{
+	Retry:                DefaultRetryPolicy,
+	MaxConcurrentPerHost: 4,
+}
+
 type secureTransport struct {
 	cerFile string
 	keyFile string
 	certs   []string
+	policy  TransportPolicy
 	conn    *http.Client
 }
 
 func NewSecureTransport(cerFile, keyFile string, certs []string) Transport {
+	return NewSecureTransportWithPolicy(cerFile, keyFile, certs, DefaultTransportPolicy)
+}
+
+func NewSecureTransportWithPolicy(cerFile, keyFile string, certs []string, policy TransportPolicy) Transport {
 	return &secureTransport{
 		cerFile: cerFile,
 		keyFile: keyFile,
 		certs:   certs,
+		policy:  policy,
 	}
 }
 
@@ -37,16 +54,9 @@ func (t *secureTransport) Client() (*http.Client, error) {
 }
 
 func (t *secureTransport) client() (*http.Client, error) {
-	pool := x509.NewCertPool()
-	for _, cert := range t.certs {
-		ca, err := ioutil.ReadFile(cert)
-		if err != nil {
-			return nil, errors.Wrapf(err, "error reading cert %s", cert)
-		}
-
-		if ok := pool.AppendCertsFromPEM(ca); !ok {
-			return nil, errors.Wrapf(err, "error appending cert %s", cert)
-		}
+	pool, err := appendCerts(x509.NewCertPool(), t.certs)
+	if err != nil {
+		return nil, err
 	}
 
 	clientCert, err := tls.LoadX509KeyPair(t.cerFile, t.keyFile)
@@ -64,7 +74,62 @@ func (t *secureTransport) client() (*http.Client, error) {
 	}
 
 	return &http.Client{
-		Timeout:   time.Minute * 2,
-		Transport: &transport,
+		Timeout: time.Minute * 2,
+		Transport: NewRetryRoundTripper(
+			NewHostLimitRoundTripper(&transport, t.policy.MaxConcurrentPerHost),
+			t.policy.Retry,
+		),
 	}, nil
 }
+
+type discoveryTransport struct {
+	certs []string
+	conn  *http.Client
+}
+
+// NewDiscoveryTransport returns a transport without client certificate trusting
+// system roots plus certs, for public endpoints like openid configuration
+func NewDiscoveryTransport(certs []string) Transport {
+	return &discoveryTransport{
+		certs: certs,
+	}
+}
+
+func (t *discoveryTransport) Client() (*http.Client, error) {
+	if t.conn != nil {
+		return t.conn, nil
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+
+	pool, err = appendCerts(pool, t.certs)
+	if err != nil {
+		return nil, err
+	}
+
+	t.conn = &http.Client{
+		Timeout: time.Minute,
+		Transport: NewRetryRoundTripper(
+			&http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}},
+			DefaultRetryPolicy,
+		),
+	}
+	return t.conn, nil
+}
+
+func appendCerts(pool *x509.CertPool, certs []string) (*x509.CertPool, error) {
+	for _, cert := range certs {
+		ca, err := ioutil.ReadFile(cert)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error reading cert %s", cert)
+		}
+
+		if ok := pool.AppendCertsFromPEM(ca); !ok {
+			return nil, errors.Errorf("error appending cert %s", cert)
+		}
+	}
+	return pool, nil
+}
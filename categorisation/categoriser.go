@@ -0,0 +1,139 @@
+package categorisation
+
+import (
+	"github.com/jmatosp/obclient/aspsp"
+	"github.com/pkg/errors"
+	"regexp"
+	"strings"
+)
+
+// Categoriser tags transactions with the categories of every rule they match
+type Categoriser interface {
+	Categorise(aspsp.Transaction) aspsp.Transaction
+	CategoriseAll([]aspsp.Transaction) []aspsp.Transaction
+}
+
+type categoriser struct {
+	rules []compiledRule
+}
+
+type compiledRule struct {
+	Rule
+	information *regexp.Regexp
+	reference   *regexp.Regexp
+}
+
+// NewCategoriser validates rules, a rule without category or with an invalid
+// regular expression or amount is an error
+func NewCategoriser(rules []Rule) (Categoriser, error) {
+	compiled := make([]compiledRule, 0, len(rules))
+	for i, rule := range rules {
+		if rule.Category == "" {
+			return nil, errors.Errorf("error rule %d has no category", i+1)
+		}
+
+		compiledRule := compiledRule{Rule: rule}
+		var err error
+		if rule.Information != "" {
+			if compiledRule.information, err = regexp.Compile(rule.Information); err != nil {
+				return nil, errors.Wrapf(err, "error rule %d information", i+1)
+			}
+		}
+		if rule.Reference != "" {
+			if compiledRule.reference, err = regexp.Compile(rule.Reference); err != nil {
+				return nil, errors.Wrapf(err, "error rule %d reference", i+1)
+			}
+		}
+		for _, amount := range []string{rule.MinAmount, rule.MaxAmount} {
+			if amount == "" {
+				continue
+			}
+			if _, err = aspsp.ParseMoney(amount, rule.Currency); err != nil {
+				return nil, errors.Wrapf(err, "error rule %d amount", i+1)
+			}
+		}
+
+		compiled = append(compiled, compiledRule)
+	}
+
+	return categoriser{rules: compiled}, nil
+}
+
+// LoadCategoriser builds a categoriser from a rules file, see LoadRules
+func LoadCategoriser(filename string) (Categoriser, error) {
+	rules, err := LoadRules(filename)
+	if err != nil {
+		return nil, err
+	}
+	return NewCategoriser(rules)
+}
+
+// Categorise returns transaction with matching categories added to the ones it has, without duplicates
+func (c categoriser) Categorise(transaction aspsp.Transaction) aspsp.Transaction {
+	categories := append([]string{}, transaction.Categories...)
+	for _, rule := range c.rules {
+		if rule.matches(transaction) && !contains(categories, rule.Category) {
+			categories = append(categories, rule.Category)
+		}
+	}
+
+	if len(categories) > 0 {
+		transaction.Categories = categories
+	}
+	return transaction
+}
+
+func (c categoriser) CategoriseAll(transactions []aspsp.Transaction) []aspsp.Transaction {
+	categorised := make([]aspsp.Transaction, 0, len(transactions))
+	for _, transaction := range transactions {
+		categorised = append(categorised, c.Categorise(transaction))
+	}
+	return categorised
+}
+
+func (r compiledRule) matches(transaction aspsp.Transaction) bool {
+	if r.information != nil && !r.information.MatchString(transaction.Information) {
+		return false
+	}
+	if r.reference != nil && !r.reference.MatchString(transaction.Reference) {
+		return false
+	}
+	if r.Currency != "" && !strings.EqualFold(r.Currency, transaction.Amount.Currency()) {
+		return false
+	}
+	if r.MinAmount != "" && compareAmount(transaction.Amount, r.MinAmount) < 0 {
+		return false
+	}
+	if r.MaxAmount != "" && compareAmount(transaction.Amount, r.MaxAmount) > 0 {
+		return false
+	}
+	if len(r.MerchantCategoryCodes) > 0 && !contains(r.MerchantCategoryCodes, transaction.MerchantCategoryCode) {
+		return false
+	}
+	if r.CounterpartyAccount != "" && normaliseAccount(r.CounterpartyAccount) != normaliseAccount(transaction.Counterparty.Identification) {
+		return false
+	}
+	return true
+}
+
+// compareAmount compares amount with a rule bound, the bound takes the amount currency
+// as rules without currency apply to any, bounds were validated by NewCategoriser
+func compareAmount(amount aspsp.Money, bound string) int {
+	boundMoney, _ := aspsp.ParseMoney(bound, amount.Currency())
+	cmp, _ := amount.Cmp(boundMoney)
+	return cmp
+}
+
+// normaliseAccount ignores spaces and dashes people add to sort codes and IBANs
+func normaliseAccount(identification string) string {
+	return strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(identification))
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+package categorisation
+
+import (
+	"github.com/jmatosp/obclient/aspsp"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestCategorise(t *testing.T) {
+	transaction := func(pence int64, currency string, change func(*aspsp.Transaction)) aspsp.Transaction {
+		transaction := aspsp.Transaction{
+			Id:                   "123",
+			Amount:               aspsp.NewMoney(pence, currency),
+			Information:          "TESCO STORES 2041",
+			Reference:            "Card 4821",
+			MerchantCategoryCode: "5411",
+			Counterparty:         aspsp.Counterparty{SchemeName: "UK.OBIE.SortCodeAccountNumber", Identification: "80200110203345"},
+		}
+		if change != nil {
+			change(&transaction)
+		}
+		return transaction
+	}
+
+	tests := []struct {
+		name        string
+		rule        Rule
+		transaction aspsp.Transaction
+		want        bool
+	}{
+		{name: "no conditions", rule: Rule{}, transaction: transaction(-2550, "GBP", nil), want: true},
+		{name: "information regex", rule: Rule{Information: `^TESCO\b`}, transaction: transaction(-2550, "GBP", nil), want: true},
+		{name: "information regex is case sensitive", rule: Rule{Information: `^tesco`}, transaction: transaction(-2550, "GBP", nil)},
+		{name: "information regex with flags", rule: Rule{Information: `(?i)^tesco`}, transaction: transaction(-2550, "GBP", nil), want: true},
+		{name: "reference regex", rule: Rule{Reference: `^Card \d+$`}, transaction: transaction(-2550, "GBP", nil), want: true},
+		{name: "reference regex not matching", rule: Rule{Reference: `^Salary`}, transaction: transaction(-2550, "GBP", nil)},
+		{name: "every condition must match", rule: Rule{Information: `TESCO`, Reference: `^Salary`}, transaction: transaction(-2550, "GBP", nil)},
+		{name: "min amount is included", rule: Rule{MinAmount: "-25.50"}, transaction: transaction(-2550, "GBP", nil), want: true},
+		{name: "below min amount", rule: Rule{MinAmount: "-25.49"}, transaction: transaction(-2550, "GBP", nil)},
+		{name: "max amount is included", rule: Rule{MaxAmount: "-25.50"}, transaction: transaction(-2550, "GBP", nil), want: true},
+		{name: "above max amount", rule: Rule{MaxAmount: "-25.51"}, transaction: transaction(-2550, "GBP", nil)},
+		{name: "debits are negative", rule: Rule{MaxAmount: "0"}, transaction: transaction(-2550, "GBP", nil), want: true},
+		{name: "credit is not a debit bound", rule: Rule{MaxAmount: "0"}, transaction: transaction(2550, "GBP", nil)},
+		{name: "debit within bounds", rule: Rule{MinAmount: "-100", MaxAmount: "-10"}, transaction: transaction(-2550, "GBP", nil), want: true},
+		{name: "positive bounds do not match a debit", rule: Rule{MinAmount: "10", MaxAmount: "100"}, transaction: transaction(-2550, "GBP", nil)},
+		{name: "currency", rule: Rule{Currency: "GBP"}, transaction: transaction(-2550, "GBP", nil), want: true},
+		{name: "currency ignores case", rule: Rule{Currency: "gbp"}, transaction: transaction(-2550, "GBP", nil), want: true},
+		{name: "other currency", rule: Rule{Currency: "EUR"}, transaction: transaction(-2550, "GBP", nil)},
+		{name: "bounds without currency take the transaction currency", rule: Rule{MinAmount: "-30"}, transaction: transaction(-2550, "EUR", nil), want: true},
+		{name: "merchant category code", rule: Rule{MerchantCategoryCodes: []string{"5812", "5411"}}, transaction: transaction(-2550, "GBP", nil), want: true},
+		{name: "other merchant category code", rule: Rule{MerchantCategoryCodes: []string{"5812"}}, transaction: transaction(-2550, "GBP", nil)},
+		{name: "merchant category code of a transaction without one", rule: Rule{MerchantCategoryCodes: []string{"5411"}}, transaction: transaction(-2550, "GBP", func(t *aspsp.Transaction) {
+			t.MerchantCategoryCode = ""
+		})},
+		{name: "counterparty account", rule: Rule{CounterpartyAccount: "80200110203345"}, transaction: transaction(-2550, "GBP", nil), want: true},
+		{name: "counterparty account with spaces and dashes", rule: Rule{CounterpartyAccount: "80-20-01 10203345"}, transaction: transaction(-2550, "GBP", nil), want: true},
+		{name: "counterparty iban ignores case and spaces", rule: Rule{CounterpartyAccount: "gb29 nwbk 6016 1331 9268 19"}, transaction: transaction(-2550, "GBP", func(t *aspsp.Transaction) {
+			t.Counterparty = aspsp.Counterparty{SchemeName: "UK.OBIE.IBAN", Identification: "GB29NWBK60161331926819"}
+		}), want: true},
+		{name: "other counterparty account", rule: Rule{CounterpartyAccount: "80200110203348"}, transaction: transaction(-2550, "GBP", nil)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.rule.Category = "Groceries"
+			categoriser, err := NewCategoriser([]Rule{test.rule})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := categoriser.Categorise(test.transaction)
+			if matched := len(got.Categories) == 1 && got.Categories[0] == "Groceries"; matched != test.want {
+				t.Errorf("got categories %q, want match %t", got.Categories, test.want)
+			}
+		})
+	}
+}
+
+func TestCategoriseKeepsCategories(t *testing.T) {
+	categoriser, err := NewCategoriser([]Rule{
+		{Category: "Groceries", Information: "TESCO"},
+		{Category: "Shopping"},
+		{Category: "Groceries", MaxAmount: "0"},
+		{Category: "Salary", Reference: "^Salary"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transaction := aspsp.Transaction{Amount: aspsp.NewMoney(-2550, "GBP"), Information: "TESCO STORES", Categories: []string{"Shopping"}}
+	transactions := categoriser.CategoriseAll([]aspsp.Transaction{transaction, {Amount: aspsp.NewMoney(150000, "GBP"), Reference: "Salary May"}})
+
+	want := [][]string{{"Shopping", "Groceries"}, {"Shopping", "Salary"}}
+	for i, transaction := range transactions {
+		if len(transaction.Categories) != len(want[i]) {
+			t.Fatalf("transaction %d got categories %q, want %q", i, transaction.Categories, want[i])
+		}
+		for j := range want[i] {
+			if transaction.Categories[j] != want[i][j] {
+				t.Errorf("transaction %d got categories %q, want %q", i, transaction.Categories, want[i])
+			}
+		}
+	}
+	if len(transaction.Categories) != 1 {
+		t.Errorf("categorised the transaction given, it has categories %q", transaction.Categories)
+	}
+}
+
+func TestNewCategoriserInvalidRules(t *testing.T) {
+	tests := []struct {
+		name string
+		rule Rule
+	}{
+		{name: "no category", rule: Rule{Information: "TESCO"}},
+		{name: "invalid information regex", rule: Rule{Category: "Groceries", Information: "TESCO("}},
+		{name: "invalid reference regex", rule: Rule{Category: "Groceries", Reference: "[Card"}},
+		{name: "invalid min amount", rule: Rule{Category: "Groceries", MinAmount: "ten"}},
+		{name: "invalid max amount", rule: Rule{Category: "Groceries", MaxAmount: "-1,000"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := NewCategoriser([]Rule{{Category: "Shopping"}, test.rule}); err == nil {
+				t.Error("rule accepted, want error")
+			}
+		})
+	}
+}
+
+func TestLoadRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		content  string
+		want     int
+		wantErr  bool
+	}{
+		{name: "yaml", filename: "rules.yaml", content: "rules:\n  - category: Groceries\n    information: TESCO\n    merchantCategoryCodes: [\"5411\"]\n  - category: Bills\n    maxAmount: \"-10\"\n", want: 2},
+		{name: "json", filename: "rules.JSON", content: `{"rules":[{"category":"Groceries","information":"TESCO"}]}`, want: 1},
+		{name: "yaml with unknown field", filename: "rules.yml", content: "rules:\n  - category: Groceries\n    merchant: TESCO\n", wantErr: true},
+		{name: "invalid json", filename: "rules.json", content: `{"rules":`, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), test.filename)
+			if err := ioutil.WriteFile(filename, []byte(test.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			rules, err := LoadRules(filename)
+			if test.wantErr {
+				if err == nil {
+					t.Error("rules loaded, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rules) != test.want {
+				t.Errorf("got %d rules, want %d", len(rules), test.want)
+			}
+		})
+	}
+
+	if _, err := LoadRules(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("missing file loaded")
+	}
+}
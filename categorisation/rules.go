@@ -0,0 +1,48 @@
+package categorisation
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// Rule tags transactions matching all of its conditions with Category, conditions
+// left empty match any transaction. Information and Reference are regular expressions,
+// amounts are signed so debits are negative and both bounds are inclusive
+type Rule struct {
+	Category              string   `json:"category" yaml:"category"`
+	Information           string   `json:"information" yaml:"information"`
+	Reference             string   `json:"reference" yaml:"reference"`
+	MinAmount             string   `json:"minAmount" yaml:"minAmount"`
+	MaxAmount             string   `json:"maxAmount" yaml:"maxAmount"`
+	Currency              string   `json:"currency" yaml:"currency"`
+	MerchantCategoryCodes []string `json:"merchantCategoryCodes" yaml:"merchantCategoryCodes"`
+	CounterpartyAccount   string   `json:"counterpartyAccount" yaml:"counterpartyAccount"`
+}
+
+type rulesFile struct {
+	Rules []Rule `json:"rules" yaml:"rules"`
+}
+
+// LoadRules reads rules from a JSON file when its extension is .json, YAML otherwise
+func LoadRules(filename string) ([]Rule, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, errors.Wrap(err, "error loading categorisation rules")
+	}
+
+	var file rulesFile
+	if strings.EqualFold(filepath.Ext(filename), ".json") {
+		err = json.Unmarshal(data, &file)
+	} else {
+		err = yaml.UnmarshalStrict(data, &file)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "error loading categorisation rules")
+	}
+
+	return file.Rules, nil
+}
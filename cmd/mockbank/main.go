@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/jmatosp/obclient/aspsp"
+	"github.com/jmatosp/obclient/aspsptest"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/signal"
+	"path"
+)
+
+const (
+	mockBankBanner = "Open Banking Mock ASPSP v0.0.1"
+	storageFolder  = ".obcli"
+)
+
+func main() {
+	var addr, dir, fixturesFile string
+	var pageSize int
+	var noPAR, jarm bool
+
+	rootCmd := &cobra.Command{
+		Use:   "mockbank",
+		Short: "Local mock ASPSP serving fixture data over mutual TLS",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := serve(addr, dir, fixturesFile, pageSize, noPAR, jarm); err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+	rootCmd.Flags().StringVar(&addr, "addr", "127.0.0.1:4443", "address to listen on")
+	rootCmd.Flags().StringVar(&dir, "dir", ".mockbank", "folder where certificates and obcli config.json are written")
+	rootCmd.Flags().StringVar(&fixturesFile, "fixtures", "", "AIS fixtures json file, defaults to built in sample accounts")
+	rootCmd.Flags().IntVar(&pageSize, "page-size", 0, "transactions per page, 0 serves them in a single page")
+	rootCmd.Flags().BoolVar(&noPAR, "no-par", false, "do not advertise pushed authorization requests, request objects go in the front channel")
+	rootCmd.Flags().BoolVar(&jarm, "jarm", false, "return authorization responses as signed JWTs (JARM)")
+
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func serve(addr, dir, fixturesFile string, pageSize int, noPAR, jarm bool) error {
+	fixtures := aspsptest.DefaultFixtures()
+	if fixturesFile != "" {
+		var err error
+		fixtures, err = aspsptest.LoadFixtures(fixturesFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	certificates, err := aspsptest.NewCertificates()
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(path.Join(dir, storageFolder), 0700); err != nil {
+		return errors.Wrap(err, "error creating mock bank folder")
+	}
+	files, err := certificates.WriteFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return errors.Wrap(err, "error starting mock bank")
+	}
+	server := aspsptest.NewUnstartedServer(certificates, fixtures)
+	server.PageSize = pageSize
+	server.NoPAR = noPAR
+	server.JARM = jarm
+	server.Listener.Close()
+	server.Listener = listener
+	server.StartTLS()
+	defer server.Close()
+
+	if err = writeConfig(dir, files, server); err != nil {
+		return err
+	}
+
+	fmt.Println(mockBankBanner)
+	fmt.Printf("Listening on %s\n", server.URL)
+	fmt.Printf("obcli configuration written to %s, run obcli from that folder\n", path.Join(dir, "config.json"))
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	<-interrupt
+	return nil
+}
+
+// writeConfig writes an obcli config.json with paths relative to dir
+func writeConfig(dir string, files aspsptest.Files, server *aspsptest.Server) error {
+	config := map[string]interface{}{
+		"storageFolder":       storageFolder,
+		"fapiFinancialId":     "0015800001041RHAAY",
+		"openidConfiguration": server.DiscoveryURL(),
+		"bankProfile": aspsp.BankProfile{
+			Name:    "mockbank",
+			BaseURL: server.URL,
+			Version: aspsp.Version31,
+		},
+		"organisationId":        "0015800001ZEZ3hAAH",
+		"softwareStatementID":   "mockbank-tpp",
+		"signingKeyId":          aspsptest.ClientSigningKeyId,
+		"softwareStatementName": "obcli",
+		"redirectUrl":           "http://localhost:8081/callback",
+		"sigPublicKeyFile":      path.Base(files.SigPublicKeyFile),
+		"sigPrivateKeyFile":     path.Base(files.SigPrivateKeyFile),
+		"cerFile":               path.Base(files.CertFile),
+		"keyFile":               path.Base(files.KeyFile),
+		"rootCAs":               []string{path.Base(files.RootCA)},
+	}
+
+	content, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "error writing obcli config")
+	}
+
+	if err = ioutil.WriteFile(path.Join(dir, "config.json"), content, 0600); err != nil {
+		return errors.Wrap(err, "error writing obcli config")
+	}
+
+	return nil
+}
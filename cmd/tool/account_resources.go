@@ -0,0 +1,337 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/jmatosp/obclient/aspsp"
+	"github.com/jmatosp/obclient/authorization"
+	"github.com/spf13/cobra"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+func newStandingOrdersCmd(ctx context.Context, storageFolder string) *cobra.Command {
+	var accountId string
+	cmd := &cobra.Command{
+		Use:   "standing-orders",
+		Short: "List standing orders, of all accounts unless --account is given",
+		Run: func(cmd *cobra.Command, args []string) {
+			standingOrdersList(ctx, storageFolder, aspsp.AccountId(accountId))
+		},
+	}
+	cmd.Flags().StringVar(&accountId, "account", "", "account id")
+	return cmd
+}
+
+func newDirectDebitsCmd(ctx context.Context, storageFolder string) *cobra.Command {
+	var accountId string
+	cmd := &cobra.Command{
+		Use:   "direct-debits",
+		Short: "List direct debits, of all accounts unless --account is given",
+		Run: func(cmd *cobra.Command, args []string) {
+			directDebitsList(ctx, storageFolder, aspsp.AccountId(accountId))
+		},
+	}
+	cmd.Flags().StringVar(&accountId, "account", "", "account id")
+	return cmd
+}
+
+func newBeneficiariesCmd(ctx context.Context, storageFolder string) *cobra.Command {
+	var accountId string
+	cmd := &cobra.Command{
+		Use:   "beneficiaries",
+		Short: "List beneficiaries, of all accounts unless --account is given",
+		Run: func(cmd *cobra.Command, args []string) {
+			beneficiariesList(ctx, storageFolder, aspsp.AccountId(accountId))
+		},
+	}
+	cmd.Flags().StringVar(&accountId, "account", "", "account id")
+	return cmd
+}
+
+func standingOrdersList(ctx context.Context, storageFolder string, accountId aspsp.AccountId) {
+	banner("Standing orders")
+	lister := aspsp.NewStandingOrderLister(makeResourceClient(mustGetToken(ctx, storageFolder)))
+	var standingOrders []aspsp.StandingOrder
+	var err error
+	if accountId == "" {
+		standingOrders, err = lister.ListAllContext(ctx)
+	} else {
+		standingOrders, err = lister.ListContext(ctx, accountId)
+	}
+	mustCheckResourceErr(ctx, storageFolder, err)
+	mustPrint(aspsp.StandingOrdersTable(standingOrders))
+}
+
+func directDebitsList(ctx context.Context, storageFolder string, accountId aspsp.AccountId) {
+	banner("Direct debits")
+	lister := aspsp.NewDirectDebitLister(makeResourceClient(mustGetToken(ctx, storageFolder)))
+	var directDebits []aspsp.DirectDebit
+	var err error
+	if accountId == "" {
+		directDebits, err = lister.ListAllContext(ctx)
+	} else {
+		directDebits, err = lister.ListContext(ctx, accountId)
+	}
+	mustCheckResourceErr(ctx, storageFolder, err)
+	mustPrint(aspsp.DirectDebitsTable(directDebits))
+}
+
+func beneficiariesList(ctx context.Context, storageFolder string, accountId aspsp.AccountId) {
+	banner("Beneficiaries")
+	lister := aspsp.NewBeneficiaryLister(makeResourceClient(mustGetToken(ctx, storageFolder)))
+	var beneficiaries []aspsp.Beneficiary
+	var err error
+	if accountId == "" {
+		beneficiaries, err = lister.ListAllContext(ctx)
+	} else {
+		beneficiaries, err = lister.ListContext(ctx, accountId)
+	}
+	mustCheckResourceErr(ctx, storageFolder, err)
+	mustPrint(aspsp.BeneficiariesTable(beneficiaries))
+}
+
+// mustGetToken returns the stored token, refreshing its access token once expired
+func mustGetToken(ctx context.Context, storageFolder string) authorization.Token {
+	generator, err := makeTokenEndpointsBuilder(mustGetClient(storageFolder)).BuildTokenGenerator()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	stored, err := aspsp.NewRefreshingFileTokenStorer(storageFolder, generator).GetContext(ctx)
+	if invalid, ok := err.(aspsp.InvalidTokenError); ok {
+		mustReauthenticate(invalid)
+	} else if err == aspsp.ErrNotFound {
+		fmt.Fprintln(os.Stderr, "No token yet, run auth first.")
+		os.Exit(1)
+	} else if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	return stored.Token
+}
+
+func newTransactionsCmd(ctx context.Context, storageFolder string) *cobra.Command {
+	var accountId, from, to, rules string
+	var limit int
+	var offline bool
+	cmd := &cobra.Command{
+		Use:   "transactions",
+		Short: "List account transactions",
+		Run: func(cmd *cobra.Command, args []string) {
+			if offline {
+				offlineTransactionsList(storageFolder, aspsp.AccountId(accountId), mustParseDate(from), mustParseEndDate(to), limit, rules)
+				return
+			}
+			transactionsList(ctx, storageFolder, aspsp.AccountId(accountId), mustParseDate(from), mustParseEndDate(to), limit, rules)
+		},
+	}
+	cmd.Flags().StringVar(&accountId, "account", "", "account id")
+	cmd.Flags().StringVar(&from, "from", "", "from booking date, YYYY-MM-DD")
+	cmd.Flags().StringVar(&to, "to", "", "to booking date, YYYY-MM-DD, included")
+	cmd.Flags().IntVar(&limit, "limit", 0, "maximum transactions to list, only the needed pages are fetched")
+	cmd.Flags().BoolVar(&offline, "offline", false, "list transactions from the local store, see sync")
+	cmd.Flags().StringVar(&rules, "rules", defaultRulesFile(), rulesFlagUsage)
+	cmd.MarkFlagRequired("account")
+	return cmd
+}
+
+func newBalancesCmd(ctx context.Context, storageFolder string) *cobra.Command {
+	var accountId string
+	cmd := &cobra.Command{
+		Use:   "balances",
+		Short: "List account balances",
+		Run: func(cmd *cobra.Command, args []string) {
+			banner("Balances")
+			balances, err := mustGetAccount(ctx, storageFolder, aspsp.AccountId(accountId)).BalancesContext(ctx)
+			mustCheckResourceErr(ctx, storageFolder, err)
+			mustPrint(aspsp.BalancesTable(balances))
+		},
+	}
+	cmd.Flags().StringVar(&accountId, "account", "", "account id")
+	cmd.MarkFlagRequired("account")
+	return cmd
+}
+
+func newProductCmd(ctx context.Context, storageFolder string) *cobra.Command {
+	var accountId string
+	cmd := &cobra.Command{
+		Use:   "product",
+		Short: "Show account product",
+		Run: func(cmd *cobra.Command, args []string) {
+			banner("Product")
+			products, err := mustGetAccount(ctx, storageFolder, aspsp.AccountId(accountId)).ProductsContext(ctx)
+			mustCheckResourceErr(ctx, storageFolder, err)
+			mustPrint(aspsp.ProductsTable(products))
+		},
+	}
+	cmd.Flags().StringVar(&accountId, "account", "", "account id")
+	cmd.MarkFlagRequired("account")
+	return cmd
+}
+
+func newOffersCmd(ctx context.Context, storageFolder string) *cobra.Command {
+	var accountId string
+	cmd := &cobra.Command{
+		Use:   "offers",
+		Short: "List account offers",
+		Run: func(cmd *cobra.Command, args []string) {
+			banner("Offers")
+			offers, err := mustGetAccount(ctx, storageFolder, aspsp.AccountId(accountId)).OffersContext(ctx)
+			mustCheckResourceErr(ctx, storageFolder, err)
+			mustPrint(aspsp.OffersTable(offers))
+		},
+	}
+	cmd.Flags().StringVar(&accountId, "account", "", "account id")
+	cmd.MarkFlagRequired("account")
+	return cmd
+}
+
+func newPartyCmd(ctx context.Context, storageFolder string) *cobra.Command {
+	var accountId string
+	var all bool
+	cmd := &cobra.Command{
+		Use:   "party",
+		Short: "Show account owner party, or all account parties with --all",
+		Run: func(cmd *cobra.Command, args []string) {
+			banner("Party")
+			account := mustGetAccount(ctx, storageFolder, aspsp.AccountId(accountId))
+			var parties []aspsp.Party
+			var err error
+			if all {
+				parties, err = account.PartiesContext(ctx)
+			} else {
+				var party aspsp.Party
+				party, err = account.PartyContext(ctx)
+				parties = []aspsp.Party{party}
+			}
+			mustCheckResourceErr(ctx, storageFolder, err)
+			mustPrint(aspsp.PartiesTable(parties))
+		},
+	}
+	cmd.Flags().StringVar(&accountId, "account", "", "account id")
+	cmd.Flags().BoolVar(&all, "all", false, "list all parties of the account")
+	cmd.MarkFlagRequired("account")
+	return cmd
+}
+
+func newScheduledPaymentsCmd(ctx context.Context, storageFolder string) *cobra.Command {
+	var accountId string
+	cmd := &cobra.Command{
+		Use:   "scheduled-payments",
+		Short: "List account scheduled payments",
+		Run: func(cmd *cobra.Command, args []string) {
+			banner("Scheduled payments")
+			scheduledPayments, err := mustGetAccount(ctx, storageFolder, aspsp.AccountId(accountId)).ScheduledPaymentsContext(ctx)
+			mustCheckResourceErr(ctx, storageFolder, err)
+			mustPrint(aspsp.ScheduledPaymentsTable(scheduledPayments))
+		},
+	}
+	cmd.Flags().StringVar(&accountId, "account", "", "account id")
+	cmd.MarkFlagRequired("account")
+	return cmd
+}
+
+func newStatementsCmd(ctx context.Context, storageFolder string) *cobra.Command {
+	var accountId, from, to, statementId, out string
+	cmd := &cobra.Command{
+		Use:   "statements",
+		Short: "List account statements",
+		Run: func(cmd *cobra.Command, args []string) {
+			banner("Statements")
+			statements, err := mustGetAccount(ctx, storageFolder, aspsp.AccountId(accountId)).StatementsContext(ctx, mustParseDate(from), mustParseDate(to))
+			mustCheckResourceErr(ctx, storageFolder, err)
+			mustPrint(aspsp.StatementsTable(statements))
+		},
+	}
+	cmd.PersistentFlags().StringVar(&accountId, "account", "", "account id")
+	cmd.Flags().StringVar(&from, "from", "", "from statement date, YYYY-MM-DD")
+	cmd.Flags().StringVar(&to, "to", "", "to statement date, YYYY-MM-DD")
+	cmd.MarkPersistentFlagRequired("account")
+
+	fileCmd := &cobra.Command{
+		Use:   "file",
+		Short: "Download statement file",
+		Run: func(cmd *cobra.Command, args []string) {
+			file, err := mustGetAccount(ctx, storageFolder, aspsp.AccountId(accountId)).StatementFileContext(ctx, statementId)
+			mustCheckResourceErr(ctx, storageFolder, err)
+			if err = ioutil.WriteFile(out, file, 0644); err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			fmt.Fprintf(os.Stderr, "Statement saved to %s\n", out)
+		},
+	}
+	fileCmd.Flags().StringVar(&statementId, "statement", "", "statement id")
+	fileCmd.Flags().StringVar(&out, "out", "statement.pdf", "file to save statement")
+	fileCmd.MarkFlagRequired("statement")
+
+	transactionsCmd := &cobra.Command{
+		Use:   "transactions",
+		Short: "List statement transactions",
+		Run: func(cmd *cobra.Command, args []string) {
+			banner("Statement transactions")
+			transactions, err := mustGetAccount(ctx, storageFolder, aspsp.AccountId(accountId)).StatementTransactionsContext(ctx, statementId)
+			mustCheckResourceErr(ctx, storageFolder, err)
+			mustPrint(aspsp.TransactionsTable(transactions))
+		},
+	}
+	transactionsCmd.Flags().StringVar(&statementId, "statement", "", "statement id")
+	transactionsCmd.MarkFlagRequired("statement")
+
+	cmd.AddCommand(fileCmd)
+	cmd.AddCommand(transactionsCmd)
+	return cmd
+}
+
+func transactionsList(ctx context.Context, storageFolder string, accountId aspsp.AccountId, from, to time.Time, limit int, rules string) {
+	banner("Transactions")
+	iterator := mustGetAccount(ctx, storageFolder, accountId).TransactionIteratorContext(ctx, from, to)
+	var transactions []aspsp.Transaction
+	for (limit <= 0 || len(transactions) < limit) && iterator.Next() {
+		transactions = append(transactions, iterator.Transaction())
+	}
+	mustCheckResourceErr(ctx, storageFolder, iterator.Err())
+	mustPrint(aspsp.TransactionsTable(mustCategorise(rules, transactions)))
+}
+
+// mustCheckResourceErr exits on a resource error, telling to authorize again when the bank rejected the consent
+func mustCheckResourceErr(ctx context.Context, storageFolder string, err error) {
+	if err == nil {
+		return
+	}
+	mustCheckRejectedConsent(ctx, storageFolder, err)
+	fmt.Fprintln(os.Stderr, err.Error())
+	os.Exit(1)
+}
+
+func mustGetAccount(ctx context.Context, storageFolder string, accountId aspsp.AccountId) aspsp.Account {
+	account, err := makeAccountLister(mustGetToken(ctx, storageFolder)).GetContext(ctx, accountId)
+	mustCheckResourceErr(ctx, storageFolder, err)
+	return account
+}
+
+// mustParseDate parses a YYYY-MM-DD flag value, an empty value is a zero time
+func mustParseDate(value string) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	return date
+}
+
+// mustParseEndDate parses a YYYY-MM-DD --to flag value as the last second of that day, so the day is
+// included, an empty value is a zero time
+func mustParseEndDate(value string) time.Time {
+	date := mustParseDate(value)
+	if date.IsZero() {
+		return date
+	}
+	return date.AddDate(0, 0, 1).Add(-time.Second)
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"github.com/jmatosp/obclient/aspsp"
+	"github.com/jmatosp/obclient/categorisation"
+	"github.com/spf13/viper"
+	"os"
+)
+
+// rulesFlagUsage documents the --rules flag, it defaults to the categoryRules setting
+const rulesFlagUsage = "categorisation rules file, yaml or json, defaults to categoryRules config"
+
+func defaultRulesFile() string {
+	return viper.GetString("categoryRules")
+}
+
+// mustCategorise tags transactions using the rules file, without one transactions are returned as they are
+func mustCategorise(rulesFile string, transactions []aspsp.Transaction) []aspsp.Transaction {
+	if rulesFile == "" {
+		return transactions
+	}
+
+	categoriser, err := categorisation.LoadCategoriser(rulesFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	return categoriser.CategoriseAll(transactions)
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/jmatosp/obclient/aspsp"
+	"github.com/jmatosp/obclient/authorization"
+	"github.com/spf13/cobra"
+	"os"
+	"time"
+)
+
+const schemeSortCodeAccountNumber = "UK.OBIE.SortCodeAccountNumber"
+
+func newFundsConfirmationCmd(ctx context.Context, storageFolder string) *cobra.Command {
+	var identification, scheme, authMode, listenAddr string
+	cmd := &cobra.Command{
+		Use:   "cof",
+		Short: "Confirmation of funds, consents are kept per debtor account",
+	}
+	cmd.PersistentFlags().StringVar(&identification, "account", "", "debtor account identification, e.g. sort code and account number")
+	cmd.PersistentFlags().StringVar(&scheme, "scheme", schemeSortCodeAccountNumber, "debtor account scheme name")
+	cmd.PersistentFlags().StringVar(&authMode, "mode", authModeBrowser, "consent mode: browser, paste, webhook or mock")
+	cmd.PersistentFlags().StringVar(&listenAddr, "listen", ":8081", "address the webhook mode waits for the callback on")
+	cmd.MarkPersistentFlagRequired("account")
+
+	var expires string
+	consentCmd := &cobra.Command{
+		Use:   "consent",
+		Short: "Create and authorise a funds confirmation consent",
+		Run: func(cmd *cobra.Command, args []string) {
+			banner("Funds confirmation consent")
+			consent := authoriseFundsConfirmation(ctx, storageFolder, aspsp.NewAccountIdentity(scheme, identification, "", "", ""), mustParseDate(expires), authMode, listenAddr)
+			fmt.Printf("Funds confirmation consent %s authorised\n", consent.ConsentId)
+		},
+	}
+	consentCmd.Flags().StringVar(&expires, "expires", "", "consent expiration date, YYYY-MM-DD, defaults to no expiration")
+
+	var amount, currency, reference string
+	checkCmd := &cobra.Command{
+		Use:   "check",
+		Short: "Confirm funds are available, a consent is created first when there is none",
+		Run: func(cmd *cobra.Command, args []string) {
+			money, err := aspsp.ParseMoney(amount, currency)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+
+			consent, err := aspsp.NewFileFundsConfirmationConsentStore(storageFolder).Get(identification)
+			if err == aspsp.ErrNotFound {
+				consent = authoriseFundsConfirmation(ctx, storageFolder, aspsp.NewAccountIdentity(scheme, identification, "", "", ""), time.Time{}, authMode, listenAddr)
+			} else if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+
+			banner("Confirmation of funds")
+			confirmer := aspsp.NewFundsConfirmer(makeEndpointClient(endpoint("cbpiiEndpoints", aspsp.APIFundsConfirmations), aspsp.NewStaticTokenSource(consent.Token)))
+			confirmation, err := confirmer.ConfirmContext(ctx, aspsp.FundsConfirmationRequest{
+				ConsentId: consent.ConsentId,
+				Reference: reference,
+				Amount:    money,
+			})
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			mustPrint(aspsp.FundsConfirmationsTable([]aspsp.FundsConfirmation{confirmation}))
+		},
+	}
+	checkCmd.Flags().StringVar(&amount, "amount", "", "amount to confirm, e.g. 120.50")
+	checkCmd.Flags().StringVar(&currency, "currency", "GBP", "amount currency")
+	checkCmd.Flags().StringVar(&reference, "reference", "obcli", "reference of the confirmation")
+	checkCmd.MarkFlagRequired("amount")
+
+	statusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the funds confirmation consent",
+		Run: func(cmd *cobra.Command, args []string) {
+			stored := mustGetFundsConfirmationConsent(storageFolder, identification)
+			consenter := makeFundsConfirmationConsenter(ctx, storageFolder, authMode, listenAddr)
+			consent, err := consenter.GetContext(ctx, stored.ConsentId)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			banner("Funds confirmation consent")
+			mustPrint(aspsp.FundsConfirmationConsentsTable([]aspsp.FundsConfirmationConsent{consent}))
+		},
+	}
+
+	revokeCmd := &cobra.Command{
+		Use:   "revoke",
+		Short: "Delete the funds confirmation consent at the bank and locally",
+		Run: func(cmd *cobra.Command, args []string) {
+			stored := mustGetFundsConfirmationConsent(storageFolder, identification)
+			consenter := makeFundsConfirmationConsenter(ctx, storageFolder, authMode, listenAddr)
+			if err := consenter.DeleteContext(ctx, stored.ConsentId); err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			if err := aspsp.NewFileFundsConfirmationConsentStore(storageFolder).Delete(identification); err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			fmt.Printf("Funds confirmation consent %s revoked\n", stored.ConsentId)
+		},
+	}
+
+	cmd.AddCommand(consentCmd)
+	cmd.AddCommand(checkCmd)
+	cmd.AddCommand(statusCmd)
+	cmd.AddCommand(revokeCmd)
+	return cmd
+}
+
+// authoriseFundsConfirmation creates a funds confirmation consent, has the PSU authorise it and stores it with its token
+func authoriseFundsConfirmation(ctx context.Context, storageFolder string, debtor aspsp.AccountIdentity, expires time.Time, authMode, listenAddr string) aspsp.AuthorisedFundsConfirmationConsent {
+	intent := mustMakeFundsConfirmationIntent(storageFolder, authMode, listenAddr)
+	grant, err := intent.GrantContext(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	consenter := aspsp.NewFundsConfirmationConsenter(makeEndpointClient(endpoint("cbpiiEndpoints", aspsp.APIFundsConfirmations), aspsp.NewGrantTokenSource(grant)))
+	consent, err := consenter.CreateContext(ctx, aspsp.FundsConfirmationConsentRequest{
+		DebtorAccount:      debtor,
+		ExpirationDateTime: expires,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	token, err := intent.AuthoriseContext(ctx, authorization.AccessConsent{
+		ConsentId: consent.ConsentId,
+		Scope:     authorization.ScopeFundsConfirmations,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	authorised := aspsp.AuthorisedFundsConfirmationConsent{
+		ConsentId:      consent.ConsentId,
+		Identification: debtor.Identification(),
+		Token:          token,
+	}
+	if err = aspsp.NewFileFundsConfirmationConsentStore(storageFolder).Store(authorised); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	return authorised
+}
+
+func makeFundsConfirmationConsenter(ctx context.Context, storageFolder, authMode, listenAddr string) aspsp.FundsConfirmationConsenter {
+	grant, err := mustMakeFundsConfirmationIntent(storageFolder, authMode, listenAddr).GrantContext(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	return aspsp.NewFundsConfirmationConsenter(makeEndpointClient(endpoint("cbpiiEndpoints", aspsp.APIFundsConfirmations), aspsp.NewGrantTokenSource(grant)))
+}
+
+func mustMakeFundsConfirmationIntent(storageFolder, authMode, listenAddr string) authorization.IntentAuthenticator {
+	authoriser, err := makeAuthoriser(authMode, listenAddr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	intent, err := makeIntentAuthenticator(mustGetClient(storageFolder), authoriser, authorization.ScopeFundsConfirmations)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	return intent
+}
+
+func mustGetFundsConfirmationConsent(storageFolder, identification string) aspsp.AuthorisedFundsConfirmationConsent {
+	consent, err := aspsp.NewFileFundsConfirmationConsentStore(storageFolder).Get(identification)
+	if err == aspsp.ErrNotFound {
+		fmt.Fprintln(os.Stderr, "No funds confirmation consent for this account, run cof consent first.")
+		os.Exit(1)
+	} else if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	return consent
+}
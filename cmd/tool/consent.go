@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/jmatosp/obclient/aspsp"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"net/http"
+	"os"
+	"time"
+)
+
+func newAuthStatusCmd(ctx context.Context, storageFolder string) *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Check the account access consent of the stored token at the bank",
+		Run: func(cmd *cobra.Command, args []string) {
+			stored := mustCheckConsent(ctx, storageFolder)
+			banner("Account access consent")
+			mustPrint(aspsp.StoredTokensTable([]aspsp.StoredToken{stored}))
+			if _, err := aspsp.NewFileTokenStorer(storageFolder).Get(); err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				fmt.Fprintln(os.Stderr, "Run auth again.")
+				os.Exit(1)
+			}
+		},
+	}
+}
+
+// mustCheckConsent gets the stored token consent status and expiration from the bank and stores them
+func mustCheckConsent(ctx context.Context, storageFolder string) aspsp.StoredToken {
+	storer := aspsp.NewFileTokenStorer(storageFolder)
+	stored := mustGetStoredToken(storageFolder)
+	if stored.ConsentId == "" {
+		fmt.Fprintln(os.Stderr, "The token is not bound to its consent, run auth again.")
+		os.Exit(1)
+	}
+
+	consenter := aspsp.NewAccountAccessConsenter(makeEndpointClient(endpoint("endpoints", aspsp.APIAccounts), mustGetGrantTokenSource(ctx, storageFolder)))
+	consent, err := consenter.GetContext(ctx, stored.ConsentId)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	stored.ConsentStatus = consent.Status
+	stored.ConsentExpires = consent.ExpirationDateTime
+	if consent.Status != aspsp.ConsentAuthorised {
+		stored.Reason = fmt.Sprintf("the bank reports it %s since %s", consent.Status, consent.StatusUpdateDateTime.Format(time.RFC3339))
+	}
+	if err = storer.Store(stored); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	return stored
+}
+
+// mustCheckRejectedConsent checks the consent when the bank forbids AIS resources, telling the
+// user to authenticate again when it is no longer authorised, other errors are left to the caller
+func mustCheckRejectedConsent(ctx context.Context, storageFolder string, err error) {
+	if rejected, ok := errors.Cause(err).(aspsp.StatusError); !ok || rejected.StatusCode != http.StatusForbidden {
+		return
+	}
+
+	stored := mustCheckConsent(ctx, storageFolder)
+	if stored.ConsentStatus != aspsp.ConsentAuthorised {
+		mustReauthenticate(aspsp.InvalidTokenError{ConsentId: stored.ConsentId, Status: stored.ConsentStatus, Reason: stored.Reason})
+	}
+}
+
+// invalidateRevoked invalidates the stored token when its consent is revoked
+func invalidateRevoked(storageFolder string, event aspsp.Event) {
+	reason := event.Reason
+	if reason == "" {
+		reason = "revoked at the bank"
+	}
+	if err := aspsp.NewFileTokenStorer(storageFolder).Invalidate(event.ResourceId, aspsp.ConsentRevoked, reason); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+	}
+}
+
+func mustReauthenticate(invalid aspsp.InvalidTokenError) {
+	fmt.Fprintf(os.Stderr, "Consent %s is %s: %s.\n", invalid.ConsentId, invalid.Status, invalid.Reason)
+	fmt.Fprintln(os.Stderr, "Run auth again.")
+	os.Exit(1)
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/jmatosp/obclient/aspsp"
+	"github.com/jmatosp/obclient/authorization"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"net/http"
+	"os"
+)
+
+func newEventsCmd(ctx context.Context, storageFolder string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "events",
+		Short: "Event notifications, pushed to a callback url or polled",
+	}
+
+	callbackCmd := &cobra.Command{
+		Use:   "callback",
+		Short: "List the callback urls event notifications are pushed to",
+		Run: func(cmd *cobra.Command, args []string) {
+			callbacks, err := makeCallbackURLRegister(ctx, storageFolder).ListContext(ctx)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			banner("Callback urls")
+			mustPrint(aspsp.CallbackURLsTable(callbacks))
+		},
+	}
+
+	var callbackUrl, version string
+	registerCmd := &cobra.Command{
+		Use:   "register",
+		Short: "Register the callback url, notifications are posted below it to " + aspsp.EventReceiverPath,
+		Run: func(cmd *cobra.Command, args []string) {
+			callback, err := makeCallbackURLRegister(ctx, storageFolder).RegisterContext(ctx, aspsp.CallbackURL{
+				URL:     callbackUrl,
+				Version: version,
+			})
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			banner("Callback url")
+			mustPrint(aspsp.CallbackURLsTable([]aspsp.CallbackURL{callback}))
+		},
+	}
+	registerCmd.Flags().StringVar(&callbackUrl, "url", "", "callback url, e.g. https://tpp.example.com/ob")
+	registerCmd.Flags().StringVar(&version, "version", "3.1", "event notification API version")
+	registerCmd.MarkFlagRequired("url")
+
+	var callbackId string
+	deleteCmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete a callback url",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := makeCallbackURLRegister(ctx, storageFolder).DeleteContext(ctx, callbackId); err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			fmt.Printf("Callback url %s deleted\n", callbackId)
+		},
+	}
+	deleteCmd.Flags().StringVar(&callbackId, "id", "", "callback url id")
+	deleteCmd.MarkFlagRequired("id")
+
+	callbackCmd.AddCommand(registerCmd)
+	callbackCmd.AddCommand(deleteCmd)
+
+	var listenAddr string
+	listenCmd := &cobra.Command{
+		Use:   "listen",
+		Short: "Receive pushed event notifications until interrupted",
+		Run: func(cmd *cobra.Command, args []string) {
+			receiver := aspsp.NewEventReceiver(mustMakeEventVerifier(ctx, storageFolder))
+			mux := http.NewServeMux()
+			mux.Handle(aspsp.EventReceiverPath, receiver)
+			server := &http.Server{Addr: listenAddr, Handler: mux}
+			go func() {
+				<-ctx.Done()
+				server.Close()
+			}()
+			go printEvents(ctx, storageFolder, receiver)
+
+			fmt.Printf("Waiting for event notifications on %s%s\n", listenAddr, aspsp.EventReceiverPath)
+			if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+	listenCmd.Flags().StringVar(&listenAddr, "listen", ":8082", "address to receive notifications on, behind the callback url")
+
+	var maxEvents int
+	pollCmd := &cobra.Command{
+		Use:   "poll",
+		Short: "Get and acknowledge the event notifications waiting at the bank",
+		Run: func(cmd *cobra.Command, args []string) {
+			poller := aspsp.NewEventPoller(makeEndpointClient(endpoint("eventEndpoints", aspsp.APIEvents), mustGetGrantTokenSource(ctx, storageFolder)), mustMakeEventVerifier(ctx, storageFolder), maxEvents)
+			polled := make(chan error, 1)
+			go func() {
+				polled <- poller.PollContext(ctx)
+			}()
+
+			var events []aspsp.Event
+			for {
+				select {
+				case event := <-poller.ResourceUpdates():
+					events = append(events, event)
+				case event := <-poller.ConsentRevocations():
+					invalidateRevoked(storageFolder, event)
+					events = append(events, event)
+				case err := <-polled:
+					if err != nil {
+						fmt.Fprintln(os.Stderr, err.Error())
+						os.Exit(1)
+					}
+					banner("Events")
+					mustPrint(aspsp.EventsTable(events))
+					return
+				}
+			}
+		},
+	}
+	pollCmd.Flags().IntVar(&maxEvents, "max-events", 0, "events per polling request, 0 lets the bank decide")
+
+	cmd.AddCommand(callbackCmd)
+	cmd.AddCommand(listenCmd)
+	cmd.AddCommand(pollCmd)
+	return cmd
+}
+
+// printEvents prints events as they come, invalidating the stored token when its consent is revoked
+func printEvents(ctx context.Context, storageFolder string, events aspsp.EventSource) {
+	for {
+		var event aspsp.Event
+		select {
+		case event = <-events.ResourceUpdates():
+		case event = <-events.ConsentRevocations():
+			invalidateRevoked(storageFolder, event)
+		case <-ctx.Done():
+			return
+		}
+		mustPrint(aspsp.EventsTable([]aspsp.Event{event}))
+	}
+}
+
+// mustMakeEventVerifier verifies notifications with the keys and issuer of the openid configuration
+func mustMakeEventVerifier(ctx context.Context, storageFolder string) aspsp.EventVerifier {
+	client, err := authorization.NewDiscoveryTransport(viper.GetStringSlice("rootCAs")).Client()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	configuration, err := authorization.FetchConfiguration(ctx, client, viper.GetString("openidConfiguration"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	return aspsp.NewEventVerifier(authorization.NewKeySet(client, configuration.JWKSURI), configuration.Issuer, mustGetClient(storageFolder).Id)
+}
+
+func makeCallbackURLRegister(ctx context.Context, storageFolder string) aspsp.CallbackURLRegister {
+	return aspsp.NewCallbackURLRegister(makeEndpointClient(endpoint("eventEndpoints", aspsp.APIEvents), mustGetGrantTokenSource(ctx, storageFolder)))
+}
+
+// mustGetGrantTokenSource uses a client credentials grant, as event notification and consent endpoints require
+func mustGetGrantTokenSource(ctx context.Context, storageFolder string) aspsp.TokenSource {
+	intent, err := makeIntentAuthenticator(mustGetClient(storageFolder), authorization.NewBrowserAuthoriser(), authorization.ScopeAccounts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	grant, err := intent.GrantContext(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	return aspsp.NewGrantTokenSource(grant)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/jmatosp/obclient/aspsp"
+	"github.com/spf13/cobra"
+	"os"
+	"time"
+)
+
+func newExportCmd(ctx context.Context, storageFolder string) *cobra.Command {
+	var format, accountId, from, to, out, rules string
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export booked transactions to ofx, qif or camt053",
+		Run: func(cmd *cobra.Command, args []string) {
+			exportTransactions(ctx, storageFolder, format, aspsp.AccountId(accountId), mustParseDate(from), mustParseEndDate(to), out, rules)
+		},
+	}
+	cmd.Flags().StringVar(&format, "format", aspsp.ExportOFX, "export format: ofx, qif or camt053")
+	cmd.Flags().StringVar(&accountId, "account", "", "account id")
+	cmd.Flags().StringVar(&from, "from", "", "from booking date, YYYY-MM-DD, defaults to one month before --to")
+	cmd.Flags().StringVar(&to, "to", "", "to booking date, YYYY-MM-DD, included, defaults to today")
+	cmd.Flags().StringVar(&out, "out", "", "file to write, defaults to stdout")
+	cmd.Flags().StringVar(&rules, "rules", defaultRulesFile(), rulesFlagUsage)
+	cmd.MarkFlagRequired("account")
+	return cmd
+}
+
+func exportTransactions(ctx context.Context, storageFolder, format string, accountId aspsp.AccountId, from, to time.Time, out, rules string) {
+	exporter, err := aspsp.NewExporter(format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if from.IsZero() {
+		from = to.AddDate(0, -1, 0)
+	}
+
+	account := mustGetAccount(ctx, storageFolder, accountId)
+	statement, err := aspsp.LoadExportStatement(ctx, account, from, to)
+	mustCheckResourceErr(ctx, storageFolder, err)
+	statement.Transactions = mustCategorise(rules, statement.Transactions)
+
+	if out == "" {
+		if err = exporter.Export(os.Stdout, statement); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
+	file, err := os.Create(out)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	err = exporter.Export(file, statement)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		// a partial file would be imported as a complete one
+		os.Remove(out)
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+}
@@ -1,130 +1,194 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"github.com/dgrijalva/jwt-go"
 	"github.com/jmatosp/obclient/aspsp"
 	"github.com/jmatosp/obclient/authorization"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"os"
+	"os/signal"
+	"time"
 )
 
 const cliBanner = "Open Banking CLI v0.0.1"
 
+var output string
+
 func main() {
 	viper.SetConfigName("config")
 	viper.AddConfigPath(".")
 	err := viper.ReadInConfig()
 	if err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 
 	storageFolder := viper.GetString("storageFolder")
 
-	rootCmd := &cobra.Command{Use: "obcli"}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	rootCmd := &cobra.Command{
+		Use: "obcli",
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			if _, err := aspsp.NewPrinter(output); err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+	rootCmd.PersistentFlags().StringVarP(&output, "output", "o", aspsp.FormatTable, "output format: table, json, csv or yaml")
 
 	clientRegister := &cobra.Command{
 		Use:   "register",
 		Short: "Dynamic register a new software client",
 		Run: func(cmd *cobra.Command, args []string) {
-			clientRegister(storageFolder)
+			clientRegister(ctx, storageFolder)
 		},
 	}
 
+	var authMode, listenAddr string
 	authorize := &cobra.Command{
 		Use:   "auth",
 		Short: "Authorize flow to use ASPSP services",
 		Run: func(cmd *cobra.Command, args []string) {
-			authorize(storageFolder)
+			authorize(ctx, storageFolder, authMode, listenAddr)
 		},
 	}
+	authorize.Flags().StringVar(&authMode, "mode", authModeBrowser, "consent mode: browser, paste, webhook or mock")
+	authorize.Flags().StringVar(&listenAddr, "listen", ":8081", "address the webhook mode waits for the callback on")
+	authorize.AddCommand(newAuthStatusCmd(ctx, storageFolder))
 
 	accountsCmd := &cobra.Command{
 		Use:   "accounts",
 		Short: "List accounts",
 		Run: func(cmd *cobra.Command, args []string) {
-			accountsList(storageFolder)
+			accountsList(ctx, storageFolder)
 		},
 	}
 
 	rootCmd.AddCommand(clientRegister)
 	rootCmd.AddCommand(authorize)
+	rootCmd.AddCommand(newLogoutCmd(ctx, storageFolder))
+	rootCmd.AddCommand(newTokenCmd(ctx, storageFolder))
 	rootCmd.AddCommand(accountsCmd)
+	rootCmd.AddCommand(newStandingOrdersCmd(ctx, storageFolder))
+	rootCmd.AddCommand(newDirectDebitsCmd(ctx, storageFolder))
+	rootCmd.AddCommand(newBeneficiariesCmd(ctx, storageFolder))
+	rootCmd.AddCommand(newTransactionsCmd(ctx, storageFolder))
+	rootCmd.AddCommand(newBalancesCmd(ctx, storageFolder))
+	rootCmd.AddCommand(newProductCmd(ctx, storageFolder))
+	rootCmd.AddCommand(newOffersCmd(ctx, storageFolder))
+	rootCmd.AddCommand(newPartyCmd(ctx, storageFolder))
+	rootCmd.AddCommand(newScheduledPaymentsCmd(ctx, storageFolder))
+	rootCmd.AddCommand(newStatementsCmd(ctx, storageFolder))
+	rootCmd.AddCommand(newExportCmd(ctx, storageFolder))
+	rootCmd.AddCommand(newSyncCmd(ctx, storageFolder))
+	rootCmd.AddCommand(newSummaryCmd(ctx, storageFolder))
+	rootCmd.AddCommand(newFundsConfirmationCmd(ctx, storageFolder))
+	rootCmd.AddCommand(newPayCmd(ctx, storageFolder))
+	rootCmd.AddCommand(newVRPCmd(ctx, storageFolder))
+	rootCmd.AddCommand(newEventsCmd(ctx, storageFolder))
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 }
 
-func accountsList(storageFolder string) {
-	fmt.Println(cliBanner)
-	fmt.Println("Accounts")
-	tokenStorer := aspsp.NewFileTokenStorer(storageFolder)
-	token, err := tokenStorer.Get()
-	if err != nil {
-		fmt.Println(err.Error())
-		os.Exit(1)
+func accountsList(ctx context.Context, storageFolder string) {
+	banner("Accounts")
+	accountLister := makeAccountLister(mustGetToken(ctx, storageFolder))
+	accounts, err := accountLister.ListContext(ctx)
+	mustCheckResourceErr(ctx, storageFolder, err)
+	mustPrint(aspsp.AccountsTable(accounts))
+}
+
+// banner is only printed for table output so structured outputs stay parsable
+func banner(title string) {
+	if output != aspsp.FormatTable {
+		return
 	}
-	accountLister := makeAccountLister(token)
-	_, err = accountLister.List()
+	fmt.Println(cliBanner)
+	fmt.Println(title)
+}
+
+func mustPrint(table aspsp.Table) {
+	printer, err := aspsp.NewPrinter(output)
 	if err != nil {
-		fmt.Println(err.Error())
+		fmt.Fprintln(os.Stderr, err.Error())
 		os.Exit(1)
 	}
-	accounts, err := accountLister.List()
-	if err != nil {
-		fmt.Println(err.Error())
+	if err = printer.Print(os.Stdout, table); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
 		os.Exit(1)
 	}
-	aspsp.NewAccountsPrinter().Print(accounts)
 }
 
-func authorize(storageFolder string) {
-	fmt.Println(cliBanner)
-	fmt.Println("Authorize")
-	storer := aspsp.NewClientStorer(storageFolder)
-	client, err := storer.Get()
-	if err == aspsp.ErrNotFound {
-		fmt.Println("This software client is not registered yet, register first.")
-		os.Exit(1)
-	} else if err != nil {
-		fmt.Println(err.Error())
+func authorize(ctx context.Context, storageFolder, authMode, listenAddr string) {
+	banner("Authorize")
+	client := mustGetClient(storageFolder)
+	authoriser, err := makeAuthoriser(authMode, listenAddr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
 		os.Exit(1)
 	}
-	authenticator, err := makeAuthenticator(client)
+	authenticator, err := makeAuthenticator(client, authoriser)
 	if err != nil {
-		fmt.Println(err.Error())
+		fmt.Fprintln(os.Stderr, err.Error())
 		os.Exit(1)
 	}
-	token, err := authenticator.Authenticate()
+	token, consent, err := authenticator.AuthenticateConsentContext(ctx)
 	if err != nil {
-		fmt.Println(err.Error())
+		fmt.Fprintln(os.Stderr, err.Error())
 		os.Exit(1)
 	}
+	stored := aspsp.StoredToken{
+		Token:         token,
+		ConsentId:     consent.ConsentId,
+		ConsentStatus: aspsp.ConsentAuthorised,
+	}
+	if token.ExpiresIn > 0 {
+		stored.TokenExpires = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
 	tokenStorer := aspsp.NewFileTokenStorer(storageFolder)
-	err = tokenStorer.Store(token)
+	err = tokenStorer.Store(stored)
 	if err != nil {
-		fmt.Println(err.Error())
+		fmt.Fprintln(os.Stderr, err.Error())
 		os.Exit(1)
 	}
 	fmt.Println("Got valid token")
 }
 
-func clientRegister(storageFolder string) {
-	fmt.Println(cliBanner)
+func mustGetClient(storageFolder string) authorization.Client {
+	client, err := aspsp.NewClientStorer(storageFolder).Get()
+	if err == aspsp.ErrNotFound {
+		fmt.Fprintln(os.Stderr, "This software client is not registered yet, register first.")
+		os.Exit(1)
+	} else if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	return client
+}
+
+func clientRegister(ctx context.Context, storageFolder string) {
+	banner("Client register")
 	storer := aspsp.NewClientStorer(storageFolder)
 	_, err := storer.Get()
 	if err == aspsp.ErrNotFound {
 		register, err := makeClientRegister()
 		if err != nil {
-			fmt.Println(err.Error())
+			fmt.Fprintln(os.Stderr, err.Error())
 			os.Exit(1)
 		}
 
-		client, err := register.Register()
+		client, err := register.RegisterContext(ctx)
 		if err != nil {
-			fmt.Println(err.Error())
+			fmt.Fprintln(os.Stderr, err.Error())
 			os.Exit(1)
 		}
 		storer.Store(client)
@@ -132,27 +196,98 @@ func clientRegister(storageFolder string) {
 		os.Exit(0)
 
 	} else if err != nil {
-		fmt.Println(err.Error())
+		fmt.Fprintln(os.Stderr, err.Error())
 		os.Exit(1)
 	}
-	fmt.Println("Client already registered, delete first to recreate")
+	fmt.Fprintln(os.Stderr, "Client already registered, delete first to recreate")
 	os.Exit(1)
 }
 
 func makeAccountLister(token authorization.Token) aspsp.AccountLister {
-	return aspsp.NewAccountLister(
+	return aspsp.NewAccountLister(makeResourceClient(token))
+}
+
+func makeResourceClient(token authorization.Token) aspsp.ResourceClient {
+	return makeEndpointClient(endpoint("endpoints", aspsp.APIAccounts), aspsp.NewStaticTokenSource(token))
+}
+
+// endpoint returns the endpoint set in config under key, e.g. pispEndpoints, or else the api endpoint of bankProfile
+func endpoint(key, api string) string {
+	if viper.IsSet(key) {
+		return viper.GetString(key)
+	}
+
+	var profile aspsp.BankProfile
+	if err := viper.UnmarshalKey("bankProfile", &profile); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	if profile.BaseURL == "" {
+		fmt.Fprintf(os.Stderr, "Neither %s nor bankProfile are configured.\n", key)
+		os.Exit(1)
+	}
+	if _, err := profile.Codec(); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	return profile.Endpoint(api)
+}
+
+// makeEndpointClient calls the resources of an API other than AIS, e.g. cbpiiEndpoints,
+// translating the payloads of the OB version in the endpoint path and signing consents and payments
+func makeEndpointClient(endpoint string, tokenSource aspsp.TokenSource) aspsp.ResourceClient {
+	version := aspsp.VersionOf(endpoint)
+	codec, err := aspsp.NewCodec(version)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	return aspsp.NewSigningResourceClient(
 		makeSecuredTransport(),
-		viper.GetString("endpoints"),
-		viper.GetString("fapiFinancialId"),
-		token,
+		endpoint,
+		tokenSource,
+		authorization.FapiHeaders{
+			FinancialId:       viper.GetString("fapiFinancialId"),
+			CustomerIPAddress: viper.GetString("customerIpAddress"),
+			CustomerUserAgent: viper.GetString("customerUserAgent"),
+		},
+		codec,
+		makeDetachedSigner(version),
+	)
+}
+
+// makeDetachedSigner signs the x-jws-signature of version as {organisationId}/{softwareStatementID}
+// with the signing key signingKeyId
+func makeDetachedSigner(version string) authorization.DetachedSigner {
+	issuer := viper.GetString("softwareStatementID")
+	if organisation := viper.GetString("organisationId"); organisation != "" {
+		issuer = organisation + "/" + issuer
+	}
+
+	return authorization.NewDetachedSigner(
+		authorization.NewSafeCertificates(viper.GetString("sigPublicKeyFile"), viper.GetString("sigPrivateKeyFile")),
+		jwt.SigningMethodPS256,
+		viper.GetString("signingKeyId"),
+		issuer,
+		aspsp.UnencodedPayload(version),
 	)
 }
 
 func makeSecuredTransport() authorization.Transport {
-	return authorization.NewSecureTransport(
+	policy := authorization.DefaultTransportPolicy
+	if viper.IsSet("maxRetries") {
+		policy.Retry.MaxRetries = viper.GetInt("maxRetries")
+	}
+	if viper.IsSet("maxConcurrentPerHost") {
+		policy.MaxConcurrentPerHost = viper.GetInt("maxConcurrentPerHost")
+	}
+
+	return authorization.NewSecureTransportWithPolicy(
 		viper.GetString("cerFile"),
 		viper.GetString("keyFile"),
 		viper.GetStringSlice("rootCAs"),
+		policy,
 	)
 }
 
@@ -170,15 +305,58 @@ func makeClientRegister() (authorization.ClientRegister, error) {
 		Build()
 }
 
-func makeAuthenticator(client authorization.Client) (authorization.Authenticator, error) {
+func makeAuthenticator(client authorization.Client, authoriser authorization.Authoriser) (authorization.Authenticator, error) {
 	return authorization.NewAuthenticatorBuilder().
 		WithWellKnown(viper.GetString("openidConfiguration")).
 		WithClient(client).
 		WithFapiFinancialId(viper.GetString("fapiFinancialId")).
-		WithAccessConsentEndpoint(viper.GetString("endpoints")).
+		WithAccessConsentEndpoint(endpoint("endpoints", aspsp.APIAccounts)).
 		WithCertFile(viper.GetString("cerFile")).
 		WithKeyFile(viper.GetString("keyFile")).
 		WithRootCAs(viper.GetStringSlice("rootCAs")).
 		WithRedirectUrl(viper.GetString("redirectUrl")).
+		WithSigPublicKeyFile(viper.GetString("sigPublicKeyFile")).
+		WithSigPrivateKeyFile(viper.GetString("sigPrivateKeyFile")).
+		WithAuthoriser(authoriser).
 		Build()
 }
+
+func makeIntentAuthenticator(client authorization.Client, authoriser authorization.Authoriser, scope string) (authorization.IntentAuthenticator, error) {
+	return authorization.NewAuthenticatorBuilder().
+		WithWellKnown(viper.GetString("openidConfiguration")).
+		WithClient(client).
+		WithFapiFinancialId(viper.GetString("fapiFinancialId")).
+		WithCertFile(viper.GetString("cerFile")).
+		WithKeyFile(viper.GetString("keyFile")).
+		WithRootCAs(viper.GetStringSlice("rootCAs")).
+		WithRedirectUrl(viper.GetString("redirectUrl")).
+		WithSigPublicKeyFile(viper.GetString("sigPublicKeyFile")).
+		WithSigPrivateKeyFile(viper.GetString("sigPrivateKeyFile")).
+		WithAuthoriser(authoriser).
+		BuildIntentAuthenticator(scope)
+}
+
+const (
+	authModeBrowser = "browser"
+	authModePaste   = "paste"
+	authModeWebhook = "webhook"
+	authModeMock    = "mock"
+)
+
+func makeAuthoriser(mode, listenAddr string) (authorization.Authoriser, error) {
+	switch mode {
+	case authModeBrowser:
+		return authorization.NewBrowserAuthoriser(), nil
+	case authModePaste:
+		return authorization.NewPasteAuthoriser(os.Stdin, os.Stdout), nil
+	case authModeWebhook:
+		return authorization.NewWebhookAuthoriser(listenAddr, os.Stdout), nil
+	case authModeMock:
+		client, err := authorization.NewDiscoveryTransport(viper.GetStringSlice("rootCAs")).Client()
+		if err != nil {
+			return nil, err
+		}
+		return authorization.NewHeadlessAuthoriser(client), nil
+	}
+	return nil, errors.Errorf("error unknown consent mode %s", mode)
+}
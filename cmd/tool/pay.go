@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jmatosp/obclient/aspsp"
+	"github.com/jmatosp/obclient/authorization"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"os"
+	"strings"
+)
+
+func newPayCmd(ctx context.Context, storageFolder string) *cobra.Command {
+	var to, toScheme, toName, from, fromScheme, amount, currency, reference string
+	var schedule, frequency, until, transferCurrency, rateType, rate, contract, instructionId string
+	var count int
+	var authMode, listenAddr string
+	cmd := &cobra.Command{
+		Use:   "pay",
+		Short: "Initiate a payment, scheduled with --schedule, a standing order with --frequency or international with --transfer-currency",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := checkPayFlags(cmd); err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			money, err := aspsp.ParseMoney(amount, currency)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+
+			if instructionId == "" {
+				instructionId = strings.Replace(uuid.New().String(), "-", "", -1)
+				fmt.Fprintf(os.Stderr, "Instruction %s, re-run with --instruction-id %s if this run fails so it is not paid twice\n", instructionId, instructionId)
+			}
+			order := aspsp.PaymentOrder{
+				Type:            aspsp.PaymentDomestic,
+				InstructionId:   instructionId,
+				Amount:          money,
+				CreditorAccount: aspsp.NewAccountIdentity(toScheme, to, toName, "", ""),
+				Reference:       reference,
+			}
+			if from != "" {
+				order.DebtorAccount = aspsp.NewAccountIdentity(fromScheme, from, "", "", "")
+			}
+			switch {
+			case transferCurrency != "":
+				order.Type = aspsp.PaymentInternational
+				order.CurrencyOfTransfer = transferCurrency
+				if rateType != "" {
+					order.ExchangeRate = &aspsp.ExchangeRate{
+						UnitCurrency: currency,
+						Rate:         rate,
+						RateType:     rateType,
+						ContractId:   contract,
+					}
+				}
+			case frequency != "":
+				order.Type = aspsp.PaymentDomesticStandingOrder
+				order.Frequency = frequency
+				order.FirstPaymentDateTime = mustParseDate(schedule)
+				order.NumberOfPayments = count
+				order.FinalPaymentDateTime = mustParseDate(until)
+			case schedule != "":
+				order.Type = aspsp.PaymentDomesticScheduled
+				order.RequestedExecutionDateTime = mustParseDate(schedule)
+			}
+
+			intent := mustMakePaymentIntent(storageFolder, authMode, listenAddr)
+			grant, err := intent.GrantContext(ctx)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+
+			consenter := aspsp.NewPaymentConsenter(makeEndpointClient(endpoint("pispEndpoints", aspsp.APIPayments), aspsp.NewGrantTokenSource(grant)))
+			consent, err := consenter.CreateContext(ctx, order)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			banner("Payment consent")
+			mustPrint(aspsp.PaymentConsentsTable([]aspsp.PaymentConsent{consent}))
+			if consent.Status == aspsp.ConsentConsumed || consent.Status == aspsp.ConsentAuthorised {
+				fmt.Fprintf(os.Stderr, "Instruction %s was already authorised, check its payment with the bank instead of paying again\n", instructionId)
+				return
+			}
+
+			token, err := intent.AuthoriseContext(ctx, authorization.AccessConsent{
+				ConsentId: consent.ConsentId,
+				Scope:     authorization.ScopePayments,
+			})
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+
+			submitter := aspsp.NewPaymentSubmitter(makeEndpointClient(endpoint("pispEndpoints", aspsp.APIPayments), aspsp.NewStaticTokenSource(token)))
+			payment, err := submitter.SubmitContext(ctx, consent)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			banner("Payment")
+			mustPrint(aspsp.PaymentsTable([]aspsp.Payment{payment}))
+		},
+	}
+	cmd.Flags().StringVar(&to, "to", "", "creditor account identification, e.g. sort code and account number")
+	cmd.Flags().StringVar(&toScheme, "to-scheme", schemeSortCodeAccountNumber, "creditor account scheme name")
+	cmd.Flags().StringVar(&toName, "to-name", "", "creditor account name")
+	cmd.Flags().StringVar(&from, "from", "", "debtor account identification, the PSU chooses it when empty")
+	cmd.Flags().StringVar(&fromScheme, "from-scheme", schemeSortCodeAccountNumber, "debtor account scheme name")
+	cmd.Flags().StringVar(&amount, "amount", "", "amount to pay, of every payment for standing orders, e.g. 120.50")
+	cmd.Flags().StringVar(&currency, "currency", "GBP", "amount currency")
+	cmd.Flags().StringVar(&reference, "reference", "", "payment reference")
+	cmd.Flags().StringVar(&schedule, "schedule", "", "execution date, YYYY-MM-DD, first payment date of standing orders")
+	cmd.Flags().StringVar(&frequency, "frequency", "", "standing order frequency code, e.g. EvryDay, IntrvlWkDay:01:03 or IntrvlMnthDay:01:15")
+	cmd.Flags().IntVar(&count, "count", 0, "number of standing order payments, defaults to open ended")
+	cmd.Flags().StringVar(&until, "until", "", "final standing order payment date, YYYY-MM-DD")
+	cmd.Flags().StringVar(&transferCurrency, "transfer-currency", "", "currency the creditor is paid in, makes the payment international")
+	cmd.Flags().StringVar(&rateType, "rate-type", "", "exchange rate type: Actual, Agreed or Indicative")
+	cmd.Flags().StringVar(&rate, "rate", "", "agreed exchange rate, transfer currency per unit of --currency")
+	cmd.Flags().StringVar(&contract, "contract", "", "agreed exchange rate contract identification")
+	cmd.Flags().StringVar(&instructionId, "instruction-id", "", "instruction identification, the one of a failed run makes the bank return its consent and payment instead of paying twice")
+	cmd.PersistentFlags().StringVar(&authMode, "mode", authModeBrowser, "consent mode: browser, paste, webhook or mock")
+	cmd.PersistentFlags().StringVar(&listenAddr, "listen", ":8081", "address the webhook mode waits for the callback on")
+	cmd.MarkFlagRequired("to")
+	cmd.MarkFlagRequired("amount")
+
+	var paymentType, paymentId string
+	statusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show a payment status",
+		Run: func(cmd *cobra.Command, args []string) {
+			grant, err := mustMakePaymentIntent(storageFolder, authModeBrowser, "").GrantContext(ctx)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			submitter := aspsp.NewPaymentSubmitter(makeEndpointClient(endpoint("pispEndpoints", aspsp.APIPayments), aspsp.NewGrantTokenSource(grant)))
+			payment, err := submitter.GetContext(ctx, paymentType, paymentId)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			banner("Payment")
+			mustPrint(aspsp.PaymentsTable([]aspsp.Payment{payment}))
+		},
+	}
+	statusCmd.Flags().StringVar(&paymentType, "type", aspsp.PaymentDomestic, "payment type: domestic, domestic-scheduled, domestic-standing-order or international")
+	statusCmd.Flags().StringVar(&paymentId, "payment", "", "payment id")
+	statusCmd.MarkFlagRequired("payment")
+
+	cmd.AddCommand(statusCmd)
+	cmd.AddCommand(newFilePaymentCmd(ctx, storageFolder, &authMode, &listenAddr))
+	return cmd
+}
+
+// checkPayFlags rejects flags the payment type does not use, rather than ignoring them
+func checkPayFlags(cmd *cobra.Command) error {
+	set := func(names ...string) []string {
+		var changed []string
+		for _, name := range names {
+			if cmd.Flags().Changed(name) {
+				changed = append(changed, "--"+name)
+			}
+		}
+		return changed
+	}
+	flags := cmd.Flags()
+
+	if flags.Changed("transfer-currency") {
+		if unsupported := set("schedule", "frequency", "count", "until"); len(unsupported) > 0 {
+			return errors.Errorf("error international payments do not support %s", strings.Join(unsupported, ", "))
+		}
+	} else if rate := set("rate-type", "rate", "contract"); len(rate) > 0 {
+		return errors.Errorf("error %s: only for international payments, see --transfer-currency", strings.Join(rate, ", "))
+	}
+	if !flags.Changed("frequency") {
+		if standingOrder := set("count", "until"); len(standingOrder) > 0 {
+			return errors.Errorf("error %s: only for standing orders, see --frequency", strings.Join(standingOrder, ", "))
+		}
+	}
+
+	rateType, _ := flags.GetString("rate-type")
+	agreed := set("rate", "contract")
+	switch {
+	case rateType == "Agreed" && len(agreed) != 2:
+		return errors.New("error an Agreed --rate-type needs --rate and --contract")
+	case rateType != "Agreed" && len(agreed) > 0:
+		return errors.Errorf("error %s: only for an Agreed --rate-type", strings.Join(agreed, ", "))
+	}
+
+	return nil
+}
+
+func mustMakePaymentIntent(storageFolder, authMode, listenAddr string) authorization.IntentAuthenticator {
+	authoriser, err := makeAuthoriser(authMode, listenAddr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	intent, err := makeIntentAuthenticator(mustGetClient(storageFolder), authoriser, authorization.ScopePayments)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	return intent
+}
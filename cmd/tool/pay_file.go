@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/jmatosp/obclient/aspsp"
+	"github.com/jmatosp/obclient/authorization"
+	"github.com/spf13/cobra"
+	"io/ioutil"
+	"os"
+)
+
+// newFilePaymentCmd pays a CSV of payees in a single file payment, authMode and listenAddr are the pay persistent flags
+func newFilePaymentCmd(ctx context.Context, storageFolder string, authMode, listenAddr *string) *cobra.Command {
+	var payees, scheme, reference, from, fromScheme, schedule, save string
+	cmd := &cobra.Command{
+		Use:   "file",
+		Short: "Pay a CSV of payees in one file payment, columns: name, identification, amount, currency, reference and optionally scheme",
+		Run: func(cmd *cobra.Command, args []string) {
+			reader, err := os.Open(payees)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			read, err := aspsp.ReadPayees(reader, scheme)
+			reader.Close()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			file, err := aspsp.NewPaymentFile(read)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			if save != "" {
+				if err = ioutil.WriteFile(save, file.Content, 0644); err != nil {
+					fmt.Fprintln(os.Stderr, err.Error())
+					os.Exit(1)
+				}
+			}
+
+			order := aspsp.FilePaymentOrder{
+				File:                       file,
+				Reference:                  reference,
+				RequestedExecutionDateTime: mustParseDate(schedule),
+			}
+			if from != "" {
+				order.DebtorAccount = aspsp.NewAccountIdentity(fromScheme, from, "", "", "")
+			}
+
+			intent := mustMakePaymentIntent(storageFolder, *authMode, *listenAddr)
+			grant, err := intent.GrantContext(ctx)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+
+			consenter := aspsp.NewFilePaymentConsenter(makeEndpointClient(endpoint("pispEndpoints", aspsp.APIPayments), aspsp.NewGrantTokenSource(grant)))
+			consent, err := consenter.CreateContext(ctx, order)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			if err = consenter.UploadContext(ctx, consent); err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			uploaded, err := consenter.GetContext(ctx, consent.ConsentId)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			consent.Status = uploaded.Status
+			banner("File payment consent")
+			mustPrint(aspsp.FilePaymentConsentsTable([]aspsp.FilePaymentConsent{consent}))
+
+			token, err := intent.AuthoriseContext(ctx, authorization.AccessConsent{
+				ConsentId: consent.ConsentId,
+				Scope:     authorization.ScopePayments,
+			})
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+
+			submitter := aspsp.NewFilePaymentSubmitter(makeEndpointClient(endpoint("pispEndpoints", aspsp.APIPayments), aspsp.NewStaticTokenSource(token)))
+			payment, err := submitter.SubmitContext(ctx, consent)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			banner("File payment")
+			mustPrint(aspsp.FilePaymentsTable([]aspsp.FilePayment{payment}))
+		},
+	}
+	cmd.Flags().StringVar(&payees, "payees", "", "CSV file of payees with a header row")
+	cmd.Flags().StringVar(&scheme, "scheme", schemeSortCodeAccountNumber, "payee account scheme name when the CSV has no scheme column")
+	cmd.Flags().StringVar(&reference, "reference", "", "file reference shown to the PSU")
+	cmd.Flags().StringVar(&from, "from", "", "debtor account identification, the PSU chooses it when empty")
+	cmd.Flags().StringVar(&fromScheme, "from-scheme", schemeSortCodeAccountNumber, "debtor account scheme name")
+	cmd.Flags().StringVar(&schedule, "schedule", "", "execution date, YYYY-MM-DD")
+	cmd.Flags().StringVar(&save, "save", "", "also write the payment file built from the CSV to this file")
+	cmd.MarkFlagRequired("payees")
+
+	var paymentId string
+	statusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show a file payment status",
+		Run: func(cmd *cobra.Command, args []string) {
+			payment, err := makeFilePaymentSubmitter(ctx, storageFolder).GetContext(ctx, paymentId)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			banner("File payment")
+			mustPrint(aspsp.FilePaymentsTable([]aspsp.FilePayment{payment}))
+		},
+	}
+	statusCmd.Flags().StringVar(&paymentId, "payment", "", "file payment id")
+	statusCmd.MarkFlagRequired("payment")
+
+	var out string
+	reportCmd := &cobra.Command{
+		Use:   "report",
+		Short: "Download the file payment report",
+		Run: func(cmd *cobra.Command, args []string) {
+			report, err := makeFilePaymentSubmitter(ctx, storageFolder).ReportContext(ctx, paymentId)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			if out == "" {
+				os.Stdout.Write(report)
+				return
+			}
+			if err = ioutil.WriteFile(out, report, 0644); err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			fmt.Printf("Report saved to %s\n", out)
+		},
+	}
+	reportCmd.Flags().StringVar(&paymentId, "payment", "", "file payment id")
+	reportCmd.Flags().StringVar(&out, "out", "", "file to write the report to, defaults to standard output")
+	reportCmd.MarkFlagRequired("payment")
+
+	cmd.AddCommand(statusCmd)
+	cmd.AddCommand(reportCmd)
+	return cmd
+}
+
+func makeFilePaymentSubmitter(ctx context.Context, storageFolder string) aspsp.FilePaymentSubmitter {
+	grant, err := mustMakePaymentIntent(storageFolder, authModeBrowser, "").GrantContext(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	return aspsp.NewFilePaymentSubmitter(makeEndpointClient(endpoint("pispEndpoints", aspsp.APIPayments), aspsp.NewGrantTokenSource(grant)))
+}
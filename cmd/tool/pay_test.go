@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCheckPayFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr bool
+	}{
+		{name: "domestic", args: []string{"--to", "80200110203348", "--amount", "10"}},
+		{name: "scheduled", args: []string{"--schedule", "2019-06-01"}},
+		{name: "standing order", args: []string{"--frequency", "EvryDay", "--schedule", "2019-06-01", "--count", "3"}},
+		{name: "standing order until", args: []string{"--frequency", "EvryDay", "--until", "2019-12-31"}},
+		{name: "international", args: []string{"--transfer-currency", "EUR", "--rate-type", "Actual"}},
+		{name: "international agreed rate", args: []string{"--transfer-currency", "EUR", "--rate-type", "Agreed", "--rate", "1.16", "--contract", "fx-1"}},
+		{name: "international standing order", args: []string{"--transfer-currency", "EUR", "--frequency", "EvryDay"}, wantErr: true},
+		{name: "international scheduled", args: []string{"--transfer-currency", "EUR", "--schedule", "2019-06-01"}, wantErr: true},
+		{name: "rate type of a domestic payment", args: []string{"--rate-type", "Actual"}, wantErr: true},
+		{name: "count without frequency", args: []string{"--count", "3"}, wantErr: true},
+		{name: "until without frequency", args: []string{"--schedule", "2019-06-01", "--until", "2019-12-31"}, wantErr: true},
+		{name: "agreed rate without contract", args: []string{"--transfer-currency", "EUR", "--rate-type", "Agreed", "--rate", "1.16"}, wantErr: true},
+		{name: "rate of an actual rate type", args: []string{"--transfer-currency", "EUR", "--rate-type", "Actual", "--rate", "1.16"}, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cmd := newPayCmd(context.Background(), t.TempDir())
+			if err := cmd.ParseFlags(test.args); err != nil {
+				t.Fatal(err)
+			}
+
+			err := checkPayFlags(cmd)
+			if test.wantErr && err == nil {
+				t.Error("flags accepted, want error")
+			}
+			if !test.wantErr && err != nil {
+				t.Errorf("flags rejected: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/jmatosp/obclient/aspsp"
+	"github.com/spf13/cobra"
+	"os"
+	"time"
+)
+
+const (
+	summaryViewTotals     = "totals"
+	summaryViewPeriods    = "periods"
+	summaryViewMerchants  = "merchants"
+	summaryViewCategories = "categories"
+)
+
+func newSummaryCmd(ctx context.Context, storageFolder string) *cobra.Command {
+	var accountId, from, to, groupBy, view, rules string
+	var top int
+	cmd := &cobra.Command{
+		Use:   "summary",
+		Short: "Summarise booked transactions, of all accounts unless --account is given",
+		Run: func(cmd *cobra.Command, args []string) {
+			summary(ctx, storageFolder, aspsp.AccountId(accountId), mustParseDate(from), mustParseDate(to), groupBy, view, top, rules)
+		},
+	}
+	cmd.Flags().StringVar(&accountId, "account", "", "account id")
+	cmd.Flags().StringVar(&from, "from", "", "from booking date, YYYY-MM-DD, defaults to one month before --to")
+	cmd.Flags().StringVar(&to, "to", "", "to booking date, YYYY-MM-DD, defaults to today")
+	cmd.Flags().StringVar(&groupBy, "group-by", aspsp.GroupByMonth, "periods grouping: day, week or month")
+	cmd.Flags().StringVar(&view, "view", summaryViewTotals, "view: totals, periods, merchants or categories")
+	cmd.Flags().IntVar(&top, "top", 10, "number of top merchants, 0 lists all")
+	cmd.Flags().StringVar(&rules, "rules", defaultRulesFile(), rulesFlagUsage+", categories take precedence over bank codes")
+	return cmd
+}
+
+func summary(ctx context.Context, storageFolder string, accountId aspsp.AccountId, from, to time.Time, groupBy, view string, top int, rules string) {
+	if err := aspsp.CheckGroupBy(groupBy); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	switch view {
+	case summaryViewTotals, summaryViewPeriods, summaryViewMerchants, summaryViewCategories:
+	default:
+		fmt.Fprintf(os.Stderr, "error unknown summary view %s\n", view)
+		os.Exit(1)
+	}
+
+	if to.IsZero() {
+		to = time.Now()
+	} else {
+		// the --to day is included
+		to = to.AddDate(0, 0, 1).Add(-time.Second)
+	}
+	if from.IsZero() {
+		from = to.AddDate(0, -1, 0)
+	}
+
+	var accounts []aspsp.Account
+	if accountId != "" {
+		accounts = append(accounts, mustGetAccount(ctx, storageFolder, accountId))
+	} else {
+		var err error
+		accounts, err = makeAccountLister(mustGetToken(ctx, storageFolder)).ListContext(ctx)
+		mustCheckResourceErr(ctx, storageFolder, err)
+	}
+
+	var summaries []aspsp.Summary
+	for _, account := range accounts {
+		transactions, err := account.TransactionsContext(ctx, from, to)
+		mustCheckResourceErr(ctx, storageFolder, err)
+		summary, err := aspsp.Summarise(account.Id(), from, to, mustCategorise(rules, transactions), groupBy, top)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		summaries = append(summaries, summary)
+	}
+
+	switch view {
+	case summaryViewTotals:
+		banner("Summary")
+		mustPrint(aspsp.SummaryTable(summaries))
+	case summaryViewPeriods:
+		banner("Summary by " + groupBy)
+		mustPrint(aspsp.SummaryPeriodsTable(summaries))
+	case summaryViewMerchants:
+		banner("Top merchants")
+		mustPrint(aspsp.SummaryMerchantsTable(summaries))
+	case summaryViewCategories:
+		banner("Summary by category")
+		mustPrint(aspsp.SummaryCategoriesTable(summaries))
+	}
+}
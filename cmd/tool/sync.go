@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/jmatosp/obclient/aspsp"
+	"github.com/spf13/cobra"
+	"os"
+	"time"
+)
+
+func newSyncCmd(ctx context.Context, storageFolder string) *cobra.Command {
+	var accountId string
+	var lookbackDays int
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Download new transactions into the local store, of all accounts unless --account is given",
+		Run: func(cmd *cobra.Command, args []string) {
+			syncTransactions(ctx, storageFolder, aspsp.AccountId(accountId), time.Duration(lookbackDays)*time.Hour*24)
+		},
+	}
+	cmd.Flags().StringVar(&accountId, "account", "", "account id")
+	cmd.Flags().IntVar(&lookbackDays, "lookback", 365, "days of transactions downloaded on the first sync")
+	return cmd
+}
+
+func syncTransactions(ctx context.Context, storageFolder string, accountId aspsp.AccountId, lookback time.Duration) {
+	banner("Sync")
+	token := mustGetToken(ctx, storageFolder)
+
+	accountIds := []aspsp.AccountId{accountId}
+	if accountId == "" {
+		accounts, err := makeAccountLister(token).ListContext(ctx)
+		mustCheckResourceErr(ctx, storageFolder, err)
+		accountIds = nil
+		for _, account := range accounts {
+			accountIds = append(accountIds, account.Id())
+		}
+	}
+
+	syncer := aspsp.NewTransactionSyncer(
+		aspsp.NewAccountResources(makeResourceClient(token)),
+		aspsp.NewFileTransactionStore(storageFolder),
+		lookback,
+	)
+
+	var results []aspsp.SyncResult
+	for _, id := range accountIds {
+		result, err := syncer.SyncContext(ctx, id)
+		mustCheckResourceErr(ctx, storageFolder, err)
+		results = append(results, result)
+	}
+	mustPrint(aspsp.SyncResultsTable(results))
+}
+
+func offlineTransactionsList(storageFolder string, accountId aspsp.AccountId, from, to time.Time, limit int, rules string) {
+	banner("Transactions (offline)")
+	stored, err := aspsp.NewFileTransactionStore(storageFolder).Get(accountId)
+	if err == aspsp.ErrNotFound {
+		fmt.Fprintln(os.Stderr, "No transactions stored for this account, run sync first.")
+		os.Exit(1)
+	} else if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	transactions := stored.Between(from, to)
+	if limit > 0 && len(transactions) > limit {
+		transactions = transactions[:limit]
+	}
+	mustPrint(aspsp.TransactionsTable(mustCategorise(rules, transactions)))
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/jmatosp/obclient/aspsp"
+	"github.com/jmatosp/obclient/authorization"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"os"
+)
+
+func newTokenCmd(ctx context.Context, storageFolder string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "token",
+		Short: "Stored access token",
+	}
+
+	statusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Introspect the stored access token at the bank",
+		Run: func(cmd *cobra.Command, args []string) {
+			stored := mustGetStoredToken(storageFolder)
+			introspector, err := makeTokenEndpointsBuilder(mustGetClient(storageFolder)).BuildTokenIntrospector()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			introspection, err := introspector.IntrospectContext(ctx, stored.Token.AccessToken, authorization.TokenTypeHintAccessToken)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			banner("Access token")
+			mustPrint(aspsp.IntrospectionsTable([]authorization.Introspection{introspection}))
+			if !introspection.Active {
+				fmt.Fprintln(os.Stderr, "The bank no longer accepts the token, run auth again.")
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.AddCommand(statusCmd)
+	return cmd
+}
+
+func newLogoutCmd(ctx context.Context, storageFolder string) *cobra.Command {
+	return &cobra.Command{
+		Use:   "logout",
+		Short: "Revoke the stored tokens at the bank and delete them, the consent is kept",
+		Run: func(cmd *cobra.Command, args []string) {
+			stored := mustGetStoredToken(storageFolder)
+			revoker, err := makeTokenEndpointsBuilder(mustGetClient(storageFolder)).BuildTokenRevoker()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			if stored.Token.RefreshToken != "" {
+				if err = revoker.RevokeContext(ctx, stored.Token.RefreshToken, authorization.TokenTypeHintRefreshToken); err != nil {
+					fmt.Fprintln(os.Stderr, err.Error())
+					os.Exit(1)
+				}
+			}
+			if err = revoker.RevokeContext(ctx, stored.Token.AccessToken, authorization.TokenTypeHintAccessToken); err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			if err = aspsp.NewFileTokenStorer(storageFolder).Delete(); err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			fmt.Println("Token revoked and deleted")
+		},
+	}
+}
+
+// mustGetStoredToken returns the stored token even when no longer valid, as it can still be revoked or introspected
+func mustGetStoredToken(storageFolder string) aspsp.StoredToken {
+	stored, err := aspsp.NewFileTokenStorer(storageFolder).Get()
+	if err == aspsp.ErrNotFound {
+		fmt.Fprintln(os.Stderr, "No token yet, run auth first.")
+		os.Exit(1)
+	} else if _, invalid := err.(aspsp.InvalidTokenError); err != nil && !invalid {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	return stored
+}
+
+// makeTokenEndpointsBuilder configures the client authentication used by the revocation and introspection endpoints
+func makeTokenEndpointsBuilder(client authorization.Client) *authorization.AuthenticatorBuilder {
+	return authorization.NewAuthenticatorBuilder().
+		WithWellKnown(viper.GetString("openidConfiguration")).
+		WithClient(client).
+		WithFapiFinancialId(viper.GetString("fapiFinancialId")).
+		WithCertFile(viper.GetString("cerFile")).
+		WithKeyFile(viper.GetString("keyFile")).
+		WithRootCAs(viper.GetStringSlice("rootCAs")).
+		WithRedirectUrl(viper.GetString("redirectUrl")).
+		WithSigPublicKeyFile(viper.GetString("sigPublicKeyFile")).
+		WithSigPrivateKeyFile(viper.GetString("sigPrivateKeyFile"))
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jmatosp/obclient/aspsp"
+	"github.com/jmatosp/obclient/authorization"
+	"github.com/spf13/cobra"
+	"os"
+	"strings"
+	"time"
+)
+
+func newVRPCmd(ctx context.Context, storageFolder string) *cobra.Command {
+	var name, authMode, listenAddr string
+	cmd := &cobra.Command{
+		Use:   "vrp",
+		Short: "Variable recurring payments, consents are kept by name and paid without the PSU",
+	}
+	cmd.PersistentFlags().StringVar(&name, "name", "sweeping", "name the consent is kept under")
+	cmd.PersistentFlags().StringVar(&authMode, "mode", authModeBrowser, "consent mode: browser, paste, webhook or mock")
+	cmd.PersistentFlags().StringVar(&listenAddr, "listen", ":8081", "address the webhook mode waits for the callback on")
+
+	var to, toScheme, toName, from, fromScheme, reference, maxAmount, currency, alignment, validFrom, validTo string
+	var limits []string
+	consentCmd := &cobra.Command{
+		Use:   "consent",
+		Short: "Create and authorise a sweeping VRP consent",
+		Run: func(cmd *cobra.Command, args []string) {
+			maximum, err := aspsp.ParseMoney(maxAmount, currency)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			request := aspsp.VRPConsentRequest{
+				CreditorAccount: aspsp.NewAccountIdentity(toScheme, to, toName, "", ""),
+				Reference:       reference,
+				ControlParameters: aspsp.VRPControlParameters{
+					VRPType:                 aspsp.VRPTypeSweeping,
+					ValidFromDateTime:       mustParseDate(validFrom),
+					ValidToDateTime:         mustParseDate(validTo),
+					MaximumIndividualAmount: maximum,
+					PeriodicLimits:          mustParseLimits(limits, alignment, currency),
+				},
+			}
+			if from != "" {
+				request.DebtorAccount = aspsp.NewAccountIdentity(fromScheme, from, "", "", "")
+			}
+
+			banner("VRP consent")
+			intent := mustMakePaymentIntent(storageFolder, authMode, listenAddr)
+			consent, err := makeVRPConsenter(ctx, intent).CreateContext(ctx, request)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+
+			token, err := intent.AuthoriseContext(ctx, authorization.AccessConsent{
+				ConsentId: consent.ConsentId,
+				Scope:     authorization.ScopePayments,
+			})
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			if token.RefreshToken == "" {
+				fmt.Fprintln(os.Stderr, "Warning: no refresh token was issued, payments need a new consent once the token expires")
+			}
+
+			mustStoreVRPConsent(storageFolder, aspsp.AuthorisedVRPConsent{Name: name, ConsentId: consent.ConsentId}, token)
+			fmt.Printf("VRP consent %s authorised and kept as %s\n", consent.ConsentId, name)
+		},
+	}
+	consentCmd.Flags().StringVar(&to, "to", "", "creditor account identification, e.g. sort code and account number")
+	consentCmd.Flags().StringVar(&toScheme, "to-scheme", schemeSortCodeAccountNumber, "creditor account scheme name")
+	consentCmd.Flags().StringVar(&toName, "to-name", "", "creditor account name")
+	consentCmd.Flags().StringVar(&from, "from", "", "debtor account identification, the PSU chooses it when empty")
+	consentCmd.Flags().StringVar(&fromScheme, "from-scheme", schemeSortCodeAccountNumber, "debtor account scheme name")
+	consentCmd.Flags().StringVar(&reference, "reference", "", "reference of every payment")
+	consentCmd.Flags().StringVar(&maxAmount, "max-amount", "", "maximum amount of a single payment")
+	consentCmd.Flags().StringVar(&currency, "currency", "GBP", "currency of amounts and limits")
+	consentCmd.Flags().StringSliceVar(&limits, "limit", nil, "periodic limit as PeriodType:amount, e.g. Month:1000, period types: Day, Week, Fortnight, Month, Half-year or Year")
+	consentCmd.Flags().StringVar(&alignment, "alignment", aspsp.PeriodAlignmentConsent, "periodic limit alignment: Consent or Calendar")
+	consentCmd.Flags().StringVar(&validFrom, "valid-from", "", "first day payments are allowed, YYYY-MM-DD")
+	consentCmd.Flags().StringVar(&validTo, "valid-to", "", "day the consent expires, YYYY-MM-DD")
+	consentCmd.MarkFlagRequired("to")
+	consentCmd.MarkFlagRequired("max-amount")
+
+	var amount, payReference string
+	var checkFunds bool
+	payCmd := &cobra.Command{
+		Use:   "pay",
+		Short: "Pay with the kept consent without the PSU, e.g. from a scheduled job",
+		Run: func(cmd *cobra.Command, args []string) {
+			stored := mustGetVRPConsent(storageFolder, name)
+			intent := mustMakePaymentIntent(storageFolder, authModeBrowser, "")
+			consent, err := makeVRPConsenter(ctx, intent).GetContext(ctx, stored.ConsentId)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			if consent.Status != aspsp.ConsentAuthorised {
+				fmt.Fprintf(os.Stderr, "VRP consent %s is %s, run vrp consent again.\n", consent.ConsentId, consent.Status)
+				os.Exit(1)
+			}
+			money, err := aspsp.ParseMoney(amount, consent.ControlParameters.MaximumIndividualAmount.Currency())
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+
+			token := mustGetVRPToken(ctx, storageFolder, intent, stored)
+			stored = mustGetVRPConsent(storageFolder, name)
+			submitter := aspsp.NewVRPSubmitter(makeEndpointClient(endpoint("pispEndpoints", aspsp.APIPayments), aspsp.NewStaticTokenSource(token)))
+			if checkFunds {
+				confirmation, err := submitter.ConfirmFundsContext(ctx, aspsp.FundsConfirmationRequest{
+					ConsentId: consent.ConsentId,
+					Reference: payReference,
+					Amount:    money,
+				})
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err.Error())
+					os.Exit(1)
+				}
+				if !confirmation.FundsAvailable {
+					fmt.Fprintf(os.Stderr, "Funds not available to pay %s, nothing paid\n", money)
+					os.Exit(1)
+				}
+			}
+
+			instruction := stored.PendingInstruction
+			if instruction.InstructionId != "" && instruction.Amount.String() == money.String() && instruction.Reference == payReference {
+				fmt.Fprintf(os.Stderr, "Resubmitting payment %s of a run that got no answer\n", instruction.InstructionId)
+			} else {
+				instruction = aspsp.VRPInstruction{
+					InstructionId: strings.Replace(uuid.New().String(), "-", "", -1),
+					Amount:        money,
+					Reference:     payReference,
+				}
+			}
+			stored.PendingInstruction = instruction
+			mustKeepVRPConsent(storageFolder, stored)
+
+			payment, err := submitter.SubmitContext(ctx, consent, instruction)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			stored.PendingInstruction = aspsp.VRPInstruction{}
+			mustKeepVRPConsent(storageFolder, stored)
+			banner("Variable recurring payment")
+			mustPrint(aspsp.VRPsTable([]aspsp.VRP{payment}))
+		},
+	}
+	payCmd.Flags().StringVar(&amount, "amount", "", "amount to pay, in the consent currency")
+	payCmd.Flags().StringVar(&payReference, "reference", "", "payment reference")
+	payCmd.Flags().BoolVar(&checkFunds, "check-funds", false, "confirm funds are available first and pay nothing otherwise")
+	payCmd.MarkFlagRequired("amount")
+
+	statusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the kept VRP consent",
+		Run: func(cmd *cobra.Command, args []string) {
+			stored := mustGetVRPConsent(storageFolder, name)
+			consent, err := makeVRPConsenter(ctx, mustMakePaymentIntent(storageFolder, authModeBrowser, "")).GetContext(ctx, stored.ConsentId)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			banner("VRP consent")
+			mustPrint(aspsp.VRPConsentsTable([]aspsp.VRPConsent{consent}))
+		},
+	}
+
+	revokeCmd := &cobra.Command{
+		Use:   "revoke",
+		Short: "Delete the VRP consent at the bank and locally",
+		Run: func(cmd *cobra.Command, args []string) {
+			stored := mustGetVRPConsent(storageFolder, name)
+			consenter := makeVRPConsenter(ctx, mustMakePaymentIntent(storageFolder, authModeBrowser, ""))
+			if err := consenter.DeleteContext(ctx, stored.ConsentId); err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			if err := aspsp.NewFileVRPConsentStore(storageFolder).Delete(name); err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			fmt.Printf("VRP consent %s revoked\n", stored.ConsentId)
+		},
+	}
+
+	cmd.AddCommand(consentCmd)
+	cmd.AddCommand(payCmd)
+	cmd.AddCommand(statusCmd)
+	cmd.AddCommand(revokeCmd)
+	return cmd
+}
+
+// mustParseLimits parses PeriodType:amount periodic limits
+func mustParseLimits(values []string, alignment, currency string) []aspsp.PeriodicLimit {
+	var limits []aspsp.PeriodicLimit
+	for _, value := range values {
+		parts := strings.SplitN(value, ":", 2)
+		if len(parts) != 2 {
+			fmt.Fprintf(os.Stderr, "invalid limit %s, expected PeriodType:amount\n", value)
+			os.Exit(1)
+		}
+		amount, err := aspsp.ParseMoney(parts[1], currency)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		limits = append(limits, aspsp.PeriodicLimit{
+			PeriodType:      parts[0],
+			PeriodAlignment: alignment,
+			Amount:          amount,
+		})
+	}
+	return limits
+}
+
+func makeVRPConsenter(ctx context.Context, intent authorization.IntentAuthenticator) aspsp.VRPConsenter {
+	grant, err := intent.GrantContext(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	return aspsp.NewVRPConsenter(makeEndpointClient(endpoint("pispEndpoints", aspsp.APIPayments), aspsp.NewGrantTokenSource(grant)))
+}
+
+// mustGetVRPToken returns the kept token, refreshed and kept again when it is about to expire
+func mustGetVRPToken(ctx context.Context, storageFolder string, intent authorization.IntentAuthenticator, stored aspsp.AuthorisedVRPConsent) authorization.Token {
+	if !stored.TokenExpiring(time.Now(), time.Minute) {
+		return stored.Token
+	}
+
+	token, err := intent.RefreshContext(ctx, stored.Token)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		fmt.Fprintln(os.Stderr, "The VRP consent token can not be refreshed, run vrp consent again.")
+		os.Exit(1)
+	}
+	mustStoreVRPConsent(storageFolder, stored, token)
+	return token
+}
+
+func mustStoreVRPConsent(storageFolder string, consent aspsp.AuthorisedVRPConsent, token authorization.Token) {
+	consent.Token = token
+	consent.TokenExpires = time.Time{}
+	if token.ExpiresIn > 0 {
+		consent.TokenExpires = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	mustKeepVRPConsent(storageFolder, consent)
+}
+
+func mustKeepVRPConsent(storageFolder string, consent aspsp.AuthorisedVRPConsent) {
+	if err := aspsp.NewFileVRPConsentStore(storageFolder).Store(consent); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+}
+
+func mustGetVRPConsent(storageFolder, name string) aspsp.AuthorisedVRPConsent {
+	consent, err := aspsp.NewFileVRPConsentStore(storageFolder).Get(name)
+	if err == aspsp.ErrNotFound {
+		fmt.Fprintf(os.Stderr, "No VRP consent named %s, run vrp consent first.\n", name)
+		os.Exit(1)
+	} else if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	return consent
+}